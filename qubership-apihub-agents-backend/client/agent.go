@@ -97,7 +97,7 @@ func (a agentClientImpl) StartDiscovery(ctx context.Context, namespace string, w
 	req := a.makeRequest(ctx)
 	resp, err := req.Post(fmt.Sprintf("%s/api/v2/namespaces/%s/workspaces/%s/discover?failOnError=%v", agentUrl, namespace, workspaceId, failOnError))
 	if err != nil {
-		return fmt.Errorf("failed to start discovery for namespace - %s. Error - %s", namespace, err.Error())
+		return fmt.Errorf("failed to start discovery for namespace - %s. Error - %w", namespace, err)
 	}
 
 	if resp.StatusCode() != http.StatusAccepted {
@@ -116,7 +116,7 @@ func (a agentClientImpl) ListServices_deprecated(ctx context.Context, namespace
 	req := a.makeRequest(ctx)
 	resp, err := req.Get(fmt.Sprintf("%s/api/v2/namespaces/%s/workspaces/%s/services", agentUrl, namespace, workspaceId))
 	if err != nil {
-		return nil, fmt.Errorf("failed to get service for namespace - %s. Error - %s", namespace, err.Error())
+		return nil, fmt.Errorf("failed to get service for namespace - %s. Error - %w", namespace, err)
 	}
 
 	if resp.StatusCode() != http.StatusOK {
@@ -140,7 +140,7 @@ func (a agentClientImpl) ListServices(ctx context.Context, namespace string, wor
 	req := a.makeRequest(ctx)
 	resp, err := req.Get(fmt.Sprintf("%s/api/v3/namespaces/%s/workspaces/%s/services", agentUrl, namespace, workspaceId))
 	if err != nil {
-		return nil, fmt.Errorf("failed to get service for namespace - %s. Error - %s", namespace, err.Error())
+		return nil, fmt.Errorf("failed to get service for namespace - %s. Error - %w", namespace, err)
 	}
 
 	if resp.StatusCode() != http.StatusOK {
@@ -164,7 +164,7 @@ func (a agentClientImpl) GetServiceSpecification(ctx context.Context, namespace
 	req := a.makeRequest(ctx)
 	resp, err := req.Get(fmt.Sprintf("%s/api/v2/namespaces/%s/workspaces/%s/services/%s/specs/%s", agentUrl, namespace, workspaceId, url.PathEscape(serviceId), url.PathEscape(fileId)))
 	if err != nil {
-		return nil, fmt.Errorf("failed to get service specification. Error - %s", err.Error())
+		return nil, fmt.Errorf("failed to get service specification. Error - %w", err)
 	}
 	if resp.StatusCode() != http.StatusOK {
 		if resp.StatusCode() == http.StatusNotFound || resp.StatusCode() == http.StatusFailedDependency {
@@ -191,7 +191,7 @@ func (a agentClientImpl) SendEmptyServiceRequest(namespace string, serviceId str
 	proxyUrl = proxyUrl + requestPath
 	resp, err := req.Execute(strings.ToUpper(requestMethod), proxyUrl)
 	if err != nil {
-		return -1, fmt.Errorf("failed to execute '%v %v' request. Error - %s", requestMethod, proxyUrl, err.Error())
+		return -1, fmt.Errorf("failed to execute '%v %v' request. Error - %w", requestMethod, proxyUrl, err)
 	}
 	proxyError := resp.Header().Get(CustomProxyErrorHeader)
 	if proxyError != "" {
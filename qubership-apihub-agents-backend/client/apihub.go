@@ -1,19 +1,20 @@
 package client
 
 import (
-	"bytes"
 	"context"
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/url"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/Netcracker/qubership-apihub-agents-backend/exception"
 	"github.com/Netcracker/qubership-apihub-agents-backend/secctx"
+	"github.com/Netcracker/qubership-apihub-agents-backend/utils"
 	"github.com/Netcracker/qubership-apihub-agents-backend/view"
 	log "github.com/sirupsen/logrus"
 	"gopkg.in/resty.v1"
@@ -27,14 +28,21 @@ type ApihubClient interface {
 	GetPackageById(ctx context.Context, id string) (*view.SimplePackage, error)
 	GetPackageByServiceName(ctx context.Context, workspaceId string, serviceName string) (*view.PackagesInfo, error)
 	CreatePackage(ctx context.Context, pkg view.PackageCreateRequest) (string, error)
+	UpdatePackage(ctx context.Context, id string, pkg view.PackageUpdateRequest) error
+	DeletePackage(ctx context.Context, id string) error
 	GetPackages(ctx context.Context, searchReq view.PackagesSearchReq) (*view.Packages, error)
 	GetUserPackagesPromoteStatuses(ctx context.Context, packagesReq view.PackagesReq) (view.AvailablePackagePromoteStatuses, error)
 	GetVersion(ctx context.Context, id, version string) (*view.VersionContent, error)
-	Publish(ctx context.Context, config view.BuildConfig, src []byte, clientBuild bool, builderId string, saveSources bool, dependencies []string) (string, error)
+	Publish(ctx context.Context, config *view.BuildConfig, src io.Reader, clientBuild bool, builderId string, saveSources bool, dependencies []string) (string, error)
 	GetVersions(ctx context.Context, packageId string, searchReq view.VersionSearchRequest) (*view.PublishedVersionsView, error)
 	DeleteVersionsRecursively(ctx context.Context, packageId string, req view.DeleteVersionsRecursivelyReq) (string, error)
+	DeleteVersion(ctx context.Context, packageId string, version string) error
+	UpdateVersionMeta(ctx context.Context, packageId string, version string, patch view.VersionPatchReq) (*view.VersionContent, error)
 	GetVersionReferences(ctx context.Context, id, version string) (*view.VersionReferences, error)
+	GetVersionChangesSummary(ctx context.Context, packageId string, version string, previousVersionPackageId string, previousVersion string) (*view.VersionChangesSummary, error)
 	GetVersionRestOperationsWithData(ctx context.Context, packageId string, version string, limit int, page int) (*view.RestOperations, error)
+	GetVersionDocuments(ctx context.Context, packageId string, version string, limit int, page int) (*view.VersionDocuments, error)
+	GetVersionDocumentRaw(ctx context.Context, packageId string, version string, slug string) ([]byte, error)
 	GetPublishStatuses(ctx context.Context, packageId string, publishIds []string) ([]view.PublishStatusResponse, error)
 	GetApiKeyById(ctx context.Context, apiKeyId string) (*view.ApihubApiKeyView, error)
 	GetUserById(ctx context.Context, userId string) (*view.User, error)
@@ -45,13 +53,18 @@ func NewApihubClient(apihubUrl string, accessToken string) ApihubClient {
 	tr := http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
 	cl := http.Client{Transport: &tr, Timeout: time.Second * 60}
 	client := resty.NewWithClient(&cl)
-	return &apihubClientImpl{client: client, apihubUrl: apihubUrl, accessToken: accessToken}
+	// sources are streamed while the publish request is being sent, so it takes longer than other requests
+	publishClient := http.Client{Transport: &tr, Timeout: publishTimeout}
+	return &apihubClientImpl{client: client, publishClient: &publishClient, apihubUrl: apihubUrl, accessToken: accessToken}
 }
 
+const publishTimeout = time.Minute * 10
+
 type apihubClientImpl struct {
-	client      *resty.Client
-	apihubUrl   string
-	accessToken string
+	client        *resty.Client
+	publishClient *http.Client
+	apihubUrl     string
+	accessToken   string
 }
 
 func (a apihubClientImpl) CheckAuthToken(ctx context.Context, token string) (bool, error) {
@@ -197,6 +210,42 @@ func (a apihubClientImpl) CreatePackage(ctx context.Context, pkg view.PackageCre
 	return res.Id, nil
 }
 
+func (a apihubClientImpl) UpdatePackage(ctx context.Context, id string, pkg view.PackageUpdateRequest) error {
+	req := a.makeRequest(ctx)
+	req.SetBody(pkg)
+
+	resp, err := req.Patch(fmt.Sprintf("%s/api/v2/packages/%s", a.apihubUrl, url.PathEscape(id)))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		if authErr := checkUnauthorized(resp); authErr != nil {
+			return authErr
+		}
+		return fmt.Errorf("failed to update package %s: status code %d", id, resp.StatusCode())
+	}
+	return nil
+}
+
+func (a apihubClientImpl) DeletePackage(ctx context.Context, id string) error {
+	req := a.makeRequest(ctx)
+
+	resp, err := req.Delete(fmt.Sprintf("%s/api/v2/packages/%s", a.apihubUrl, url.PathEscape(id)))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode() != http.StatusNoContent && resp.StatusCode() != http.StatusOK {
+		if authErr := checkUnauthorized(resp); authErr != nil {
+			return authErr
+		}
+		if resp.StatusCode() == http.StatusNotFound {
+			return nil
+		}
+		return fmt.Errorf("failed to delete package %s: status code %d", id, resp.StatusCode())
+	}
+	return nil
+}
+
 func (a apihubClientImpl) GetPackages(ctx context.Context, searchReq view.PackagesSearchReq) (*view.Packages, error) {
 	req := a.makeRequest(ctx)
 
@@ -326,61 +375,78 @@ func (a apihubClientImpl) GetVersion(ctx context.Context, id, version string) (*
 	return &versionContent, nil
 }
 
-func (a apihubClientImpl) Publish(ctx context.Context, config view.BuildConfig, src []byte, clientBuild bool, builderId string, saveSources bool, dependencies []string) (string, error) {
-	req := a.makeRequest(ctx)
-
-	confBytes, err := json.Marshal(config)
-	if err != nil {
-		return "", err
-	}
-
+// Publish streams the multipart request to APIHUB, src is read while the request is being sent and may be nil for packages without sources.
+// The config is marshalled after src is read, so the src writer can set config metadata calculated from the streamed content
+func (a apihubClientImpl) Publish(ctx context.Context, config *view.BuildConfig, src io.Reader, clientBuild bool, builderId string, saveSources bool, dependencies []string) (string, error) {
 	depBytes, err := json.Marshal(dependencies)
 	if err != nil {
 		return "", err
 	}
 
-	var data []*resty.MultipartField
-	data = append(data, &resty.MultipartField{
-		Param:  "config",
-		Reader: bytes.NewReader(confBytes),
-	})
-	data = append(data, &resty.MultipartField{
-		Param:  "clientBuild",
-		Reader: strings.NewReader(strconv.FormatBool(clientBuild)),
-	})
-	data = append(data, &resty.MultipartField{
-		Param:  "saveSources",
-		Reader: strings.NewReader(strconv.FormatBool(saveSources)),
-	})
-	data = append(data, &resty.MultipartField{
-		Param:  "dependencies",
-		Reader: bytes.NewReader(depBytes),
-	})
-	if builderId != "" {
-		data = append(data, &resty.MultipartField{
-			Param:  "builderId",
-			Reader: strings.NewReader(builderId),
-		})
+	fields := [][2]string{
+		{"clientBuild", strconv.FormatBool(clientBuild)},
+		{"saveSources", strconv.FormatBool(saveSources)},
+		{"dependencies", string(depBytes)},
 	}
+	if builderId != "" {
+		fields = append(fields, [2]string{"builderId", builderId})
+	}
+
+	bodyReader, bodyWriter := io.Pipe()
+	defer bodyReader.Close() // unblocks the writer if the request failed before the body is sent
+	mw := multipart.NewWriter(bodyWriter)
+	utils.SafeAsync(func() {
+		writeErr := func() error {
+			for _, field := range fields {
+				if err := mw.WriteField(field[0], field[1]); err != nil {
+					return err
+				}
+			}
+			if src != nil {
+				fw, err := mw.CreateFormFile("sources", "sources.zip")
+				if err != nil {
+					return err
+				}
+				if _, err = io.Copy(fw, src); err != nil {
+					return err
+				}
+			}
+			confBytes, err := json.Marshal(config)
+			if err != nil {
+				return err
+			}
+			if err = mw.WriteField("config", string(confBytes)); err != nil {
+				return err
+			}
+			return mw.Close()
+		}()
+		bodyWriter.CloseWithError(writeErr)
+	})
 
-	if src != nil {
-		req.SetFileReader("sources", "sources.zip", bytes.NewReader(src))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/api/v2/packages/%s/publish", a.apihubUrl, url.PathEscape(config.PackageId)), bodyReader)
+	if err != nil {
+		return "", err
 	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	a.setAuthHeader(ctx, req.Header)
 
-	req.SetMultipartFields(data...)
-
-	resp, err := req.Post(fmt.Sprintf("%s/api/v2/packages/%s/publish", a.apihubUrl, url.PathEscape(config.PackageId)))
+	resp, err := a.publishClient.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("failed to build and publish package %s: %s", config.PackageId, err.Error())
 	}
-	if !(resp.StatusCode() == http.StatusAccepted || resp.StatusCode() == http.StatusNoContent) {
-		if authErr := checkUnauthorized(resp); authErr != nil {
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to build and publish package %s: %s", config.PackageId, err.Error())
+	}
+	if !(resp.StatusCode == http.StatusAccepted || resp.StatusCode == http.StatusNoContent) {
+		if authErr := checkUnauthorizedStatus(resp.StatusCode); authErr != nil {
 			return "", authErr
 		}
-		return "", fmt.Errorf("failed to build and publish package %s: status code = %d, body = %s", config.PackageId, resp.StatusCode(), string(resp.Body()))
+		return "", fmt.Errorf("failed to build and publish package %s: status code = %d, body = %s", config.PackageId, resp.StatusCode, string(respBody))
 	}
 	var publishResponse view.PublishId
-	if err = json.Unmarshal(resp.Body(), &publishResponse); err != nil {
+	if err = json.Unmarshal(respBody, &publishResponse); err != nil {
 		return "", err
 	}
 	return publishResponse.PublishId, nil
@@ -496,6 +562,32 @@ func (a apihubClientImpl) GetVersionReferences(ctx context.Context, id, version
 	return &versionReferences, nil
 }
 
+func (a apihubClientImpl) GetVersionChangesSummary(ctx context.Context, packageId string, version string, previousVersionPackageId string, previousVersion string) (*view.VersionChangesSummary, error) {
+	req := a.makeRequest(ctx)
+	req.SetQueryParam("previousVersionPackageId", previousVersionPackageId)
+	req.SetQueryParam("previousVersion", previousVersion)
+	resp, err := req.Get(fmt.Sprintf("%s/api/v2/packages/%s/versions/%s/changes/summary", a.apihubUrl, url.PathEscape(packageId), url.PathEscape(version)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get version %s changes summary for id %s: %s", version, packageId, err.Error())
+	}
+
+	if resp.StatusCode() != http.StatusOK {
+		if resp.StatusCode() == http.StatusNotFound {
+			return nil, nil
+		}
+		if authErr := checkUnauthorized(resp); authErr != nil {
+			return nil, authErr
+		}
+		return nil, fmt.Errorf("failed to get version %s changes summary for id %s: status code %d %v", version, packageId, resp.StatusCode(), err)
+	}
+	var changesSummary view.VersionChangesSummary
+	err = json.Unmarshal(resp.Body(), &changesSummary)
+	if err != nil {
+		return nil, err
+	}
+	return &changesSummary, nil
+}
+
 func (a apihubClientImpl) GetVersionRestOperationsWithData(ctx context.Context, packageId string, version string, limit int, page int) (*view.RestOperations, error) {
 	req := a.makeRequest(ctx)
 	resp, err := req.Get(fmt.Sprintf("%s/api/v2/packages/%s/versions/%s/rest/operations?includeData=true&limit=%d&page=%d", a.apihubUrl, url.PathEscape(packageId), url.PathEscape(version), limit, page))
@@ -521,6 +613,92 @@ func (a apihubClientImpl) GetVersionRestOperationsWithData(ctx context.Context,
 	return &restOperations, nil
 }
 
+func (a apihubClientImpl) GetVersionDocuments(ctx context.Context, packageId string, version string, limit int, page int) (*view.VersionDocuments, error) {
+	req := a.makeRequest(ctx)
+	resp, err := req.Get(fmt.Sprintf("%s/api/v2/packages/%s/versions/%s/documents?limit=%d&page=%d", a.apihubUrl, url.PathEscape(packageId), url.PathEscape(version), limit, page))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get version documents. Error - %s", err.Error())
+	}
+
+	if resp.StatusCode() != http.StatusOK {
+		if resp.StatusCode() == http.StatusNotFound {
+			return nil, nil
+		}
+		if authErr := checkUnauthorized(resp); authErr != nil {
+			return nil, authErr
+		}
+		return nil, fmt.Errorf("failed to get version documents: status code %d %v", resp.StatusCode(), err)
+	}
+
+	var documents view.VersionDocuments
+	err = json.Unmarshal(resp.Body(), &documents)
+	if err != nil {
+		return nil, err
+	}
+	return &documents, nil
+}
+
+func (a apihubClientImpl) GetVersionDocumentRaw(ctx context.Context, packageId string, version string, slug string) ([]byte, error) {
+	req := a.makeRequest(ctx)
+	resp, err := req.Get(fmt.Sprintf("%s/api/v2/packages/%s/versions/%s/files/%s/raw", a.apihubUrl, url.PathEscape(packageId), url.PathEscape(version), url.PathEscape(slug)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get version document %s. Error - %s", slug, err.Error())
+	}
+
+	if resp.StatusCode() != http.StatusOK {
+		if resp.StatusCode() == http.StatusNotFound {
+			return nil, nil
+		}
+		if authErr := checkUnauthorized(resp); authErr != nil {
+			return nil, authErr
+		}
+		return nil, fmt.Errorf("failed to get version document %s: status code %d %v", slug, resp.StatusCode(), err)
+	}
+	return resp.Body(), nil
+}
+
+func (a apihubClientImpl) DeleteVersion(ctx context.Context, packageId string, version string) error {
+	req := a.makeRequest(ctx)
+	resp, err := req.Delete(fmt.Sprintf("%s/api/v2/packages/%s/versions/%s", a.apihubUrl, url.PathEscape(packageId), url.PathEscape(version)))
+	if err != nil {
+		return fmt.Errorf("failed to delete version %s of package %s: %s", version, packageId, err.Error())
+	}
+	if resp.StatusCode() != http.StatusNoContent && resp.StatusCode() != http.StatusOK {
+		if authErr := checkUnauthorized(resp); authErr != nil {
+			return authErr
+		}
+		if resp.StatusCode() == http.StatusNotFound {
+			return nil
+		}
+		return fmt.Errorf("failed to delete version %s of package %s: status code %d", version, packageId, resp.StatusCode())
+	}
+	return nil
+}
+
+func (a apihubClientImpl) UpdateVersionMeta(ctx context.Context, packageId string, version string, patch view.VersionPatchReq) (*view.VersionContent, error) {
+	req := a.makeRequest(ctx)
+	req.SetBody(patch)
+	resp, err := req.Patch(fmt.Sprintf("%s/api/v2/packages/%s/versions/%s", a.apihubUrl, url.PathEscape(packageId), url.PathEscape(version)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update version %s of package %s: %s", version, packageId, err.Error())
+	}
+	if resp.StatusCode() != http.StatusOK {
+		if resp.StatusCode() == http.StatusNotFound {
+			return nil, nil
+		}
+		if authErr := checkUnauthorized(resp); authErr != nil {
+			return nil, authErr
+		}
+		return nil, fmt.Errorf("failed to update version %s of package %s: status code %d %s", version, packageId, resp.StatusCode(), string(resp.Body()))
+	}
+	var versionContent view.VersionContent
+	err = json.Unmarshal(resp.Body(), &versionContent)
+	if err != nil {
+		return nil, err
+	}
+	return &versionContent, nil
+}
+
 func (a apihubClientImpl) GetPublishStatuses(ctx context.Context, packageId string, publishIds []string) ([]view.PublishStatusResponse, error) {
 	req := a.makeRequest(ctx)
 	req.SetBody(map[string]interface{}{
@@ -608,13 +786,20 @@ func (a apihubClientImpl) GetSystemInfo(ctx context.Context) (*view.ApihubSystem
 }
 
 func checkUnauthorized(resp *resty.Response) error {
-	if resp != nil && (resp.StatusCode() == http.StatusUnauthorized || resp.StatusCode() == http.StatusForbidden) {
+	if resp == nil {
+		return nil
+	}
+	return checkUnauthorizedStatus(resp.StatusCode())
+}
+
+func checkUnauthorizedStatus(statusCode int) error {
+	if statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden {
 		log.Errorf("Incorrect api key detected!")
 		return &exception.CustomError{
 			Status:  http.StatusFailedDependency,
 			Code:    exception.NoApihubAccess,
 			Message: exception.NoApihubAccessMsg,
-			Params:  map[string]interface{}{"code": strconv.Itoa(resp.StatusCode())},
+			Params:  map[string]interface{}{"code": strconv.Itoa(statusCode)},
 		}
 	}
 	return nil
@@ -623,17 +808,20 @@ func checkUnauthorized(resp *resty.Response) error {
 func (a apihubClientImpl) makeRequest(ctx context.Context) *resty.Request {
 	req := a.client.R()
 	req.SetContext(ctx)
+	a.setAuthHeader(ctx, req.Header)
+	return req
+}
 
+func (a apihubClientImpl) setAuthHeader(ctx context.Context, header http.Header) {
 	if secctx.IsSystem(ctx) {
-		req.SetHeader("api-key", a.accessToken)
+		header.Set("api-key", a.accessToken)
 	} else {
 		if secctx.GetUserToken(ctx) != "" {
-			req.SetHeader("Authorization", fmt.Sprintf("Bearer %s", secctx.GetUserToken(ctx)))
+			header.Set("Authorization", fmt.Sprintf("Bearer %s", secctx.GetUserToken(ctx)))
 		} else if secctx.GetApiKey(ctx) != "" {
-			req.SetHeader("api-key", secctx.GetApiKey(ctx))
+			header.Set("api-key", secctx.GetApiKey(ctx))
 		} else if secctx.GetPersonalAccessToken(ctx) != "" {
-			req.SetHeader("X-Personal-Access-Token", secctx.GetPersonalAccessToken(ctx))
+			header.Set("X-Personal-Access-Token", secctx.GetPersonalAccessToken(ctx))
 		}
 	}
-	return req
 }
@@ -7,7 +7,6 @@ import (
 	"net/http"
 	"strconv"
 
-	"github.com/Netcracker/qubership-apihub-agents-backend/client"
 	"github.com/Netcracker/qubership-apihub-agents-backend/exception"
 	"github.com/Netcracker/qubership-apihub-agents-backend/secctx"
 	"github.com/Netcracker/qubership-apihub-agents-backend/service"
@@ -19,20 +18,21 @@ type AgentController interface {
 	ProcessAgentSignal(w http.ResponseWriter, r *http.Request)
 	ListAgents(w http.ResponseWriter, r *http.Request)
 	GetAgent(w http.ResponseWriter, r *http.Request)
+	GetAgentsOverview(w http.ResponseWriter, r *http.Request)
 	GetAgentNamespaces(w http.ResponseWriter, r *http.Request)
 	ListServiceNames(w http.ResponseWriter, r *http.Request)
 }
 
-func NewAgentController(agentService service.AgentService, agentClient client.AgentClient) AgentController {
+func NewAgentController(agentService service.AgentService, agentOverviewService service.AgentOverviewService) AgentController {
 	return &agentControllerImpl{
-		agentService: agentService,
-		agentClient:  agentClient,
+		agentService:         agentService,
+		agentOverviewService: agentOverviewService,
 	}
 }
 
 type agentControllerImpl struct {
-	agentService service.AgentService
-	agentClient  client.AgentClient
+	agentService         service.AgentService
+	agentOverviewService service.AgentOverviewService
 }
 
 func (a agentControllerImpl) ProcessAgentSignal(w http.ResponseWriter, r *http.Request) {
@@ -142,6 +142,49 @@ func (a agentControllerImpl) GetAgent(w http.ResponseWriter, r *http.Request) {
 	respondWithJson(w, http.StatusOK, agent)
 }
 
+func (a agentControllerImpl) GetAgentsOverview(w http.ResponseWriter, r *http.Request) {
+	workspaceId := r.URL.Query().Get("workspaceId")
+	if workspaceId == "" {
+		RespondWithCustomError(w, &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.RequiredParamsMissing,
+			Message: exception.RequiredParamsMissingMsg,
+			Params:  map[string]interface{}{"params": "workspaceId"},
+		})
+		return
+	}
+	onlyActiveStr := r.URL.Query().Get("onlyActive")
+	var err error
+	onlyActive := true
+	if onlyActiveStr != "" {
+		onlyActive, err = strconv.ParseBool(onlyActiveStr)
+		if err != nil {
+			RespondWithCustomError(w, &exception.CustomError{
+				Status:  http.StatusBadRequest,
+				Code:    exception.IncorrectParamType,
+				Message: exception.IncorrectParamTypeMsg,
+				Params:  map[string]interface{}{"param": "onlyActive", "type": "boolean"},
+				Debug:   err.Error(),
+			})
+			return
+		}
+	}
+
+	refresh, customErr := getRefreshQueryParam(r)
+	if customErr != nil {
+		RespondWithCustomError(w, customErr)
+		return
+	}
+
+	result, err := a.agentOverviewService.GetAgentsOverview(secctx.MakeUserContext(r), workspaceId, onlyActive, refresh)
+	if err != nil {
+		respondWithError(w, "Failed to get agents overview", err)
+		return
+	}
+
+	respondWithJson(w, http.StatusOK, result)
+}
+
 func (a agentControllerImpl) GetAgentNamespaces(w http.ResponseWriter, r *http.Request) {
 	agentId := getStringParam(r, "agentId")
 
@@ -183,7 +226,12 @@ func (a agentControllerImpl) GetAgentNamespaces(w http.ResponseWriter, r *http.R
 		})
 		return
 	}
-	agentNamespaces, err := a.agentClient.GetNamespaces(secctx.MakeUserContext(r), agent.AgentUrl)
+	refresh, customErr := getRefreshQueryParam(r)
+	if customErr != nil {
+		RespondWithCustomError(w, customErr)
+		return
+	}
+	agentNamespaces, err := a.agentService.GetNamespaces(secctx.MakeUserContext(r), *agent, refresh)
 	if err != nil {
 		respondWithError(w, "Failed to get agent namespaces", err)
 		return
@@ -234,7 +282,12 @@ func (a agentControllerImpl) ListServiceNames(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	serviceNames, err := a.agentClient.ListServiceNames(secctx.MakeUserContext(r), agent.AgentUrl, namespace)
+	refresh, customErr := getRefreshQueryParam(r)
+	if customErr != nil {
+		RespondWithCustomError(w, customErr)
+		return
+	}
+	serviceNames, err := a.agentService.ListServiceNames(secctx.MakeUserContext(r), *agent, namespace, refresh)
 	if err != nil {
 		respondWithError(w, "Failed to get service names", err)
 		return
@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/Netcracker/qubership-apihub-agents-backend/exception"
+	"github.com/Netcracker/qubership-apihub-agents-backend/secctx"
+	"github.com/Netcracker/qubership-apihub-agents-backend/service"
+	"github.com/Netcracker/qubership-apihub-agents-backend/utils"
+	"github.com/Netcracker/qubership-apihub-agents-backend/view"
+)
+
+type BaselineMappingController interface {
+	GetBaselineMappingRules(w http.ResponseWriter, r *http.Request)
+	UpdateBaselineMappingRules(w http.ResponseWriter, r *http.Request)
+	TestBaselineMapping(w http.ResponseWriter, r *http.Request)
+}
+
+func NewBaselineMappingController(baselineMappingService service.BaselineMappingService) BaselineMappingController {
+	return &baselineMappingControllerImpl{baselineMappingService: baselineMappingService}
+}
+
+type baselineMappingControllerImpl struct {
+	baselineMappingService service.BaselineMappingService
+}
+
+func (c baselineMappingControllerImpl) GetBaselineMappingRules(w http.ResponseWriter, r *http.Request) {
+	workspaceId := getStringParam(r, "workspaceId")
+
+	rules, err := c.baselineMappingService.GetBaselineMappingRules(secctx.MakeUserContext(r), workspaceId)
+	if err != nil {
+		respondWithError(w, "failed to get baseline mapping rules", err)
+		return
+	}
+	respondWithJson(w, http.StatusOK, rules)
+}
+
+func (c baselineMappingControllerImpl) UpdateBaselineMappingRules(w http.ResponseWriter, r *http.Request) {
+	workspaceId := getStringParam(r, "workspaceId")
+	var req view.UpdateBaselineMappingRulesReq
+	if !readBaselineMappingReq(w, r, &req) {
+		return
+	}
+	validationErr := utils.ValidateObject(req)
+	if validationErr != nil {
+		if customError, ok := validationErr.(*exception.CustomError); ok {
+			RespondWithCustomError(w, customError)
+			return
+		}
+	}
+
+	rules, err := c.baselineMappingService.UpdateBaselineMappingRules(secctx.MakeUserContext(r), workspaceId, req)
+	if err != nil {
+		respondWithError(w, "failed to update baseline mapping rules", err)
+		return
+	}
+	respondWithJson(w, http.StatusOK, rules)
+}
+
+func (c baselineMappingControllerImpl) TestBaselineMapping(w http.ResponseWriter, r *http.Request) {
+	workspaceId := getStringParam(r, "workspaceId")
+	var req view.TestBaselineMappingReq
+	if !readBaselineMappingReq(w, r, &req) {
+		return
+	}
+	validationErr := utils.ValidateObject(req)
+	if validationErr != nil {
+		if customError, ok := validationErr.(*exception.CustomError); ok {
+			RespondWithCustomError(w, customError)
+			return
+		}
+	}
+
+	result, err := c.baselineMappingService.TestBaselineMapping(secctx.MakeUserContext(r), workspaceId, req)
+	if err != nil {
+		respondWithError(w, "failed to test baseline mapping", err)
+		return
+	}
+	respondWithJson(w, http.StatusOK, result)
+}
+
+// readBaselineMappingReq unmarshals the request body to req which must be a pointer
+func readBaselineMappingReq(w http.ResponseWriter, r *http.Request, req interface{}) bool {
+	defer r.Body.Close()
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		RespondWithCustomError(w, &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.BadRequestBody,
+			Message: exception.BadRequestBodyMsg,
+			Debug:   err.Error(),
+		})
+		return false
+	}
+	err = json.Unmarshal(body, req)
+	if err != nil {
+		RespondWithCustomError(w, &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.BadRequestBody,
+			Message: exception.BadRequestBodyMsg,
+			Debug:   err.Error(),
+		})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/Netcracker/qubership-apihub-agents-backend/exception"
+	"github.com/Netcracker/qubership-apihub-agents-backend/secctx"
+	"github.com/Netcracker/qubership-apihub-agents-backend/service"
+	"github.com/Netcracker/qubership-apihub-agents-backend/view"
+)
+
+type CleanupController interface {
+	RunSnapshotsCleanup(w http.ResponseWriter, r *http.Request)
+	RunAuthSecurityChecksCleanup(w http.ResponseWriter, r *http.Request)
+	ListCleanupRuns(w http.ResponseWriter, r *http.Request)
+	GetCleanupRun(w http.ResponseWriter, r *http.Request)
+}
+
+func NewCleanupController(cleanupService service.CleanupService) CleanupController {
+	return &cleanupControllerImpl{cleanupService: cleanupService}
+}
+
+type cleanupControllerImpl struct {
+	cleanupService service.CleanupService
+}
+
+func (c cleanupControllerImpl) RunSnapshotsCleanup(w http.ResponseWriter, r *http.Request) {
+	if !checkSysadmin(w, r) {
+		return
+	}
+
+	runId, err := c.cleanupService.RunSnapshotsCleanup(secctx.MakeUserContext(r))
+	if err != nil {
+		respondWithError(w, "failed to start snapshots cleanup", err)
+		return
+	}
+	respondWithJson(w, http.StatusAccepted, view.CleanupRunId{RunId: runId})
+}
+
+func (c cleanupControllerImpl) RunAuthSecurityChecksCleanup(w http.ResponseWriter, r *http.Request) {
+	if !checkSysadmin(w, r) {
+		return
+	}
+
+	runId, err := c.cleanupService.RunAuthSecurityChecksCleanup(secctx.MakeUserContext(r))
+	if err != nil {
+		respondWithError(w, "failed to start auth security checks cleanup", err)
+		return
+	}
+	respondWithJson(w, http.StatusAccepted, view.CleanupRunId{RunId: runId})
+}
+
+func (c cleanupControllerImpl) ListCleanupRuns(w http.ResponseWriter, r *http.Request) {
+	if !checkSysadmin(w, r) {
+		return
+	}
+	limit, cErr := getLimitQueryParam(r)
+	if cErr != nil {
+		respondWithError(w, cErr.Error(), cErr)
+		return
+	}
+	page, cErr := getPageQueryParam(r)
+	if cErr != nil {
+		respondWithError(w, cErr.Error(), cErr)
+		return
+	}
+
+	runs, err := c.cleanupService.ListCleanupRuns(r.URL.Query().Get("jobType"), limit, page)
+	if err != nil {
+		respondWithError(w, "failed to list cleanup runs", err)
+		return
+	}
+	respondWithJson(w, http.StatusOK, runs)
+}
+
+func (c cleanupControllerImpl) GetCleanupRun(w http.ResponseWriter, r *http.Request) {
+	if !checkSysadmin(w, r) {
+		return
+	}
+
+	run, err := c.cleanupService.GetCleanupRun(getStringParam(r, "runId"))
+	if err != nil {
+		respondWithError(w, "failed to get cleanup run", err)
+		return
+	}
+	respondWithJson(w, http.StatusOK, run)
+}
+
+func checkSysadmin(w http.ResponseWriter, r *http.Request) bool {
+	if !secctx.IsSysadm(secctx.MakeUserContext(r)) {
+		RespondWithCustomError(w, &exception.CustomError{
+			Status:  http.StatusForbidden,
+			Code:    exception.InsufficientPrivileges,
+			Message: exception.InsufficientPrivilegesMsg,
+		})
+		return false
+	}
+	return true
+}
@@ -1,28 +1,54 @@
 package controller
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
+	"time"
 
+	"github.com/Netcracker/qubership-apihub-agents-backend/exception"
 	"github.com/Netcracker/qubership-apihub-agents-backend/secctx"
 	"github.com/Netcracker/qubership-apihub-agents-backend/service"
+	"github.com/Netcracker/qubership-apihub-agents-backend/utils"
+	"github.com/Netcracker/qubership-apihub-agents-backend/view"
+	log "github.com/sirupsen/logrus"
 )
 
 type DiscoveryController interface {
 	StartDiscovery(w http.ResponseWriter, r *http.Request)
 	ListDiscoveredServices_deprecated(w http.ResponseWriter, r *http.Request)
 	ListDiscoveredServices(w http.ResponseWriter, r *http.Request)
+	ListDiscoveries(w http.ResponseWriter, r *http.Request)
+	GetDiscovery(w http.ResponseWriter, r *http.Request)
+	CompareDiscoveries(w http.ResponseWriter, r *http.Request)
+	GetDiscoveryEvents(w http.ResponseWriter, r *http.Request)
+	StartBulkDiscovery(w http.ResponseWriter, r *http.Request)
+	GetBulkDiscovery(w http.ResponseWriter, r *http.Request)
 }
 
-func NewDiscoveryController(discoveryService service.DiscoveryService) DiscoveryController {
+func NewDiscoveryController(discoveryService service.DiscoveryService, discoveryHistoryService service.DiscoveryHistoryService,
+	discoveryEventsService service.DiscoveryEventsService, bulkDiscoveryService service.BulkDiscoveryService) DiscoveryController {
 	return &discoveryControllerImpl{
-		discoveryService: discoveryService,
+		discoveryService:        discoveryService,
+		discoveryHistoryService: discoveryHistoryService,
+		discoveryEventsService:  discoveryEventsService,
+		bulkDiscoveryService:    bulkDiscoveryService,
 	}
 }
 
 type discoveryControllerImpl struct {
-	discoveryService service.DiscoveryService
+	discoveryService        service.DiscoveryService
+	discoveryHistoryService service.DiscoveryHistoryService
+	discoveryEventsService  service.DiscoveryEventsService
+	bulkDiscoveryService    service.BulkDiscoveryService
 }
 
+const serverSentEventsHeartbeatInterval = 15 * time.Second
+
+// serverSentEventWriteDeadline extends the write deadline set by WriteDeadlineMiddleware for every event since the stream may last longer
+const serverSentEventWriteDeadline = time.Minute
+
 func (d discoveryControllerImpl) StartDiscovery(w http.ResponseWriter, r *http.Request) {
 	namespace := getStringParam(r, "namespace")
 	agentId := getStringParam(r, "agentId")
@@ -32,8 +58,13 @@ func (d discoveryControllerImpl) StartDiscovery(w http.ResponseWriter, r *http.R
 	if queryParamErr != nil {
 		respondWithError(w, "failed to parse failOnError query param", queryParamErr)
 	}
+	syncPackages, queryParamErr := getSyncPackagesQueryParam(r)
+	if queryParamErr != nil {
+		respondWithError(w, "failed to parse syncPackages query param", queryParamErr)
+		return
+	}
 
-	err := d.discoveryService.StartDiscovery(secctx.MakeUserContext(r), agentId, namespace, workspaceId, failOnError)
+	err := d.discoveryService.StartDiscovery(secctx.MakeUserContext(r), agentId, namespace, workspaceId, failOnError, syncPackages)
 	if err != nil {
 		respondWithError(w, "failed to start discovery process", err)
 		return
@@ -66,3 +97,168 @@ func (d discoveryControllerImpl) ListDiscoveredServices(w http.ResponseWriter, r
 	}
 	respondWithJson(w, http.StatusOK, serviceList)
 }
+
+func (d discoveryControllerImpl) ListDiscoveries(w http.ResponseWriter, r *http.Request) {
+	namespace := getStringParam(r, "namespace")
+	agentId := getStringParam(r, "agentId")
+	workspaceId := getStringParam(r, "workspaceId")
+	limit, cErr := getLimitQueryParam(r)
+	if cErr != nil {
+		respondWithError(w, cErr.Error(), cErr)
+		return
+	}
+	page, cErr := getPageQueryParam(r)
+	if cErr != nil {
+		respondWithError(w, cErr.Error(), cErr)
+		return
+	}
+
+	discoveries, err := d.discoveryHistoryService.ListDiscoveries(secctx.MakeUserContext(r), agentId, namespace, workspaceId, limit, page)
+	if err != nil {
+		respondWithError(w, "failed to list discoveries", err)
+		return
+	}
+	respondWithJson(w, http.StatusOK, discoveries)
+}
+
+func (d discoveryControllerImpl) GetDiscovery(w http.ResponseWriter, r *http.Request) {
+	namespace := getStringParam(r, "namespace")
+	agentId := getStringParam(r, "agentId")
+	workspaceId := getStringParam(r, "workspaceId")
+	discoveryId := getStringParam(r, "discoveryId")
+
+	discovery, err := d.discoveryHistoryService.GetDiscovery(secctx.MakeUserContext(r), agentId, namespace, workspaceId, discoveryId)
+	if err != nil {
+		respondWithError(w, "failed to get discovery", err)
+		return
+	}
+	respondWithJson(w, http.StatusOK, discovery)
+}
+
+func (d discoveryControllerImpl) CompareDiscoveries(w http.ResponseWriter, r *http.Request) {
+	namespace := getStringParam(r, "namespace")
+	agentId := getStringParam(r, "agentId")
+	workspaceId := getStringParam(r, "workspaceId")
+	from := r.URL.Query().Get("from")
+	to := r.URL.Query().Get("to")
+	if from == "" || to == "" {
+		RespondWithCustomError(w, &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.RequiredParamsMissing,
+			Message: exception.RequiredParamsMissingMsg,
+			Params:  map[string]interface{}{"params": "from, to"},
+		})
+		return
+	}
+
+	diff, err := d.discoveryHistoryService.CompareDiscoveries(secctx.MakeUserContext(r), agentId, namespace, workspaceId, from, to)
+	if err != nil {
+		respondWithError(w, "failed to compare discoveries", err)
+		return
+	}
+	respondWithJson(w, http.StatusOK, diff)
+}
+
+func (d discoveryControllerImpl) GetDiscoveryEvents(w http.ResponseWriter, r *http.Request) {
+	namespace := getStringParam(r, "namespace")
+	agentId := getStringParam(r, "agentId")
+	workspaceId := getStringParam(r, "workspaceId")
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respondWithError(w, "failed to stream discovery events", fmt.Errorf("streaming is not supported"))
+		return
+	}
+	events, unsubscribe, err := d.discoveryEventsService.Subscribe(agentId, namespace, workspaceId)
+	if err != nil {
+		respondWithError(w, "failed to subscribe to discovery events", err)
+		return
+	}
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	rc := http.NewResponseController(w)
+	heartbeat := time.NewTicker(serverSentEventsHeartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		var payload []byte
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			payload = []byte(": heartbeat\n\n")
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(event.Data())
+			if err != nil {
+				log.Errorf("Failed to marshal discovery event: %v", err.Error())
+				continue
+			}
+			payload = []byte(fmt.Sprintf("event: %s\ndata: %s\n\n", event.Type, data))
+		}
+		_ = rc.SetWriteDeadline(time.Now().Add(serverSentEventWriteDeadline))
+		if _, err := w.Write(payload); err != nil {
+			log.Debugf("Failed to write discovery event: %v", err.Error())
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+func (d discoveryControllerImpl) StartBulkDiscovery(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		RespondWithCustomError(w, &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.BadRequestBody,
+			Message: exception.BadRequestBodyMsg,
+			Debug:   err.Error(),
+		})
+		return
+	}
+	var req view.StartBulkDiscoveryReq
+	err = json.Unmarshal(body, &req)
+	if err != nil {
+		RespondWithCustomError(w, &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.BadRequestBody,
+			Message: exception.BadRequestBodyMsg,
+			Debug:   err.Error(),
+		})
+		return
+	}
+	validationErr := utils.ValidateObject(req)
+	if validationErr != nil {
+		if customError, ok := validationErr.(*exception.CustomError); ok {
+			RespondWithCustomError(w, customError)
+			return
+		}
+	}
+
+	jobId, err := d.bulkDiscoveryService.StartBulkDiscovery(secctx.MakeUserContext(r), req)
+	if err != nil {
+		respondWithError(w, "failed to start bulk discovery", err)
+		return
+	}
+	respondWithJson(w, http.StatusAccepted, view.BulkDiscoveryJobId{JobId: jobId})
+}
+
+func (d discoveryControllerImpl) GetBulkDiscovery(w http.ResponseWriter, r *http.Request) {
+	jobId := getStringParam(r, "jobId")
+
+	job, err := d.bulkDiscoveryService.GetBulkDiscovery(secctx.MakeUserContext(r), jobId)
+	if err != nil {
+		respondWithError(w, "failed to get bulk discovery", err)
+		return
+	}
+	respondWithJson(w, http.StatusOK, job)
+}
@@ -76,20 +76,42 @@ func (a *agentProxyControllerImpl) Proxy(w http.ResponseWriter, r *http.Request)
 		})
 	}
 
-	agentUrl, _ := url.Parse(agent.AgentUrl)
-	r.URL.Host = agentUrl.Host
-	r.URL.Scheme = agentUrl.Scheme
-	r.Host = agentUrl.Host
-	log.Debugf("Sending proxy request to %s", r.URL)
-	resp, err := a.tr.RoundTrip(r)
+	// request body can be sent only once, so failover to another agent replica is possible for requests without body only
+	retryable := r.Body == nil || r.Body == http.NoBody
+	var resp *http.Response
+	err = a.agentService.CallAgent(*agent, func(agentUrl string) error {
+		parsedAgentUrl, err := url.Parse(agentUrl)
+		if err != nil {
+			return err
+		}
+		r.URL.Host = parsedAgentUrl.Host
+		r.URL.Scheme = parsedAgentUrl.Scheme
+		r.Host = parsedAgentUrl.Host
+		log.Debugf("Sending proxy request to %s", r.URL)
+		resp, err = a.tr.RoundTrip(r)
+		if err != nil && !retryable {
+			return &exception.CustomError{
+				Status:  http.StatusFailedDependency,
+				Code:    exception.ProxyFailed,
+				Message: exception.ProxyFailedMsg,
+				Params:  map[string]interface{}{"url": r.URL.String()},
+				Debug:   err.Error(),
+			}
+		}
+		return err
+	})
 	if err != nil {
-		RespondWithCustomError(w, &exception.CustomError{
-			Status:  http.StatusFailedDependency,
-			Code:    exception.ProxyFailed,
-			Message: exception.ProxyFailedMsg,
-			Params:  map[string]interface{}{"url": r.URL.String()},
-			Debug:   err.Error(),
-		})
+		if customError, ok := err.(*exception.CustomError); ok {
+			RespondWithCustomError(w, customError)
+		} else {
+			RespondWithCustomError(w, &exception.CustomError{
+				Status:  http.StatusFailedDependency,
+				Code:    exception.ProxyFailed,
+				Message: exception.ProxyFailedMsg,
+				Params:  map[string]interface{}{"url": r.URL.String()},
+				Debug:   err.Error(),
+			})
+		}
 		return
 	}
 	defer resp.Body.Close()
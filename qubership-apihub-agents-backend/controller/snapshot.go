@@ -2,21 +2,37 @@ package controller
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Netcracker/qubership-apihub-agents-backend/exception"
 	"github.com/Netcracker/qubership-apihub-agents-backend/secctx"
 	"github.com/Netcracker/qubership-apihub-agents-backend/service"
+	"github.com/Netcracker/qubership-apihub-agents-backend/utils"
 	"github.com/Netcracker/qubership-apihub-agents-backend/view"
 	log "github.com/sirupsen/logrus"
 )
 
+const snapshotBundleMaxSize = 1 << 30
+const snapshotBundleMaxMemory = 32 << 20
+
 type SnapshotController interface {
 	CreateSnapshot(w http.ResponseWriter, r *http.Request)
+	ValidateSnapshot(w http.ResponseWriter, r *http.Request)
 	ListSnapshots(w http.ResponseWriter, r *http.Request)
 	GetSnapshot(w http.ResponseWriter, r *http.Request)
+	CompareSnapshots(w http.ResponseWriter, r *http.Request)
+	CompareEnvironmentSnapshots(w http.ResponseWriter, r *http.Request)
+	GetSnapshotBundle(w http.ResponseWriter, r *http.Request)
+	ImportSnapshotBundle(w http.ResponseWriter, r *http.Request)
+	PromoteSnapshot(w http.ResponseWriter, r *http.Request)
+	DeleteSnapshot(w http.ResponseWriter, r *http.Request)
+	UpdateSnapshot(w http.ResponseWriter, r *http.Request)
 }
 
 func NewSnapshotController(snapshotService service.SnapshotService, agentService service.AgentService) SnapshotController {
@@ -29,10 +45,49 @@ type snapshotControllerImpl struct {
 }
 
 func (s snapshotControllerImpl) CreateSnapshot(w http.ResponseWriter, r *http.Request) {
-	var err error
 	namespace := getStringParam(r, "namespace")
-	agentId := getStringParam(r, "agentId")
 	workspaceId := getStringParam(r, "workspaceId")
+	version, snapshotDTO, ok := s.readCreateSnapshotReq(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := s.snapshotService.CreateSnapshot(secctx.MakeUserContext(r), namespace, workspaceId, version, *snapshotDTO)
+	if err != nil {
+		log.Error("Failed to create snapshot: ", err.Error())
+		if customError, ok := err.(*exception.CustomError); ok {
+			RespondWithCustomError(w, customError)
+		} else {
+			RespondWithCustomError(w, &exception.CustomError{
+				Status:  http.StatusInternalServerError,
+				Message: "Failed to create snapshot",
+				Debug:   err.Error()})
+		}
+		return
+	}
+	respondWithJson(w, http.StatusOK, resp)
+}
+
+func (s snapshotControllerImpl) ValidateSnapshot(w http.ResponseWriter, r *http.Request) {
+	namespace := getStringParam(r, "namespace")
+	workspaceId := getStringParam(r, "workspaceId")
+	version, snapshotDTO, ok := s.readCreateSnapshotReq(w, r)
+	if !ok {
+		return
+	}
+
+	validation, err := s.snapshotService.ValidateSnapshot(secctx.MakeUserContext(r), namespace, workspaceId, version, *snapshotDTO)
+	if err != nil {
+		respondWithError(w, "Failed to validate snapshot", err)
+		return
+	}
+	respondWithJson(w, http.StatusOK, validation)
+}
+
+// readCreateSnapshotReq reads the create snapshot request, empty version is generated by the workspace snapshot version template
+func (s snapshotControllerImpl) readCreateSnapshotReq(w http.ResponseWriter, r *http.Request) (string, *view.CreateSnapshotDTO, bool) {
+	var err error
+	agentId := getStringParam(r, "agentId")
 	agent, err := s.agentService.GetAgent(agentId)
 	if err != nil {
 		if customError, ok := err.(*exception.CustomError); ok {
@@ -44,7 +99,7 @@ func (s snapshotControllerImpl) CreateSnapshot(w http.ResponseWriter, r *http.Re
 				Debug:   err.Error(),
 				Params:  map[string]interface{}{"id": agentId}})
 		}
-		return
+		return "", nil, false
 	}
 	if agent == nil {
 		RespondWithCustomError(w, &exception.CustomError{
@@ -52,7 +107,7 @@ func (s snapshotControllerImpl) CreateSnapshot(w http.ResponseWriter, r *http.Re
 			Code:    exception.AgentNotFound,
 			Message: exception.AgentNotFoundMsg,
 			Params:  map[string]interface{}{"id": agentId}})
-		return
+		return "", nil, false
 	}
 
 	clientBuild := false
@@ -67,7 +122,7 @@ func (s snapshotControllerImpl) CreateSnapshot(w http.ResponseWriter, r *http.Re
 				Params:  map[string]interface{}{"param": "clientBuild"},
 				Debug:   err.Error(),
 			})
-			return
+			return "", nil, false
 		}
 	}
 
@@ -83,7 +138,7 @@ func (s snapshotControllerImpl) CreateSnapshot(w http.ResponseWriter, r *http.Re
 				Params:  map[string]interface{}{"param": "promote"},
 				Debug:   err.Error(),
 			})
-			return
+			return "", nil, false
 		}
 	}
 
@@ -96,7 +151,7 @@ func (s snapshotControllerImpl) CreateSnapshot(w http.ResponseWriter, r *http.Re
 			Message: exception.BadRequestBodyMsg,
 			Debug:   err.Error(),
 		})
-		return
+		return "", nil, false
 	}
 	var req view.CreateSnapshotRequest
 	err = json.Unmarshal(body, &req)
@@ -107,7 +162,7 @@ func (s snapshotControllerImpl) CreateSnapshot(w http.ResponseWriter, r *http.Re
 			Message: exception.BadRequestBodyMsg,
 			Debug:   err.Error(),
 		})
-		return
+		return "", nil, false
 	}
 
 	if clientBuild && req.BuilderId == "" {
@@ -117,17 +172,7 @@ func (s snapshotControllerImpl) CreateSnapshot(w http.ResponseWriter, r *http.Re
 			Message: exception.RequiredParamsMissingMsg,
 			Params:  map[string]interface{}{"params": "builderId"},
 		})
-		return
-	}
-
-	if req.Version == "" {
-		RespondWithCustomError(w, &exception.CustomError{
-			Status:  http.StatusBadRequest,
-			Code:    exception.RequiredParamsMissing,
-			Message: exception.RequiredParamsMissingMsg,
-			Params:  map[string]interface{}{"params": "version"},
-		})
-		return
+		return "", nil, false
 	}
 
 	status := string(view.DraftStatus)
@@ -136,30 +181,19 @@ func (s snapshotControllerImpl) CreateSnapshot(w http.ResponseWriter, r *http.Re
 	}
 
 	snapshotDTO := view.CreateSnapshotDTO{
-		PreviousVersion: req.PreviousVersion,
-		Services:        req.Services,
-		ClientBuild:     clientBuild,
-		BuilderId:       req.BuilderId,
-		Promote:         promote,
-		VersionStatus:   status,
-		AgentUrl:        agent.AgentUrl,
-		CloudName:       agent.AgentDeploymentCloud,
-	}
-
-	resp, err := s.snapshotService.CreateSnapshot(secctx.MakeUserContext(r), namespace, workspaceId, req.Version, snapshotDTO)
-	if err != nil {
-		log.Error("Failed to create snapshot: ", err.Error())
-		if customError, ok := err.(*exception.CustomError); ok {
-			RespondWithCustomError(w, customError)
-		} else {
-			RespondWithCustomError(w, &exception.CustomError{
-				Status:  http.StatusInternalServerError,
-				Message: "Failed to create snapshot",
-				Debug:   err.Error()})
-		}
-		return
+		PreviousVersion:  req.PreviousVersion,
+		Services:         req.Services,
+		ClientBuild:      clientBuild,
+		BuilderId:        req.BuilderId,
+		Promote:          promote,
+		VersionStatus:    status,
+		Agent:            *agent,
+		CloudName:        agent.AgentDeploymentCloud,
+		ValidateSpecs:    req.ValidateSpecs || req.FailOnSpecErrors,
+		FailOnSpecErrors: req.FailOnSpecErrors,
+		SkipUnchanged:    !req.RepublishUnchanged,
 	}
-	respondWithJson(w, http.StatusOK, resp)
+	return req.Version, &snapshotDTO, true
 }
 
 func (s snapshotControllerImpl) ListSnapshots(w http.ResponseWriter, r *http.Request) {
@@ -196,6 +230,52 @@ func (s snapshotControllerImpl) ListSnapshots(w http.ResponseWriter, r *http.Req
 		}
 	}
 
+	req := view.SnapshotListReq{
+		Page:       page,
+		Limit:      limit,
+		Status:     r.URL.Query().Get("status"),
+		CreatedBy:  r.URL.Query().Get("createdBy"),
+		TextFilter: r.URL.Query().Get("textFilter"),
+		SortBy:     r.URL.Query().Get("sortBy"),
+		SortOrder:  r.URL.Query().Get("sortOrder"),
+	}
+	for param, value := range map[string]**time.Time{"createdAfter": &req.CreatedAfter, "createdBefore": &req.CreatedBefore} {
+		if r.URL.Query().Get(param) == "" {
+			continue
+		}
+		*value, err = parseSnapshotListTime(r.URL.Query().Get(param))
+		if err != nil {
+			RespondWithCustomError(w, &exception.CustomError{
+				Status:  http.StatusBadRequest,
+				Code:    exception.IncorrectParamType,
+				Message: exception.IncorrectParamTypeMsg,
+				Params:  map[string]interface{}{"param": param, "type": "date-time"},
+				Debug:   err.Error(),
+			})
+			return
+		}
+	}
+	if req.SortBy != "" && req.SortBy != view.SnapshotsSortByCreatedAt && req.SortBy != view.SnapshotsSortByVersion {
+		RespondWithCustomError(w, &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.InvalidParameter,
+			Message: exception.InvalidParameterMsg,
+			Params:  map[string]interface{}{"param": "sortBy"},
+			Debug:   fmt.Sprintf("allowed values are %s and %s", view.SnapshotsSortByCreatedAt, view.SnapshotsSortByVersion),
+		})
+		return
+	}
+	if req.SortOrder != "" && req.SortOrder != view.VersionSortOrderAsc && req.SortOrder != view.VersionSortOrderDesc {
+		RespondWithCustomError(w, &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.InvalidParameter,
+			Message: exception.InvalidParameterMsg,
+			Params:  map[string]interface{}{"param": "sortOrder"},
+			Debug:   fmt.Sprintf("allowed values are %s and %s", view.VersionSortOrderAsc, view.VersionSortOrderDesc),
+		})
+		return
+	}
+
 	agentId := getStringParam(r, "agentId")
 	agent, err := s.agentService.GetAgent(agentId)
 	if err != nil {
@@ -219,7 +299,7 @@ func (s snapshotControllerImpl) ListSnapshots(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	snapshots, err := s.snapshotService.ListSnapshots(secctx.MakeUserContext(r), namespace, workspaceId, page, limit, agent.AgentDeploymentCloud)
+	snapshots, err := s.snapshotService.ListSnapshots(secctx.MakeUserContext(r), namespace, workspaceId, agent.AgentDeploymentCloud, req)
 	if err != nil {
 		log.Error("Failed to list snapshots: ", err.Error())
 		if customError, ok := err.(*exception.CustomError); ok {
@@ -284,3 +364,313 @@ func (s snapshotControllerImpl) GetSnapshot(w http.ResponseWriter, r *http.Reque
 
 	respondWithJson(w, http.StatusOK, sn)
 }
+
+func (s snapshotControllerImpl) CompareSnapshots(w http.ResponseWriter, r *http.Request) {
+	namespace := getStringParam(r, "namespace")
+	agentId := getStringParam(r, "agentId")
+	workspaceId := getStringParam(r, "workspaceId")
+	from := r.URL.Query().Get("from")
+	to := r.URL.Query().Get("to")
+	missingParams := make([]string, 0)
+	if from == "" {
+		missingParams = append(missingParams, "from")
+	}
+	if to == "" {
+		missingParams = append(missingParams, "to")
+	}
+	if len(missingParams) > 0 {
+		RespondWithCustomError(w, &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.RequiredParamsMissing,
+			Message: exception.RequiredParamsMissingMsg,
+			Params:  map[string]interface{}{"params": strings.Join(missingParams, ", ")},
+		})
+		return
+	}
+	agent, err := s.agentService.GetAgent(agentId)
+	if err != nil {
+		respondWithError(w, "Failed to get agent", err)
+		return
+	}
+	if agent == nil {
+		RespondWithCustomError(w, &exception.CustomError{
+			Status:  http.StatusNotFound,
+			Code:    exception.AgentNotFound,
+			Message: exception.AgentNotFoundMsg,
+			Params:  map[string]interface{}{"id": agentId}})
+		return
+	}
+
+	comparison, err := s.snapshotService.CompareSnapshots(secctx.MakeUserContext(r), namespace, workspaceId, agent.AgentDeploymentCloud, from, to)
+	if err != nil {
+		respondWithError(w, "Failed to compare snapshots", err)
+		return
+	}
+	respondWithJson(w, http.StatusOK, comparison)
+}
+
+func (s snapshotControllerImpl) CompareEnvironmentSnapshots(w http.ResponseWriter, r *http.Request) {
+	workspaceId := getStringParam(r, "workspaceId")
+	query := r.URL.Query()
+	from := view.SnapshotRef{
+		CloudName: query.Get("fromCloud"),
+		Namespace: query.Get("fromNamespace"),
+		Version:   query.Get("fromVersion"),
+	}
+	to := view.SnapshotRef{
+		CloudName: query.Get("toCloud"),
+		Namespace: query.Get("toNamespace"),
+		Version:   query.Get("toVersion"),
+	}
+	missingParams := make([]string, 0)
+	for param, value := range map[string]string{
+		"fromCloud": from.CloudName, "fromNamespace": from.Namespace, "fromVersion": from.Version,
+		"toCloud": to.CloudName, "toNamespace": to.Namespace, "toVersion": to.Version,
+	} {
+		if value == "" {
+			missingParams = append(missingParams, param)
+		}
+	}
+	if len(missingParams) > 0 {
+		slices.Sort(missingParams)
+		RespondWithCustomError(w, &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.RequiredParamsMissing,
+			Message: exception.RequiredParamsMissingMsg,
+			Params:  map[string]interface{}{"params": strings.Join(missingParams, ", ")},
+		})
+		return
+	}
+
+	comparison, err := s.snapshotService.CompareEnvironmentSnapshots(secctx.MakeUserContext(r), workspaceId, from, to)
+	if err != nil {
+		respondWithError(w, "Failed to compare snapshots", err)
+		return
+	}
+	respondWithJson(w, http.StatusOK, comparison)
+}
+
+func (s snapshotControllerImpl) GetSnapshotBundle(w http.ResponseWriter, r *http.Request) {
+	namespace := getStringParam(r, "namespace")
+	agentId := getStringParam(r, "agentId")
+	workspaceId := getStringParam(r, "workspaceId")
+	version := getStringParam(r, "version")
+	agent, err := s.agentService.GetAgent(agentId)
+	if err != nil {
+		respondWithError(w, "Failed to get agent", err)
+		return
+	}
+	if agent == nil {
+		RespondWithCustomError(w, &exception.CustomError{
+			Status:  http.StatusNotFound,
+			Code:    exception.AgentNotFound,
+			Message: exception.AgentNotFoundMsg,
+			Params:  map[string]interface{}{"id": agentId}})
+		return
+	}
+
+	ctx := secctx.MakeUserContext(r)
+	manifest, err := s.snapshotService.GetSnapshotBundle(ctx, namespace, workspaceId, version, agent.AgentDeploymentCloud)
+	if err != nil {
+		respondWithError(w, "Failed to get snapshot bundle", err)
+		return
+	}
+
+	filename := fmt.Sprintf("%s_%s_%s.zip", agent.AgentDeploymentCloud, namespace, strings.Split(manifest.Version, "@")[0])
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%v"`, filename))
+	w.Header().Set("Content-Transfer-Encoding", "binary")
+	w.Header().Set("Expires", "0")
+	err = s.snapshotService.WriteSnapshotBundle(ctx, manifest, w)
+	if err != nil {
+		// the response is already started, so the archive is left incomplete
+		log.Errorf("Failed to write snapshot bundle %s: %s", version, err.Error())
+	}
+}
+
+func (s snapshotControllerImpl) ImportSnapshotBundle(w http.ResponseWriter, r *http.Request) {
+	workspaceId := getStringParam(r, "workspaceId")
+	r.Body = http.MaxBytesReader(w, r.Body, snapshotBundleMaxSize)
+	err := r.ParseMultipartForm(snapshotBundleMaxMemory)
+	if err != nil {
+		RespondWithCustomError(w, &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.BadRequestBody,
+			Message: exception.BadRequestBodyMsg,
+			Debug:   err.Error(),
+		})
+		return
+	}
+	bundle, bundleHeader, err := r.FormFile("bundle")
+	if err != nil {
+		RespondWithCustomError(w, &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.RequiredParamsMissing,
+			Message: exception.RequiredParamsMissingMsg,
+			Params:  map[string]interface{}{"params": "bundle"},
+			Debug:   err.Error(),
+		})
+		return
+	}
+	defer bundle.Close()
+	req := view.ImportSnapshotBundleReq{
+		CloudName: r.URL.Query().Get("cloudName"),
+		Namespace: r.URL.Query().Get("namespace"),
+		Version:   r.URL.Query().Get("version"),
+		Status:    r.URL.Query().Get("status"),
+	}
+
+	resp, err := s.snapshotService.ImportSnapshotBundle(secctx.MakeUserContext(r), workspaceId, bundle, bundleHeader.Size, req)
+	if err != nil {
+		respondWithError(w, "Failed to import snapshot bundle", err)
+		return
+	}
+	respondWithJson(w, http.StatusOK, resp)
+}
+
+func (s snapshotControllerImpl) PromoteSnapshot(w http.ResponseWriter, r *http.Request) {
+	namespace := getStringParam(r, "namespace")
+	agentId := getStringParam(r, "agentId")
+	workspaceId := getStringParam(r, "workspaceId")
+	version := getStringParam(r, "version")
+	agent, err := s.agentService.GetAgent(agentId)
+	if err != nil {
+		respondWithError(w, "Failed to get agent", err)
+		return
+	}
+	if agent == nil {
+		RespondWithCustomError(w, &exception.CustomError{
+			Status:  http.StatusNotFound,
+			Code:    exception.AgentNotFound,
+			Message: exception.AgentNotFoundMsg,
+			Params:  map[string]interface{}{"id": agentId}})
+		return
+	}
+
+	defer r.Body.Close()
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		RespondWithCustomError(w, &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.BadRequestBody,
+			Message: exception.BadRequestBodyMsg,
+			Debug:   err.Error(),
+		})
+		return
+	}
+	var req view.PromoteSnapshotReq
+	err = json.Unmarshal(body, &req)
+	if err != nil {
+		RespondWithCustomError(w, &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.BadRequestBody,
+			Message: exception.BadRequestBodyMsg,
+			Debug:   err.Error(),
+		})
+		return
+	}
+	validationErr := utils.ValidateObject(req)
+	if validationErr != nil {
+		if customError, ok := validationErr.(*exception.CustomError); ok {
+			RespondWithCustomError(w, customError)
+			return
+		}
+	}
+
+	result, err := s.snapshotService.PromoteSnapshot(secctx.MakeUserContext(r), namespace, workspaceId, version, agent.AgentDeploymentCloud, req)
+	if err != nil {
+		respondWithError(w, "Failed to promote snapshot", err)
+		return
+	}
+	respondWithJson(w, http.StatusOK, result)
+}
+
+func (s snapshotControllerImpl) DeleteSnapshot(w http.ResponseWriter, r *http.Request) {
+	namespace := getStringParam(r, "namespace")
+	agentId := getStringParam(r, "agentId")
+	workspaceId := getStringParam(r, "workspaceId")
+	version := getStringParam(r, "version")
+	agent, err := s.agentService.GetAgent(agentId)
+	if err != nil {
+		respondWithError(w, "Failed to get agent", err)
+		return
+	}
+	if agent == nil {
+		RespondWithCustomError(w, &exception.CustomError{
+			Status:  http.StatusNotFound,
+			Code:    exception.AgentNotFound,
+			Message: exception.AgentNotFoundMsg,
+			Params:  map[string]interface{}{"id": agentId}})
+		return
+	}
+
+	result, err := s.snapshotService.DeleteSnapshot(secctx.MakeUserContext(r), namespace, workspaceId, version, agent.AgentDeploymentCloud)
+	if err != nil {
+		respondWithError(w, "Failed to delete snapshot", err)
+		return
+	}
+	respondWithJson(w, http.StatusOK, result)
+}
+
+func (s snapshotControllerImpl) UpdateSnapshot(w http.ResponseWriter, r *http.Request) {
+	namespace := getStringParam(r, "namespace")
+	agentId := getStringParam(r, "agentId")
+	workspaceId := getStringParam(r, "workspaceId")
+	version := getStringParam(r, "version")
+	agent, err := s.agentService.GetAgent(agentId)
+	if err != nil {
+		respondWithError(w, "Failed to get agent", err)
+		return
+	}
+	if agent == nil {
+		RespondWithCustomError(w, &exception.CustomError{
+			Status:  http.StatusNotFound,
+			Code:    exception.AgentNotFound,
+			Message: exception.AgentNotFoundMsg,
+			Params:  map[string]interface{}{"id": agentId}})
+		return
+	}
+
+	defer r.Body.Close()
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		RespondWithCustomError(w, &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.BadRequestBody,
+			Message: exception.BadRequestBodyMsg,
+			Debug:   err.Error(),
+		})
+		return
+	}
+	var req view.UpdateSnapshotReq
+	err = json.Unmarshal(body, &req)
+	if err != nil {
+		RespondWithCustomError(w, &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.BadRequestBody,
+			Message: exception.BadRequestBodyMsg,
+			Debug:   err.Error(),
+		})
+		return
+	}
+
+	result, err := s.snapshotService.UpdateSnapshot(secctx.MakeUserContext(r), namespace, workspaceId, version, agent.AgentDeploymentCloud, req)
+	if err != nil {
+		respondWithError(w, "Failed to update snapshot", err)
+		return
+	}
+	respondWithJson(w, http.StatusOK, result)
+}
+
+// parseSnapshotListTime accepts either date-time in RFC 3339 format or date only
+func parseSnapshotListTime(value string) (*time.Time, error) {
+	result, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		var dateErr error
+		result, dateErr = time.Parse(time.DateOnly, value)
+		if dateErr != nil {
+			return nil, err
+		}
+	}
+	return &result, nil
+}
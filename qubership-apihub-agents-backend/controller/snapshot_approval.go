@@ -0,0 +1,187 @@
+package controller
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/Netcracker/qubership-apihub-agents-backend/exception"
+	"github.com/Netcracker/qubership-apihub-agents-backend/secctx"
+	"github.com/Netcracker/qubership-apihub-agents-backend/service"
+	"github.com/Netcracker/qubership-apihub-agents-backend/utils"
+	"github.com/Netcracker/qubership-apihub-agents-backend/view"
+)
+
+type SnapshotApprovalController interface {
+	GetSnapshotApprovers(w http.ResponseWriter, r *http.Request)
+	UpdateSnapshotApprovers(w http.ResponseWriter, r *http.Request)
+	SubmitSnapshotApproval(w http.ResponseWriter, r *http.Request)
+	GetSnapshotApproval(w http.ResponseWriter, r *http.Request)
+	DecideSnapshotApproval(w http.ResponseWriter, r *http.Request)
+	CommentSnapshotApproval(w http.ResponseWriter, r *http.Request)
+}
+
+func NewSnapshotApprovalController(snapshotApprovalService service.SnapshotApprovalService, agentService service.AgentService) SnapshotApprovalController {
+	return &snapshotApprovalControllerImpl{snapshotApprovalService: snapshotApprovalService, agentService: agentService}
+}
+
+type snapshotApprovalControllerImpl struct {
+	snapshotApprovalService service.SnapshotApprovalService
+	agentService            service.AgentService
+}
+
+func (c snapshotApprovalControllerImpl) GetSnapshotApprovers(w http.ResponseWriter, r *http.Request) {
+	workspaceId := getStringParam(r, "workspaceId")
+
+	approvers, err := c.snapshotApprovalService.GetSnapshotApprovers(workspaceId)
+	if err != nil {
+		respondWithError(w, "failed to get snapshot approvers", err)
+		return
+	}
+	respondWithJson(w, http.StatusOK, approvers)
+}
+
+func (c snapshotApprovalControllerImpl) UpdateSnapshotApprovers(w http.ResponseWriter, r *http.Request) {
+	workspaceId := getStringParam(r, "workspaceId")
+	var req view.UpdateSnapshotApproversReq
+	if !readSnapshotApprovalReq(w, r, &req) {
+		return
+	}
+
+	approvers, err := c.snapshotApprovalService.UpdateSnapshotApprovers(secctx.MakeUserContext(r), workspaceId, req)
+	if err != nil {
+		respondWithError(w, "failed to update snapshot approvers", err)
+		return
+	}
+	respondWithJson(w, http.StatusOK, approvers)
+}
+
+func (c snapshotApprovalControllerImpl) SubmitSnapshotApproval(w http.ResponseWriter, r *http.Request) {
+	cloudName, ok := c.getAgentCloudName(w, r)
+	if !ok {
+		return
+	}
+	var req view.SubmitSnapshotApprovalReq
+	if !readSnapshotApprovalReq(w, r, &req) {
+		return
+	}
+
+	approval, err := c.snapshotApprovalService.SubmitSnapshotApproval(secctx.MakeUserContext(r), getStringParam(r, "namespace"), getStringParam(r, "workspaceId"), getStringParam(r, "version"), cloudName, req)
+	if err != nil {
+		respondWithError(w, "failed to submit snapshot for approval", err)
+		return
+	}
+	respondWithJson(w, http.StatusCreated, approval)
+}
+
+func (c snapshotApprovalControllerImpl) GetSnapshotApproval(w http.ResponseWriter, r *http.Request) {
+	cloudName, ok := c.getAgentCloudName(w, r)
+	if !ok {
+		return
+	}
+
+	approval, err := c.snapshotApprovalService.GetSnapshotApproval(secctx.MakeUserContext(r), getStringParam(r, "namespace"), getStringParam(r, "workspaceId"), getStringParam(r, "version"), cloudName)
+	if err != nil {
+		respondWithError(w, "failed to get snapshot approval", err)
+		return
+	}
+	respondWithJson(w, http.StatusOK, approval)
+}
+
+func (c snapshotApprovalControllerImpl) DecideSnapshotApproval(w http.ResponseWriter, r *http.Request) {
+	cloudName, ok := c.getAgentCloudName(w, r)
+	if !ok {
+		return
+	}
+	var req view.SnapshotApprovalDecisionReq
+	if !readSnapshotApprovalReq(w, r, &req) {
+		return
+	}
+	validationErr := utils.ValidateObject(req)
+	if validationErr != nil {
+		if customError, ok := validationErr.(*exception.CustomError); ok {
+			RespondWithCustomError(w, customError)
+			return
+		}
+	}
+
+	approval, err := c.snapshotApprovalService.DecideSnapshotApproval(secctx.MakeUserContext(r), getStringParam(r, "namespace"), getStringParam(r, "workspaceId"), getStringParam(r, "version"), cloudName, req)
+	if err != nil {
+		respondWithError(w, "failed to save snapshot approval decision", err)
+		return
+	}
+	respondWithJson(w, http.StatusOK, approval)
+}
+
+func (c snapshotApprovalControllerImpl) CommentSnapshotApproval(w http.ResponseWriter, r *http.Request) {
+	cloudName, ok := c.getAgentCloudName(w, r)
+	if !ok {
+		return
+	}
+	var req view.SnapshotApprovalCommentReq
+	if !readSnapshotApprovalReq(w, r, &req) {
+		return
+	}
+	validationErr := utils.ValidateObject(req)
+	if validationErr != nil {
+		if customError, ok := validationErr.(*exception.CustomError); ok {
+			RespondWithCustomError(w, customError)
+			return
+		}
+	}
+
+	approval, err := c.snapshotApprovalService.CommentSnapshotApproval(secctx.MakeUserContext(r), getStringParam(r, "namespace"), getStringParam(r, "workspaceId"), getStringParam(r, "version"), cloudName, req)
+	if err != nil {
+		respondWithError(w, "failed to comment snapshot approval", err)
+		return
+	}
+	respondWithJson(w, http.StatusOK, approval)
+}
+
+// getAgentCloudName returns the cloud of the agent, snapshots are grouped by it
+func (c snapshotApprovalControllerImpl) getAgentCloudName(w http.ResponseWriter, r *http.Request) (string, bool) {
+	agentId := getStringParam(r, "agentId")
+	agent, err := c.agentService.GetAgent(agentId)
+	if err != nil {
+		respondWithError(w, "Failed to get agent", err)
+		return "", false
+	}
+	if agent == nil {
+		RespondWithCustomError(w, &exception.CustomError{
+			Status:  http.StatusNotFound,
+			Code:    exception.AgentNotFound,
+			Message: exception.AgentNotFoundMsg,
+			Params:  map[string]interface{}{"id": agentId}})
+		return "", false
+	}
+	return agent.AgentDeploymentCloud, true
+}
+
+// readSnapshotApprovalReq reads the request body, an empty body is allowed since all fields of some requests are optional
+func readSnapshotApprovalReq(w http.ResponseWriter, r *http.Request, req interface{}) bool {
+	defer r.Body.Close()
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		RespondWithCustomError(w, &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.BadRequestBody,
+			Message: exception.BadRequestBodyMsg,
+			Debug:   err.Error(),
+		})
+		return false
+	}
+	if len(body) == 0 {
+		return true
+	}
+	err = json.Unmarshal(body, req)
+	if err != nil {
+		RespondWithCustomError(w, &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.BadRequestBody,
+			Message: exception.BadRequestBodyMsg,
+			Debug:   err.Error(),
+		})
+		return false
+	}
+	return true
+}
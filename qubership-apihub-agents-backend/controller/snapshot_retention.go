@@ -0,0 +1,89 @@
+package controller
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/Netcracker/qubership-apihub-agents-backend/exception"
+	"github.com/Netcracker/qubership-apihub-agents-backend/secctx"
+	"github.com/Netcracker/qubership-apihub-agents-backend/service"
+	"github.com/Netcracker/qubership-apihub-agents-backend/utils"
+	"github.com/Netcracker/qubership-apihub-agents-backend/view"
+)
+
+type SnapshotRetentionController interface {
+	GetSnapshotRetentionPolicies(w http.ResponseWriter, r *http.Request)
+	UpdateSnapshotRetentionPolicies(w http.ResponseWriter, r *http.Request)
+	DryRunSnapshotRetention(w http.ResponseWriter, r *http.Request)
+}
+
+func NewSnapshotRetentionController(snapshotRetentionService service.SnapshotRetentionService) SnapshotRetentionController {
+	return &snapshotRetentionControllerImpl{snapshotRetentionService: snapshotRetentionService}
+}
+
+type snapshotRetentionControllerImpl struct {
+	snapshotRetentionService service.SnapshotRetentionService
+}
+
+func (c snapshotRetentionControllerImpl) GetSnapshotRetentionPolicies(w http.ResponseWriter, r *http.Request) {
+	workspaceId := getStringParam(r, "workspaceId")
+
+	policies, err := c.snapshotRetentionService.GetSnapshotRetentionPolicies(workspaceId)
+	if err != nil {
+		respondWithError(w, "failed to get snapshot retention policies", err)
+		return
+	}
+	respondWithJson(w, http.StatusOK, policies)
+}
+
+func (c snapshotRetentionControllerImpl) UpdateSnapshotRetentionPolicies(w http.ResponseWriter, r *http.Request) {
+	workspaceId := getStringParam(r, "workspaceId")
+	defer r.Body.Close()
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		RespondWithCustomError(w, &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.BadRequestBody,
+			Message: exception.BadRequestBodyMsg,
+			Debug:   err.Error(),
+		})
+		return
+	}
+	var req view.UpdateSnapshotRetentionPoliciesReq
+	err = json.Unmarshal(body, &req)
+	if err != nil {
+		RespondWithCustomError(w, &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.BadRequestBody,
+			Message: exception.BadRequestBodyMsg,
+			Debug:   err.Error(),
+		})
+		return
+	}
+	validationErr := utils.ValidateObject(req)
+	if validationErr != nil {
+		if customError, ok := validationErr.(*exception.CustomError); ok {
+			RespondWithCustomError(w, customError)
+			return
+		}
+	}
+
+	policies, err := c.snapshotRetentionService.UpdateSnapshotRetentionPolicies(secctx.MakeUserContext(r), workspaceId, req)
+	if err != nil {
+		respondWithError(w, "failed to update snapshot retention policies", err)
+		return
+	}
+	respondWithJson(w, http.StatusOK, policies)
+}
+
+func (c snapshotRetentionControllerImpl) DryRunSnapshotRetention(w http.ResponseWriter, r *http.Request) {
+	workspaceId := getStringParam(r, "workspaceId")
+
+	result, err := c.snapshotRetentionService.DryRunSnapshotRetention(secctx.MakeUserContext(r), workspaceId)
+	if err != nil {
+		respondWithError(w, "failed to dry run snapshot retention policies", err)
+		return
+	}
+	respondWithJson(w, http.StatusOK, result)
+}
@@ -0,0 +1,89 @@
+package controller
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/Netcracker/qubership-apihub-agents-backend/exception"
+	"github.com/Netcracker/qubership-apihub-agents-backend/secctx"
+	"github.com/Netcracker/qubership-apihub-agents-backend/service"
+	"github.com/Netcracker/qubership-apihub-agents-backend/utils"
+	"github.com/Netcracker/qubership-apihub-agents-backend/view"
+)
+
+type SnapshotVersionController interface {
+	GetSnapshotVersionTemplate(w http.ResponseWriter, r *http.Request)
+	UpdateSnapshotVersionTemplate(w http.ResponseWriter, r *http.Request)
+	DeleteSnapshotVersionTemplate(w http.ResponseWriter, r *http.Request)
+}
+
+func NewSnapshotVersionController(snapshotVersionService service.SnapshotVersionService) SnapshotVersionController {
+	return &snapshotVersionControllerImpl{snapshotVersionService: snapshotVersionService}
+}
+
+type snapshotVersionControllerImpl struct {
+	snapshotVersionService service.SnapshotVersionService
+}
+
+func (c snapshotVersionControllerImpl) GetSnapshotVersionTemplate(w http.ResponseWriter, r *http.Request) {
+	workspaceId := getStringParam(r, "workspaceId")
+
+	template, err := c.snapshotVersionService.GetSnapshotVersionTemplate(workspaceId)
+	if err != nil {
+		respondWithError(w, "failed to get snapshot version template", err)
+		return
+	}
+	respondWithJson(w, http.StatusOK, template)
+}
+
+func (c snapshotVersionControllerImpl) UpdateSnapshotVersionTemplate(w http.ResponseWriter, r *http.Request) {
+	workspaceId := getStringParam(r, "workspaceId")
+	defer r.Body.Close()
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		RespondWithCustomError(w, &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.BadRequestBody,
+			Message: exception.BadRequestBodyMsg,
+			Debug:   err.Error(),
+		})
+		return
+	}
+	var req view.UpdateSnapshotVersionTemplateReq
+	err = json.Unmarshal(body, &req)
+	if err != nil {
+		RespondWithCustomError(w, &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.BadRequestBody,
+			Message: exception.BadRequestBodyMsg,
+			Debug:   err.Error(),
+		})
+		return
+	}
+	validationErr := utils.ValidateObject(req)
+	if validationErr != nil {
+		if customError, ok := validationErr.(*exception.CustomError); ok {
+			RespondWithCustomError(w, customError)
+			return
+		}
+	}
+
+	template, err := c.snapshotVersionService.UpdateSnapshotVersionTemplate(secctx.MakeUserContext(r), workspaceId, req)
+	if err != nil {
+		respondWithError(w, "failed to update snapshot version template", err)
+		return
+	}
+	respondWithJson(w, http.StatusOK, template)
+}
+
+func (c snapshotVersionControllerImpl) DeleteSnapshotVersionTemplate(w http.ResponseWriter, r *http.Request) {
+	workspaceId := getStringParam(r, "workspaceId")
+
+	err := c.snapshotVersionService.DeleteSnapshotVersionTemplate(secctx.MakeUserContext(r), workspaceId)
+	if err != nil {
+		respondWithError(w, "failed to delete snapshot version template", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
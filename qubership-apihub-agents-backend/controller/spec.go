@@ -3,7 +3,6 @@ package controller
 import (
 	"net/http"
 
-	"github.com/Netcracker/qubership-apihub-agents-backend/client"
 	"github.com/Netcracker/qubership-apihub-agents-backend/exception"
 	"github.com/Netcracker/qubership-apihub-agents-backend/secctx"
 	"github.com/Netcracker/qubership-apihub-agents-backend/service"
@@ -14,12 +13,11 @@ type SpecificationsController interface {
 	GetServiceSpecification(w http.ResponseWriter, r *http.Request)
 }
 
-func NewSpecificationsController(agentClient client.AgentClient, agentService service.AgentService) SpecificationsController {
-	return specificationsControllerImpl{agentClient: agentClient, agentService: agentService}
+func NewSpecificationsController(agentService service.AgentService) SpecificationsController {
+	return specificationsControllerImpl{agentService: agentService}
 }
 
 type specificationsControllerImpl struct {
-	agentClient  client.AgentClient
 	agentService service.AgentService
 }
 
@@ -71,7 +69,8 @@ func (s specificationsControllerImpl) GetServiceSpecification(w http.ResponseWri
 		return
 	}
 
-	specBytes, err := s.agentClient.GetServiceSpecification(secctx.MakeUserContext(r), namespace, workspaceId, serviceId, fileId, agent.AgentUrl)
+	ctx := secctx.MakeUserContext(r)
+	specBytes, err := s.agentService.GetServiceSpecification(ctx, *agent, namespace, workspaceId, serviceId, fileId)
 	if err != nil {
 		log.Error("Failed to get specification: ", err.Error())
 		if customError, ok := err.(*exception.CustomError); ok {
@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/Netcracker/qubership-apihub-agents-backend/exception"
+	"github.com/Netcracker/qubership-apihub-agents-backend/secctx"
+	"github.com/Netcracker/qubership-apihub-agents-backend/service"
+	"github.com/Netcracker/qubership-apihub-agents-backend/utils"
+	"github.com/Netcracker/qubership-apihub-agents-backend/view"
+)
+
+type SpecValidationController interface {
+	GetSpecLintRules(w http.ResponseWriter, r *http.Request)
+	UpdateSpecLintRules(w http.ResponseWriter, r *http.Request)
+}
+
+func NewSpecValidationController(specValidationService service.SpecValidationService) SpecValidationController {
+	return &specValidationControllerImpl{specValidationService: specValidationService}
+}
+
+type specValidationControllerImpl struct {
+	specValidationService service.SpecValidationService
+}
+
+func (c specValidationControllerImpl) GetSpecLintRules(w http.ResponseWriter, r *http.Request) {
+	workspaceId := getStringParam(r, "workspaceId")
+
+	rules, err := c.specValidationService.GetSpecLintRules(workspaceId)
+	if err != nil {
+		respondWithError(w, "failed to get spec lint rules", err)
+		return
+	}
+	respondWithJson(w, http.StatusOK, rules)
+}
+
+func (c specValidationControllerImpl) UpdateSpecLintRules(w http.ResponseWriter, r *http.Request) {
+	workspaceId := getStringParam(r, "workspaceId")
+	defer r.Body.Close()
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		RespondWithCustomError(w, &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.BadRequestBody,
+			Message: exception.BadRequestBodyMsg,
+			Debug:   err.Error(),
+		})
+		return
+	}
+	var req view.UpdateSpecLintRulesReq
+	err = json.Unmarshal(body, &req)
+	if err != nil {
+		RespondWithCustomError(w, &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.BadRequestBody,
+			Message: exception.BadRequestBodyMsg,
+			Debug:   err.Error(),
+		})
+		return
+	}
+	validationErr := utils.ValidateObject(req)
+	if validationErr != nil {
+		if customError, ok := validationErr.(*exception.CustomError); ok {
+			RespondWithCustomError(w, customError)
+			return
+		}
+	}
+
+	rules, err := c.specValidationService.UpdateSpecLintRules(secctx.MakeUserContext(r), workspaceId, req)
+	if err != nil {
+		respondWithError(w, "failed to update spec lint rules", err)
+		return
+	}
+	respondWithJson(w, http.StatusOK, rules)
+}
@@ -115,3 +115,37 @@ func getFailOnErrorQueryParam(r *http.Request) (bool, *exception.CustomError) {
 	}
 	return false, nil
 }
+
+func getSyncPackagesQueryParam(r *http.Request) (bool, *exception.CustomError) {
+	if r.URL.Query().Get("syncPackages") != "" {
+		val, err := strconv.ParseBool(r.URL.Query().Get("syncPackages"))
+		if err != nil {
+			return false, &exception.CustomError{
+				Status:  http.StatusBadRequest,
+				Code:    exception.IncorrectParamType,
+				Message: exception.IncorrectParamTypeMsg,
+				Params:  map[string]interface{}{"param": "syncPackages", "type": "bool"},
+				Debug:   err.Error(),
+			}
+		}
+		return val, nil
+	}
+	return false, nil
+}
+
+func getRefreshQueryParam(r *http.Request) (bool, *exception.CustomError) {
+	if r.URL.Query().Get("refresh") != "" {
+		val, err := strconv.ParseBool(r.URL.Query().Get("refresh"))
+		if err != nil {
+			return false, &exception.CustomError{
+				Status:  http.StatusBadRequest,
+				Code:    exception.IncorrectParamType,
+				Message: exception.IncorrectParamTypeMsg,
+				Params:  map[string]interface{}{"param": "refresh", "type": "bool"},
+				Debug:   err.Error(),
+			}
+		}
+		return val, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,197 @@
+package controller
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/Netcracker/qubership-apihub-agents-backend/exception"
+	"github.com/Netcracker/qubership-apihub-agents-backend/secctx"
+	"github.com/Netcracker/qubership-apihub-agents-backend/service"
+	"github.com/Netcracker/qubership-apihub-agents-backend/utils"
+	"github.com/Netcracker/qubership-apihub-agents-backend/view"
+)
+
+type WorkspaceCopyController interface {
+	PreviewWorkspaceCopy(w http.ResponseWriter, r *http.Request)
+	CopyWorkspaceStructure(w http.ResponseWriter, r *http.Request)
+	ListWorkspaceCopies(w http.ResponseWriter, r *http.Request)
+	GetWorkspaceCopy(w http.ResponseWriter, r *http.Request)
+	ResumeWorkspaceCopy(w http.ResponseWriter, r *http.Request)
+	RollbackWorkspaceCopy(w http.ResponseWriter, r *http.Request)
+	GetWorkspaceDrift(w http.ResponseWriter, r *http.Request)
+	SyncWorkspaceStructure(w http.ResponseWriter, r *http.Request)
+}
+
+func NewWorkspaceCopyController(workspaceCopyService service.WorkspaceCopyService) WorkspaceCopyController {
+	return &workspaceCopyControllerImpl{workspaceCopyService: workspaceCopyService}
+}
+
+type workspaceCopyControllerImpl struct {
+	workspaceCopyService service.WorkspaceCopyService
+}
+
+func (c workspaceCopyControllerImpl) PreviewWorkspaceCopy(w http.ResponseWriter, r *http.Request) {
+	workspaceId := getStringParam(r, "workspaceId")
+	req, ok := readWorkspaceCopyReq(w, r)
+	if !ok {
+		return
+	}
+	plan, err := c.workspaceCopyService.PreviewWorkspaceCopy(secctx.MakeUserContext(r), workspaceId, *req)
+	if err != nil {
+		respondWithError(w, "failed to preview workspace structure copy", err)
+		return
+	}
+	respondWithJson(w, http.StatusOK, plan)
+}
+
+func (c workspaceCopyControllerImpl) CopyWorkspaceStructure(w http.ResponseWriter, r *http.Request) {
+	workspaceId := getStringParam(r, "workspaceId")
+	req, ok := readWorkspaceCopyReq(w, r)
+	if !ok {
+		return
+	}
+	workspaceCopy, err := c.workspaceCopyService.CopyWorkspaceStructure(secctx.MakeUserContext(r), workspaceId, *req)
+	if err != nil {
+		respondWithError(w, "failed to copy workspace structure", err)
+		return
+	}
+	respondWithJson(w, http.StatusOK, workspaceCopy)
+}
+
+func (c workspaceCopyControllerImpl) ListWorkspaceCopies(w http.ResponseWriter, r *http.Request) {
+	workspaceId := getStringParam(r, "workspaceId")
+	limit, cErr := getLimitQueryParam(r)
+	if cErr != nil {
+		respondWithError(w, cErr.Error(), cErr)
+		return
+	}
+	page, cErr := getPageQueryParam(r)
+	if cErr != nil {
+		respondWithError(w, cErr.Error(), cErr)
+		return
+	}
+
+	copies, err := c.workspaceCopyService.ListWorkspaceCopies(workspaceId, limit, page)
+	if err != nil {
+		respondWithError(w, "failed to list workspace structure copies", err)
+		return
+	}
+	respondWithJson(w, http.StatusOK, copies)
+}
+
+func (c workspaceCopyControllerImpl) GetWorkspaceCopy(w http.ResponseWriter, r *http.Request) {
+	workspaceId := getStringParam(r, "workspaceId")
+	copyId := getStringParam(r, "copyId")
+
+	workspaceCopy, err := c.workspaceCopyService.GetWorkspaceCopy(workspaceId, copyId)
+	if err != nil {
+		respondWithError(w, "failed to get workspace structure copy", err)
+		return
+	}
+	respondWithJson(w, http.StatusOK, workspaceCopy)
+}
+
+func (c workspaceCopyControllerImpl) ResumeWorkspaceCopy(w http.ResponseWriter, r *http.Request) {
+	workspaceId := getStringParam(r, "workspaceId")
+	copyId := getStringParam(r, "copyId")
+
+	workspaceCopy, err := c.workspaceCopyService.ResumeWorkspaceCopy(secctx.MakeUserContext(r), workspaceId, copyId)
+	if err != nil {
+		respondWithError(w, "failed to resume workspace structure copy", err)
+		return
+	}
+	respondWithJson(w, http.StatusOK, workspaceCopy)
+}
+
+func (c workspaceCopyControllerImpl) RollbackWorkspaceCopy(w http.ResponseWriter, r *http.Request) {
+	workspaceId := getStringParam(r, "workspaceId")
+	copyId := getStringParam(r, "copyId")
+
+	workspaceCopy, err := c.workspaceCopyService.RollbackWorkspaceCopy(secctx.MakeUserContext(r), workspaceId, copyId)
+	if err != nil {
+		respondWithError(w, "failed to roll back workspace structure copy", err)
+		return
+	}
+	respondWithJson(w, http.StatusOK, workspaceCopy)
+}
+
+func (c workspaceCopyControllerImpl) GetWorkspaceDrift(w http.ResponseWriter, r *http.Request) {
+	workspaceId := getStringParam(r, "workspaceId")
+	srcWorkspaceId := r.URL.Query().Get("srcWorkspaceId")
+
+	report, err := c.workspaceCopyService.GetWorkspaceDrift(secctx.MakeUserContext(r), workspaceId, srcWorkspaceId)
+	if err != nil {
+		respondWithError(w, "failed to get workspace structure drift", err)
+		return
+	}
+	respondWithJson(w, http.StatusOK, report)
+}
+
+func (c workspaceCopyControllerImpl) SyncWorkspaceStructure(w http.ResponseWriter, r *http.Request) {
+	workspaceId := getStringParam(r, "workspaceId")
+	defer r.Body.Close()
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		RespondWithCustomError(w, &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.BadRequestBody,
+			Message: exception.BadRequestBodyMsg,
+			Debug:   err.Error(),
+		})
+		return
+	}
+	var req view.WorkspaceSyncReq
+	if len(body) > 0 {
+		err = json.Unmarshal(body, &req)
+		if err != nil {
+			RespondWithCustomError(w, &exception.CustomError{
+				Status:  http.StatusBadRequest,
+				Code:    exception.BadRequestBody,
+				Message: exception.BadRequestBodyMsg,
+				Debug:   err.Error(),
+			})
+			return
+		}
+	}
+
+	report, err := c.workspaceCopyService.SyncWorkspaceStructure(secctx.MakeUserContext(r), workspaceId, req)
+	if err != nil {
+		respondWithError(w, "failed to sync workspace structure", err)
+		return
+	}
+	respondWithJson(w, http.StatusOK, report)
+}
+
+func readWorkspaceCopyReq(w http.ResponseWriter, r *http.Request) (*view.WorkspaceCopyReq, bool) {
+	defer r.Body.Close()
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		RespondWithCustomError(w, &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.BadRequestBody,
+			Message: exception.BadRequestBodyMsg,
+			Debug:   err.Error(),
+		})
+		return nil, false
+	}
+	var req view.WorkspaceCopyReq
+	err = json.Unmarshal(body, &req)
+	if err != nil {
+		RespondWithCustomError(w, &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.BadRequestBody,
+			Message: exception.BadRequestBodyMsg,
+			Debug:   err.Error(),
+		})
+		return nil, false
+	}
+	validationErr := utils.ValidateObject(req)
+	if validationErr != nil {
+		if customError, ok := validationErr.(*exception.CustomError); ok {
+			RespondWithCustomError(w, customError)
+			return nil, false
+		}
+	}
+	return &req, true
+}
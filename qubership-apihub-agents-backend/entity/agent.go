@@ -1,11 +1,14 @@
 package entity
 
 import (
+	"sort"
 	"time"
 
 	"github.com/Netcracker/qubership-apihub-agents-backend/view"
 )
 
+const agentInactivityThreshold = time.Second * 30
+
 type AgentEntity struct {
 	tableName struct{} `pg:"agent"`
 
@@ -19,9 +22,32 @@ type AgentEntity struct {
 	AgentVersion   string    `pg:"agent_version, type:varchar"`
 }
 
+type AgentReplicaEntity struct {
+	tableName struct{} `pg:"agent_replica, alias:agent_replica"`
+
+	AgentId        string    `pg:"agent_id, pk, type:varchar"`
+	InstanceId     string    `pg:"instance_id, pk, type:varchar"`
+	Url            string    `pg:"url, type:varchar"`
+	LastActive     time.Time `pg:"last_active, type:timestamp without time zone"`
+	BackendVersion string    `pg:"backend_version, type:varchar"`
+	AgentVersion   string    `pg:"agent_version, type:varchar"`
+}
+
+// AgentDiscoveryReplicaEntity is the agent replica which runs discovery of the namespace for the workspace,
+// discovery results are kept in the replica memory
+type AgentDiscoveryReplicaEntity struct {
+	tableName struct{} `pg:"agent_discovery_replica, alias:agent_discovery_replica"`
+
+	AgentId     string    `pg:"agent_id, pk, type:varchar"`
+	Namespace   string    `pg:"namespace, pk, type:varchar"`
+	WorkspaceId string    `pg:"workspace_id, pk, type:varchar"`
+	Url         string    `pg:"url, type:varchar"`
+	PinnedAt    time.Time `pg:"pinned_at, type:timestamp without time zone"`
+}
+
 func MakeAgentView(ent AgentEntity) view.AgentInstance {
 	status := view.AgentStatusActive
-	if time.Since(ent.LastActive) > time.Second*30 {
+	if time.Since(ent.LastActive) > agentInactivityThreshold {
 		status = view.AgentStatusInactive
 	}
 	name := ent.Name
@@ -41,3 +67,26 @@ func MakeAgentView(ent AgentEntity) view.AgentInstance {
 		AgentVersion:             ent.AgentVersion,
 	}
 }
+
+// MakeAgentReplicasView returns replicas ordered by last activity, the most recently active replica goes first
+func MakeAgentReplicasView(ents []AgentReplicaEntity) []view.AgentReplica {
+	result := make([]view.AgentReplica, 0, len(ents))
+	for _, ent := range ents {
+		status := view.AgentStatusActive
+		if time.Since(ent.LastActive) > agentInactivityThreshold {
+			status = view.AgentStatusInactive
+		}
+		result = append(result, view.AgentReplica{
+			InstanceId:     ent.InstanceId,
+			Url:            ent.Url,
+			LastActive:     ent.LastActive,
+			Status:         status,
+			BackendVersion: ent.BackendVersion,
+			AgentVersion:   ent.AgentVersion,
+		})
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].LastActive.After(result[j].LastActive)
+	})
+	return result
+}
@@ -0,0 +1,25 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/Netcracker/qubership-apihub-agents-backend/view"
+)
+
+type BaselineMappingEntity struct {
+	tableName struct{} `pg:"baseline_mapping, alias:baseline_mapping"`
+
+	WorkspaceId string                     `pg:"workspace_id, pk, type:varchar"`
+	Rules       []view.BaselineMappingRule `pg:"rules, type:jsonb"`
+	UpdatedAt   time.Time                  `pg:"updated_at, type:timestamp without time zone"`
+	UpdatedBy   string                     `pg:"updated_by, type:varchar"`
+}
+
+func MakeBaselineMappingRulesView(ent BaselineMappingEntity) view.BaselineMappingRules {
+	return view.BaselineMappingRules{
+		WorkspaceId: ent.WorkspaceId,
+		Rules:       ent.Rules,
+		UpdatedAt:   &ent.UpdatedAt,
+		UpdatedBy:   ent.UpdatedBy,
+	}
+}
@@ -0,0 +1,64 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/Netcracker/qubership-apihub-agents-backend/view"
+)
+
+type BulkDiscoveryEntity struct {
+	tableName struct{} `pg:"bulk_discovery, alias:bulk_discovery"`
+
+	JobId       string     `pg:"job_id, pk, type:varchar"`
+	WorkspaceId string     `pg:"workspace_id, type:varchar"`
+	Status      string     `pg:"status, type:varchar"`
+	Details     string     `pg:"details, type:varchar"`
+	CreatedAt   time.Time  `pg:"created_at, type:timestamp without time zone"`
+	CreatedBy   string     `pg:"created_by, type:varchar"`
+	FinishedAt  *time.Time `pg:"finished_at, type:timestamp without time zone"`
+}
+
+type BulkDiscoveryTargetEntity struct {
+	tableName struct{} `pg:"bulk_discovery_target, alias:bulk_discovery_target"`
+
+	JobId         string     `pg:"job_id, pk, type:varchar"`
+	AgentId       string     `pg:"agent_id, pk, type:varchar"`
+	Namespace     string     `pg:"namespace, pk, type:varchar"`
+	Status        string     `pg:"status, type:varchar"`
+	Details       string     `pg:"details, type:varchar"`
+	ServicesCount int        `pg:"services_count, type:integer, use_zero"`
+	StartedAt     *time.Time `pg:"started_at, type:timestamp without time zone"`
+	FinishedAt    *time.Time `pg:"finished_at, type:timestamp without time zone"`
+}
+
+func MakeBulkDiscoveryJobView(ent BulkDiscoveryEntity, targetEnts []BulkDiscoveryTargetEntity) view.BulkDiscoveryJob {
+	result := view.BulkDiscoveryJob{
+		JobId:        ent.JobId,
+		WorkspaceId:  ent.WorkspaceId,
+		Status:       view.Status(ent.Status),
+		Details:      ent.Details,
+		CreatedAt:    ent.CreatedAt,
+		CreatedBy:    ent.CreatedBy,
+		FinishedAt:   ent.FinishedAt,
+		TargetsTotal: len(targetEnts),
+		Targets:      make([]view.BulkDiscoveryTargetStatus, 0, len(targetEnts)),
+	}
+	for _, targetEnt := range targetEnts {
+		switch view.Status(targetEnt.Status) {
+		case view.StatusComplete:
+			result.TargetsComplete++
+		case view.StatusError:
+			result.TargetsFailed++
+		}
+		result.Targets = append(result.Targets, view.BulkDiscoveryTargetStatus{
+			AgentId:       targetEnt.AgentId,
+			Namespace:     targetEnt.Namespace,
+			Status:        view.Status(targetEnt.Status),
+			Details:       targetEnt.Details,
+			ServicesCount: targetEnt.ServicesCount,
+			StartedAt:     targetEnt.StartedAt,
+			FinishedAt:    targetEnt.FinishedAt,
+		})
+	}
+	return result
+}
@@ -0,0 +1,45 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/Netcracker/qubership-apihub-agents-backend/view"
+)
+
+type CleanupRunEntity struct {
+	tableName struct{} `pg:"cleanup_run, alias:cleanup_run"`
+
+	RunId                 string                 `pg:"run_id, pk, type:varchar"`
+	JobType               string                 `pg:"job_type, type:varchar"`
+	Trigger               string                 `pg:"trigger, type:varchar"`
+	Status                string                 `pg:"status, type:varchar"`
+	Details               string                 `pg:"details, type:varchar"`
+	StartedAt             time.Time              `pg:"started_at, type:timestamp without time zone"`
+	StartedBy             string                 `pg:"started_by, type:varchar"`
+	FinishedAt            *time.Time             `pg:"finished_at, type:timestamp without time zone"`
+	WorkspacesScanned     int                    `pg:"workspaces_scanned, type:integer, use_zero"`
+	GroupsProcessed       int                    `pg:"groups_processed, type:integer, use_zero"`
+	DeletedSnapshots      int                    `pg:"deleted_snapshots, type:integer, use_zero"`
+	DeletedSecurityChecks int                    `pg:"deleted_security_checks, type:integer, use_zero"`
+	Groups                []view.CleanupRunGroup `pg:"groups, type:jsonb"`
+	Errors                []string               `pg:"errors, type:jsonb"`
+}
+
+func MakeCleanupRunView(ent CleanupRunEntity) view.CleanupRun {
+	return view.CleanupRun{
+		RunId:                 ent.RunId,
+		JobType:               ent.JobType,
+		Trigger:               ent.Trigger,
+		Status:                view.Status(ent.Status),
+		Details:               ent.Details,
+		StartedAt:             ent.StartedAt,
+		StartedBy:             ent.StartedBy,
+		FinishedAt:            ent.FinishedAt,
+		WorkspacesScanned:     ent.WorkspacesScanned,
+		GroupsProcessed:       ent.GroupsProcessed,
+		DeletedSnapshots:      ent.DeletedSnapshots,
+		DeletedSecurityChecks: ent.DeletedSecurityChecks,
+		Groups:                ent.Groups,
+		Errors:                ent.Errors,
+	}
+}
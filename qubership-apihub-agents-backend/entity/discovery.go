@@ -0,0 +1,74 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/Netcracker/qubership-apihub-agents-backend/view"
+)
+
+type NamespaceDiscoveryEntity struct {
+	tableName struct{} `pg:"namespace_discovery, alias:namespace_discovery"`
+
+	AgentId     string     `pg:"agent_id, pk, type:varchar"`
+	Namespace   string     `pg:"namespace, pk, type:varchar"`
+	WorkspaceId string     `pg:"workspace_id, pk, type:varchar"`
+	Status      string     `pg:"status, type:varchar"`
+	Details     string     `pg:"details, type:varchar"`
+	StartedAt   time.Time  `pg:"started_at, type:timestamp without time zone"`
+	StartedBy   string     `pg:"started_by, type:varchar"`
+	FinishedAt  *time.Time `pg:"finished_at, type:timestamp without time zone"`
+}
+
+func MakeNamespaceDiscoveryOverviewView(ent NamespaceDiscoveryEntity) view.NamespaceDiscoveryOverview {
+	return view.NamespaceDiscoveryOverview{
+		Status:     ent.Status,
+		Details:    ent.Details,
+		StartedAt:  ent.StartedAt,
+		StartedBy:  ent.StartedBy,
+		FinishedAt: ent.FinishedAt,
+	}
+}
+
+type DiscoveryHistoryEntity struct {
+	tableName struct{} `pg:"discovery_history, alias:discovery_history"`
+
+	DiscoveryId   string                   `pg:"discovery_id, pk, type:varchar"`
+	AgentId       string                   `pg:"agent_id, type:varchar"`
+	CloudName     string                   `pg:"cloud_name, type:varchar"`
+	Namespace     string                   `pg:"namespace, type:varchar"`
+	WorkspaceId   string                   `pg:"workspace_id, type:varchar"`
+	Status        string                   `pg:"status, type:varchar"`
+	Details       string                   `pg:"details, type:varchar"`
+	StartedAt     *time.Time               `pg:"started_at, type:timestamp without time zone"`
+	StartedBy     string                   `pg:"started_by, type:varchar"`
+	FinishedAt    time.Time                `pg:"finished_at, type:timestamp without time zone"`
+	ServicesCount int                      `pg:"services_count, type:integer, use_zero"`
+	Services      []view.DiscoveredService `pg:"services, type:jsonb"`
+}
+
+func MakeDiscoveryHistoryItemView(ent DiscoveryHistoryEntity) view.DiscoveryHistoryItem {
+	return view.DiscoveryHistoryItem{
+		DiscoveryId:   ent.DiscoveryId,
+		AgentId:       ent.AgentId,
+		CloudName:     ent.CloudName,
+		Namespace:     ent.Namespace,
+		WorkspaceId:   ent.WorkspaceId,
+		Status:        ent.Status,
+		Details:       ent.Details,
+		StartedAt:     ent.StartedAt,
+		StartedBy:     ent.StartedBy,
+		FinishedAt:    ent.FinishedAt,
+		ServicesCount: ent.ServicesCount,
+	}
+}
+
+func MakeDiscoveryHistoryView(ent DiscoveryHistoryEntity) view.DiscoveryHistory {
+	services := ent.Services
+	if services == nil {
+		services = make([]view.DiscoveredService, 0)
+	}
+	return view.DiscoveryHistory{
+		DiscoveryHistoryItem: MakeDiscoveryHistoryItemView(ent),
+		Services:             services,
+	}
+}
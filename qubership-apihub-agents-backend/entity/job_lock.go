@@ -0,0 +1,12 @@
+package entity
+
+import "time"
+
+type JobLockEntity struct {
+	tableName struct{} `pg:"job_lock, alias:job_lock"`
+
+	JobName    string    `pg:"job_name, pk, type:varchar"`
+	InstanceId string    `pg:"instance_id, type:varchar"`
+	AcquiredAt time.Time `pg:"acquired_at, type:timestamp without time zone"`
+	ExpiresAt  time.Time `pg:"expires_at, type:timestamp without time zone"`
+}
@@ -91,3 +91,12 @@ type NamespaceSecurityCheckResultEntity struct {
 	ActualResponseCode   int      `pg:"actual_response_code, type:integer, use_zero"`
 	ExpectedResponseCode int      `pg:"expected_response_code, type:integer, use_zero"`
 }
+
+func MakeNamespaceSecurityCheckOverviewView(ent NamespaceSecurityCheckStatusEntity) view.NamespaceSecurityCheckOverview {
+	return view.NamespaceSecurityCheckOverview{
+		ProcessId:                    ent.ProcessId,
+		NamespaceSecurityCheckStatus: MakeNamespaceSecurityCheckStatusView(ent),
+		StartedAt:                    ent.StartedAt,
+		FinishedAt:                   ent.FinishedAt,
+	}
+}
@@ -0,0 +1,103 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/Netcracker/qubership-apihub-agents-backend/view"
+)
+
+type SnapshotApproversEntity struct {
+	tableName struct{} `pg:"snapshot_approvers, alias:snapshot_approvers"`
+
+	WorkspaceId string    `pg:"workspace_id, pk, type:varchar"`
+	Approvers   []string  `pg:"approvers, type:jsonb"`
+	UpdatedAt   time.Time `pg:"updated_at, type:timestamp without time zone"`
+	UpdatedBy   string    `pg:"updated_by, type:varchar"`
+}
+
+type SnapshotApprovalEntity struct {
+	tableName struct{} `pg:"snapshot_approval, alias:snapshot_approval"`
+
+	ApprovalId  string    `pg:"approval_id, pk, type:varchar"`
+	WorkspaceId string    `pg:"workspace_id, type:varchar"`
+	CloudName   string    `pg:"cloud_name, type:varchar"`
+	Namespace   string    `pg:"namespace, type:varchar"`
+	PackageId   string    `pg:"package_id, type:varchar"`
+	Version     string    `pg:"version, type:varchar"`
+	Status      string    `pg:"status, type:varchar"`
+	CreatedAt   time.Time `pg:"created_at, type:timestamp without time zone"`
+	CreatedBy   string    `pg:"created_by, type:varchar"`
+	UpdatedAt   time.Time `pg:"updated_at, type:timestamp without time zone"`
+}
+
+type SnapshotApprovalServiceEntity struct {
+	tableName struct{} `pg:"snapshot_approval_service, alias:snapshot_approval_service"`
+
+	ApprovalId string     `pg:"approval_id, pk, type:varchar"`
+	ServiceId  string     `pg:"service_id, pk, type:varchar"`
+	PackageId  string     `pg:"package_id, type:varchar"`
+	Version    string     `pg:"version, type:varchar"`
+	Status     string     `pg:"status, type:varchar"`
+	DecidedAt  *time.Time `pg:"decided_at, type:timestamp without time zone"`
+	DecidedBy  string     `pg:"decided_by, type:varchar"`
+	Comment    string     `pg:"comment, type:varchar"`
+}
+
+type SnapshotApprovalEventEntity struct {
+	tableName struct{} `pg:"snapshot_approval_event, alias:snapshot_approval_event"`
+
+	EventId    string    `pg:"event_id, pk, type:varchar"`
+	ApprovalId string    `pg:"approval_id, type:varchar"`
+	ServiceId  string    `pg:"service_id, type:varchar"`
+	Action     string    `pg:"action, type:varchar"`
+	Comment    string    `pg:"comment, type:varchar"`
+	CreatedAt  time.Time `pg:"created_at, type:timestamp without time zone"`
+	CreatedBy  string    `pg:"created_by, type:varchar"`
+}
+
+func MakeSnapshotApproversView(ent SnapshotApproversEntity) view.SnapshotApprovers {
+	return view.SnapshotApprovers{
+		WorkspaceId: ent.WorkspaceId,
+		Approvers:   ent.Approvers,
+		UpdatedAt:   &ent.UpdatedAt,
+		UpdatedBy:   ent.UpdatedBy,
+	}
+}
+
+func MakeSnapshotApprovalView(ent SnapshotApprovalEntity, serviceEnts []SnapshotApprovalServiceEntity, eventEnts []SnapshotApprovalEventEntity) view.SnapshotApproval {
+	result := view.SnapshotApproval{
+		ApprovalId:  ent.ApprovalId,
+		WorkspaceId: ent.WorkspaceId,
+		CloudName:   ent.CloudName,
+		Namespace:   ent.Namespace,
+		PackageId:   ent.PackageId,
+		Version:     ent.Version,
+		Status:      view.SnapshotApprovalStatus(ent.Status),
+		CreatedAt:   ent.CreatedAt,
+		CreatedBy:   ent.CreatedBy,
+		UpdatedAt:   ent.UpdatedAt,
+		Services:    make([]view.SnapshotApprovalService, 0, len(serviceEnts)),
+		Events:      make([]view.SnapshotApprovalEvent, 0, len(eventEnts)),
+	}
+	for _, serviceEnt := range serviceEnts {
+		result.Services = append(result.Services, view.SnapshotApprovalService{
+			ServiceId: serviceEnt.ServiceId,
+			PackageId: serviceEnt.PackageId,
+			Version:   serviceEnt.Version,
+			Status:    view.SnapshotApprovalStatus(serviceEnt.Status),
+			DecidedAt: serviceEnt.DecidedAt,
+			DecidedBy: serviceEnt.DecidedBy,
+			Comment:   serviceEnt.Comment,
+		})
+	}
+	for _, eventEnt := range eventEnts {
+		result.Events = append(result.Events, view.SnapshotApprovalEvent{
+			ServiceId: eventEnt.ServiceId,
+			Action:    view.SnapshotApprovalAction(eventEnt.Action),
+			Comment:   eventEnt.Comment,
+			CreatedAt: eventEnt.CreatedAt,
+			CreatedBy: eventEnt.CreatedBy,
+		})
+	}
+	return result
+}
@@ -0,0 +1,25 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/Netcracker/qubership-apihub-agents-backend/view"
+)
+
+type SnapshotRetentionEntity struct {
+	tableName struct{} `pg:"snapshot_retention, alias:snapshot_retention"`
+
+	WorkspaceId string                         `pg:"workspace_id, pk, type:varchar"`
+	Policies    []view.SnapshotRetentionPolicy `pg:"policies, type:jsonb"`
+	UpdatedAt   time.Time                      `pg:"updated_at, type:timestamp without time zone"`
+	UpdatedBy   string                         `pg:"updated_by, type:varchar"`
+}
+
+func MakeSnapshotRetentionPoliciesView(ent SnapshotRetentionEntity) view.SnapshotRetentionPolicies {
+	return view.SnapshotRetentionPolicies{
+		WorkspaceId: ent.WorkspaceId,
+		Policies:    ent.Policies,
+		UpdatedAt:   &ent.UpdatedAt,
+		UpdatedBy:   ent.UpdatedBy,
+	}
+}
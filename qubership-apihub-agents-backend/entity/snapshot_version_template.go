@@ -0,0 +1,25 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/Netcracker/qubership-apihub-agents-backend/view"
+)
+
+type SnapshotVersionTemplateEntity struct {
+	tableName struct{} `pg:"snapshot_version_template, alias:snapshot_version_template"`
+
+	WorkspaceId string    `pg:"workspace_id, pk, type:varchar"`
+	Template    string    `pg:"template, type:varchar"`
+	UpdatedAt   time.Time `pg:"updated_at, type:timestamp without time zone"`
+	UpdatedBy   string    `pg:"updated_by, type:varchar"`
+}
+
+func MakeSnapshotVersionTemplateView(ent SnapshotVersionTemplateEntity) view.SnapshotVersionTemplate {
+	return view.SnapshotVersionTemplate{
+		WorkspaceId: ent.WorkspaceId,
+		Template:    ent.Template,
+		UpdatedAt:   &ent.UpdatedAt,
+		UpdatedBy:   ent.UpdatedBy,
+	}
+}
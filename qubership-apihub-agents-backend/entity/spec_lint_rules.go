@@ -0,0 +1,25 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/Netcracker/qubership-apihub-agents-backend/view"
+)
+
+type SpecLintRulesEntity struct {
+	tableName struct{} `pg:"spec_lint_rules, alias:spec_lint_rules"`
+
+	WorkspaceId string              `pg:"workspace_id, pk, type:varchar"`
+	Rules       []view.SpecLintRule `pg:"rules, type:jsonb"`
+	UpdatedAt   time.Time           `pg:"updated_at, type:timestamp without time zone"`
+	UpdatedBy   string              `pg:"updated_by, type:varchar"`
+}
+
+func MakeSpecLintRulesView(ent SpecLintRulesEntity) view.SpecLintRules {
+	return view.SpecLintRules{
+		WorkspaceId: ent.WorkspaceId,
+		Rules:       ent.Rules,
+		UpdatedAt:   &ent.UpdatedAt,
+		UpdatedBy:   ent.UpdatedBy,
+	}
+}
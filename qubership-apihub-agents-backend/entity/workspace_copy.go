@@ -0,0 +1,97 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/Netcracker/qubership-apihub-agents-backend/view"
+)
+
+type WorkspaceCopyEntity struct {
+	tableName struct{} `pg:"workspace_copy, alias:workspace_copy"`
+
+	CopyId         string     `pg:"copy_id, pk, type:varchar"`
+	SrcWorkspaceId string     `pg:"src_workspace_id, type:varchar"`
+	DstWorkspaceId string     `pg:"dst_workspace_id, type:varchar"`
+	DefaultRole    string     `pg:"default_role, type:varchar"`
+	Status         string     `pg:"status, type:varchar"`
+	Details        string     `pg:"details, type:varchar"`
+	CreatedAt      time.Time  `pg:"created_at, type:timestamp without time zone"`
+	CreatedBy      string     `pg:"created_by, type:varchar"`
+	FinishedAt     *time.Time `pg:"finished_at, type:timestamp without time zone"`
+}
+
+type WorkspaceCopyPackageEntity struct {
+	tableName struct{} `pg:"workspace_copy_package, alias:workspace_copy_package"`
+
+	CopyId                string `pg:"copy_id, pk, type:varchar"`
+	PackageId             string `pg:"package_id, pk, type:varchar"`
+	Seq                   int    `pg:"seq, type:integer, use_zero"`
+	SrcPackageId          string `pg:"src_package_id, type:varchar"`
+	ParentId              string `pg:"parent_id, type:varchar"`
+	Kind                  string `pg:"kind, type:varchar"`
+	Name                  string `pg:"name, type:varchar"`
+	Alias                 string `pg:"alias, type:varchar"`
+	Description           string `pg:"description, type:varchar"`
+	ServiceName           string `pg:"service_name, type:varchar"`
+	ImageUrl              string `pg:"image_url, type:varchar"`
+	ReleaseVersionPattern string `pg:"release_version_pattern, type:varchar"`
+	Status                string `pg:"status, type:varchar"`
+	Details               string `pg:"details, type:varchar"`
+}
+
+func MakeWorkspaceCopyPackageEntity(copyId string, seq int, pkg view.WorkspaceCopyPackage) WorkspaceCopyPackageEntity {
+	return WorkspaceCopyPackageEntity{
+		CopyId:                copyId,
+		PackageId:             pkg.PackageId,
+		Seq:                   seq,
+		SrcPackageId:          pkg.SrcPackageId,
+		ParentId:              pkg.ParentId,
+		Kind:                  pkg.Kind,
+		Name:                  pkg.Name,
+		Alias:                 pkg.Alias,
+		Description:           pkg.Description,
+		ServiceName:           pkg.ServiceName,
+		ImageUrl:              pkg.ImageUrl,
+		ReleaseVersionPattern: pkg.ReleaseVersionPattern,
+		Status:                string(pkg.Status),
+		Details:               pkg.Details,
+	}
+}
+
+func MakeWorkspaceCopyPackageView(ent WorkspaceCopyPackageEntity) view.WorkspaceCopyPackage {
+	return view.WorkspaceCopyPackage{
+		PackageId:             ent.PackageId,
+		SrcPackageId:          ent.SrcPackageId,
+		ParentId:              ent.ParentId,
+		Kind:                  ent.Kind,
+		Name:                  ent.Name,
+		Alias:                 ent.Alias,
+		Description:           ent.Description,
+		ServiceName:           ent.ServiceName,
+		ImageUrl:              ent.ImageUrl,
+		ReleaseVersionPattern: ent.ReleaseVersionPattern,
+		Status:                view.WorkspaceCopyPackageStatus(ent.Status),
+		Details:               ent.Details,
+	}
+}
+
+func MakeWorkspaceCopyView(ent WorkspaceCopyEntity, packageEnts []WorkspaceCopyPackageEntity) view.WorkspaceCopy {
+	result := view.WorkspaceCopy{
+		CopyId:         ent.CopyId,
+		SrcWorkspaceId: ent.SrcWorkspaceId,
+		DstWorkspaceId: ent.DstWorkspaceId,
+		DefaultRole:    ent.DefaultRole,
+		Status:         view.WorkspaceCopyStatus(ent.Status),
+		Details:        ent.Details,
+		CreatedAt:      ent.CreatedAt,
+		CreatedBy:      ent.CreatedBy,
+		FinishedAt:     ent.FinishedAt,
+	}
+	if packageEnts != nil {
+		result.Packages = make([]view.WorkspaceCopyPackage, 0, len(packageEnts))
+		for _, packageEnt := range packageEnts {
+			result.Packages = append(result.Packages, MakeWorkspaceCopyPackageView(packageEnt))
+		}
+	}
+	return result
+}
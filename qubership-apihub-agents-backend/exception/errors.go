@@ -74,3 +74,66 @@ const SecurityCheckNotFoundMsg = "Security check with processId='$processId' not
 
 const InsufficientPrivileges = "17"
 const InsufficientPrivilegesMsg = "You don't have enough privileges to perform this operation"
+
+const DiscoveryNotFound = "18"
+const DiscoveryNotFoundMsg = "Discovery with id '$discoveryId' not found"
+
+const NoDiscoveryTargets = "19"
+const NoDiscoveryTargetsMsg = "No agent namespaces match the bulk discovery request"
+
+const BulkDiscoveryNotFound = "20"
+const BulkDiscoveryNotFoundMsg = "Bulk discovery with jobId='$jobId' not found"
+
+const WorkspaceCopyNotFound = "21"
+const WorkspaceCopyNotFoundMsg = "Workspace structure copy with copyId='$copyId' not found"
+
+const IncorrectWorkspaceCopyStatus = "22"
+const IncorrectWorkspaceCopyStatusMsg = "Unable to $action workspace structure copy '$copyId' in status '$status'"
+
+const SnapshotNotFound = "23"
+const SnapshotNotFoundMsg = "Snapshot '$version' not found"
+
+const ReleaseVersionPatternMismatch = "24"
+const ReleaseVersionPatternMismatchMsg = "Release version '$version' doesn't match release version pattern '$pattern' of package '$packageId'"
+
+const SnapshotVersionTemplateNotResolved = "25"
+const SnapshotVersionTemplateNotResolvedMsg = "Unable to resolve snapshot version template '$template': $reason"
+
+const SpecValidationFailed = "26"
+const SpecValidationFailedMsg = "Specifications of services $services have errors, snapshot is not published"
+
+const InvalidSnapshotBundle = "27"
+const InvalidSnapshotBundleMsg = "Invalid snapshot bundle: $reason"
+
+const BaselineNotFound = "28"
+const BaselineNotFoundMsg = "Baseline packages are not found for services $services"
+
+const PromoteStatusNotAllowed = "29"
+const PromoteStatusNotAllowedMsg = "Not enough privileges to publish $status version to packages $packages"
+
+const SnapshotApprovalNotFound = "30"
+const SnapshotApprovalNotFoundMsg = "Snapshot '$version' is not submitted for approval"
+
+const SnapshotApprovalAlreadySubmitted = "31"
+const SnapshotApprovalAlreadySubmittedMsg = "Snapshot '$version' is already submitted for approval, approval status is '$status'"
+
+const NotSnapshotApprover = "32"
+const NotSnapshotApproverMsg = "User '$userId' is not a snapshot approver in workspace '$workspaceId'"
+
+const SnapshotNotApproved = "33"
+const SnapshotNotApprovedMsg = "Promotion to release requires approval of snapshot '$version', services are not approved: $services"
+
+const SnapshotApproversNotConfigured = "34"
+const SnapshotApproversNotConfiguredMsg = "Snapshot approvers are not configured for workspace '$workspaceId'"
+
+const CleanupRunInProgress = "35"
+const CleanupRunInProgressMsg = "Cleanup job '$jobType' is already running"
+
+const CleanupRunNotFound = "36"
+const CleanupRunNotFoundMsg = "Cleanup run '$runId' not found"
+
+const WorkspaceLocked = "37"
+const WorkspaceLockedMsg = "Workspace '$workspaceId' structure is being changed by another operation, try again later"
+
+const ReleasePromotionRequiresApproval = "38"
+const ReleasePromotionRequiresApprovalMsg = "Workspace '$workspaceId' has snapshot approvers, discovered specifications cannot be promoted to release. Use promote snapshot operation for the approved snapshot"
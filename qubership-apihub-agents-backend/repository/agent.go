@@ -1,15 +1,22 @@
 package repository
 
 import (
+	"context"
+	"time"
+
 	"github.com/Netcracker/qubership-apihub-agents-backend/db"
 	"github.com/Netcracker/qubership-apihub-agents-backend/entity"
 	"github.com/go-pg/pg/v10"
 )
 
 type AgentRepository interface {
-	CreateOrUpdateAgent(ent entity.AgentEntity) error
 	ListAgents(onlyActive bool) ([]entity.AgentEntity, error)
 	GetAgent(id string) (*entity.AgentEntity, error)
+	CreateOrUpdateAgentWithReplica(ent entity.AgentEntity, replica entity.AgentReplicaEntity) error
+	ListAgentReplicas(agentIds []string) ([]entity.AgentReplicaEntity, error)
+	DeleteAgentReplicasInactiveSince(agentId string, since time.Time) error
+	SaveAgentDiscoveryReplica(ent *entity.AgentDiscoveryReplicaEntity) error
+	GetAgentDiscoveryReplica(agentId string, namespace string, workspaceId string) (*entity.AgentDiscoveryReplicaEntity, error)
 }
 
 func NewAgentRepository(cp db.ConnectionProvider) AgentRepository {
@@ -20,12 +27,19 @@ type agentRepositoryImpl struct {
 	cp db.ConnectionProvider
 }
 
-func (a agentRepositoryImpl) CreateOrUpdateAgent(ent entity.AgentEntity) error {
-	_, err := a.cp.GetConnection().Model(&ent).OnConflict("(agent_id) DO UPDATE").Insert()
-	if err != nil {
-		return err
-	}
-	return nil
+func (a agentRepositoryImpl) CreateOrUpdateAgentWithReplica(ent entity.AgentEntity, replica entity.AgentReplicaEntity) error {
+	ctx := context.Background()
+	return a.cp.GetConnection().RunInTransaction(ctx, func(tx *pg.Tx) error {
+		_, err := tx.Model(&ent).OnConflict("(agent_id) DO UPDATE").Insert()
+		if err != nil {
+			return err
+		}
+		_, err = tx.Model(&replica).OnConflict("(agent_id, instance_id) DO UPDATE").Insert()
+		if err != nil {
+			return err
+		}
+		return nil
+	})
 }
 
 func (a agentRepositoryImpl) ListAgents(onlyActive bool) ([]entity.AgentEntity, error) {
@@ -56,3 +70,53 @@ func (a agentRepositoryImpl) GetAgent(id string) (*entity.AgentEntity, error) {
 	}
 	return result, nil
 }
+
+func (a agentRepositoryImpl) ListAgentReplicas(agentIds []string) ([]entity.AgentReplicaEntity, error) {
+	result := make([]entity.AgentReplicaEntity, 0)
+	if len(agentIds) == 0 {
+		return result, nil
+	}
+	err := a.cp.GetConnection().Model(&result).
+		Where("agent_id in (?)", pg.In(agentIds)).
+		Order("agent_id ASC", "last_active DESC").
+		Select()
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (a agentRepositoryImpl) DeleteAgentReplicasInactiveSince(agentId string, since time.Time) error {
+	_, err := a.cp.GetConnection().Model(&entity.AgentReplicaEntity{}).
+		Where("agent_id = ?", agentId).
+		Where("last_active < ?", since).
+		Delete()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (a agentRepositoryImpl) SaveAgentDiscoveryReplica(ent *entity.AgentDiscoveryReplicaEntity) error {
+	_, err := a.cp.GetConnection().Model(ent).OnConflict("(agent_id, namespace, workspace_id) DO UPDATE").Insert()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (a agentRepositoryImpl) GetAgentDiscoveryReplica(agentId string, namespace string, workspaceId string) (*entity.AgentDiscoveryReplicaEntity, error) {
+	result := new(entity.AgentDiscoveryReplicaEntity)
+	err := a.cp.GetConnection().Model(result).
+		Where("agent_id = ?", agentId).
+		Where("namespace = ?", namespace).
+		Where("workspace_id = ?", workspaceId).
+		First()
+	if err != nil {
+		if err == pg.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return result, nil
+}
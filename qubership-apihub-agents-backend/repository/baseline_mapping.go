@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"github.com/Netcracker/qubership-apihub-agents-backend/db"
+	"github.com/Netcracker/qubership-apihub-agents-backend/entity"
+	"github.com/go-pg/pg/v10"
+)
+
+type BaselineMappingRepository interface {
+	SaveBaselineMapping(ent *entity.BaselineMappingEntity) error
+	GetBaselineMapping(workspaceId string) (*entity.BaselineMappingEntity, error)
+}
+
+func NewBaselineMappingRepository(cp db.ConnectionProvider) BaselineMappingRepository {
+	return &baselineMappingRepositoryImpl{cp: cp}
+}
+
+type baselineMappingRepositoryImpl struct {
+	cp db.ConnectionProvider
+}
+
+func (b baselineMappingRepositoryImpl) SaveBaselineMapping(ent *entity.BaselineMappingEntity) error {
+	_, err := b.cp.GetConnection().Model(ent).OnConflict("(workspace_id) DO UPDATE").Insert()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (b baselineMappingRepositoryImpl) GetBaselineMapping(workspaceId string) (*entity.BaselineMappingEntity, error) {
+	result := new(entity.BaselineMappingEntity)
+	err := b.cp.GetConnection().Model(result).
+		Where("workspace_id = ?", workspaceId).
+		First()
+	if err != nil {
+		if err == pg.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Netcracker/qubership-apihub-agents-backend/db"
+	"github.com/Netcracker/qubership-apihub-agents-backend/entity"
+	"github.com/Netcracker/qubership-apihub-agents-backend/view"
+	"github.com/go-pg/pg/v10"
+)
+
+type BulkDiscoveryRepository interface {
+	SaveBulkDiscovery(ent *entity.BulkDiscoveryEntity, targetEnts []entity.BulkDiscoveryTargetEntity) error
+	UpdateBulkDiscoveryStatus(ent *entity.BulkDiscoveryEntity) error
+	UpdateBulkDiscoveryTargetStatus(ent *entity.BulkDiscoveryTargetEntity) error
+	GetBulkDiscovery(jobId string) (*entity.BulkDiscoveryEntity, error)
+	GetBulkDiscoveryTargets(jobId string) ([]entity.BulkDiscoveryTargetEntity, error)
+	// InterruptBulkDiscovery sets error status to the running job and its unfinished targets, false is returned if the job is not running
+	InterruptBulkDiscovery(jobId string, details string) (bool, error)
+}
+
+func NewBulkDiscoveryRepository(cp db.ConnectionProvider) BulkDiscoveryRepository {
+	return &bulkDiscoveryRepositoryImpl{cp: cp}
+}
+
+type bulkDiscoveryRepositoryImpl struct {
+	cp db.ConnectionProvider
+}
+
+func (b bulkDiscoveryRepositoryImpl) SaveBulkDiscovery(ent *entity.BulkDiscoveryEntity, targetEnts []entity.BulkDiscoveryTargetEntity) error {
+	ctx := context.Background()
+	return b.cp.GetConnection().RunInTransaction(ctx, func(tx *pg.Tx) error {
+		_, err := tx.Model(ent).Insert()
+		if err != nil {
+			return err
+		}
+		if len(targetEnts) == 0 {
+			return nil
+		}
+		_, err = tx.Model(&targetEnts).Insert()
+		return err
+	})
+}
+
+func (b bulkDiscoveryRepositoryImpl) UpdateBulkDiscoveryStatus(ent *entity.BulkDiscoveryEntity) error {
+	_, err := b.cp.GetConnection().Model(ent).
+		Column("status", "details", "finished_at").
+		WherePK().
+		Update()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (b bulkDiscoveryRepositoryImpl) UpdateBulkDiscoveryTargetStatus(ent *entity.BulkDiscoveryTargetEntity) error {
+	_, err := b.cp.GetConnection().Model(ent).
+		Column("status", "details", "services_count", "started_at", "finished_at").
+		WherePK().
+		Update()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (b bulkDiscoveryRepositoryImpl) GetBulkDiscovery(jobId string) (*entity.BulkDiscoveryEntity, error) {
+	result := new(entity.BulkDiscoveryEntity)
+	err := b.cp.GetConnection().Model(result).
+		Where("job_id = ?", jobId).
+		First()
+	if err != nil {
+		if err == pg.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+func (b bulkDiscoveryRepositoryImpl) GetBulkDiscoveryTargets(jobId string) ([]entity.BulkDiscoveryTargetEntity, error) {
+	result := make([]entity.BulkDiscoveryTargetEntity, 0)
+	err := b.cp.GetConnection().Model(&result).
+		Where("job_id = ?", jobId).
+		Order("agent_id", "namespace").
+		Select()
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (b bulkDiscoveryRepositoryImpl) InterruptBulkDiscovery(jobId string, details string) (bool, error) {
+	interrupted := false
+	err := b.cp.GetConnection().RunInTransaction(context.Background(), func(tx *pg.Tx) error {
+		finishedAt := time.Now()
+		res, err := tx.Model(&entity.BulkDiscoveryEntity{}).
+			Set("status = ?", view.StatusError).
+			Set("details = ?", details).
+			Set("finished_at = ?", finishedAt).
+			Where("job_id = ?", jobId).
+			Where("status = ?", view.StatusRunning).
+			Update()
+		if err != nil {
+			return err
+		}
+		if res.RowsAffected() == 0 {
+			return nil
+		}
+		_, err = tx.Model(&entity.BulkDiscoveryTargetEntity{}).
+			Set("status = ?", view.StatusError).
+			Set("details = ?", details).
+			Set("finished_at = ?", finishedAt).
+			Where("job_id = ?", jobId).
+			Where("status in (?)", pg.In([]view.Status{view.StatusNone, view.StatusRunning})).
+			Update()
+		if err != nil {
+			return err
+		}
+		interrupted = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return interrupted, nil
+}
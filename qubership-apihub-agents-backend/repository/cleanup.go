@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/Netcracker/qubership-apihub-agents-backend/db"
+	"github.com/Netcracker/qubership-apihub-agents-backend/entity"
+	"github.com/Netcracker/qubership-apihub-agents-backend/view"
+	"github.com/go-pg/pg/v10"
+)
+
+type CleanupRepository interface {
+	SaveCleanupRun(ent *entity.CleanupRunEntity) error
+	UpdateCleanupRun(ent *entity.CleanupRunEntity) error
+	// ListCleanupRuns returns runs without processed groups, all job types are returned if jobType is empty
+	ListCleanupRuns(jobType string, limit int, page int) ([]entity.CleanupRunEntity, error)
+	GetCleanupRun(runId string) (*entity.CleanupRunEntity, error)
+	// InterruptCleanupRuns sets error status to runs of the job type which are still running
+	InterruptCleanupRuns(jobType string, details string) error
+}
+
+func NewCleanupRepository(cp db.ConnectionProvider) CleanupRepository {
+	return &cleanupRepositoryImpl{cp: cp}
+}
+
+type cleanupRepositoryImpl struct {
+	cp db.ConnectionProvider
+}
+
+func (c cleanupRepositoryImpl) SaveCleanupRun(ent *entity.CleanupRunEntity) error {
+	_, err := c.cp.GetConnection().Model(ent).Insert()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (c cleanupRepositoryImpl) UpdateCleanupRun(ent *entity.CleanupRunEntity) error {
+	_, err := c.cp.GetConnection().Model(ent).WherePK().Update()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (c cleanupRepositoryImpl) ListCleanupRuns(jobType string, limit int, page int) ([]entity.CleanupRunEntity, error) {
+	result := make([]entity.CleanupRunEntity, 0)
+	query := c.cp.GetConnection().Model(&result).
+		ExcludeColumn("groups")
+	if jobType != "" {
+		query.Where("job_type = ?", jobType)
+	}
+	err := query.
+		Order("started_at DESC", "run_id").
+		Limit(limit).
+		Offset(limit * page).
+		Select()
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c cleanupRepositoryImpl) GetCleanupRun(runId string) (*entity.CleanupRunEntity, error) {
+	result := new(entity.CleanupRunEntity)
+	err := c.cp.GetConnection().Model(result).
+		Where("run_id = ?", runId).
+		First()
+	if err != nil {
+		if err == pg.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c cleanupRepositoryImpl) InterruptCleanupRuns(jobType string, details string) error {
+	_, err := c.cp.GetConnection().Model(&entity.CleanupRunEntity{}).
+		Set("status = ?", view.StatusError).
+		Set("details = ?", details).
+		Set("finished_at = ?", time.Now()).
+		Where("job_type = ?", jobType).
+		Where("status = ?", view.StatusRunning).
+		Update()
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Netcracker/qubership-apihub-agents-backend/db"
+	"github.com/Netcracker/qubership-apihub-agents-backend/entity"
+	"github.com/Netcracker/qubership-apihub-agents-backend/view"
+	"github.com/go-pg/pg/v10"
+)
+
+type DiscoveryRepository interface {
+	SaveNamespaceDiscovery(ent *entity.NamespaceDiscoveryEntity) error
+	GetNamespaceDiscovery(agentId string, namespace string, workspaceId string) (*entity.NamespaceDiscoveryEntity, error)
+	FinishNamespaceDiscovery(historyEnt *entity.DiscoveryHistoryEntity, keepLast int) (bool, error)
+	UpdateDiscoveryHistoryServices(discoveryId string, services []view.DiscoveredService) error
+	ListNamespaceDiscoveries(workspaceId string) ([]entity.NamespaceDiscoveryEntity, error)
+	ListDiscoveryHistory(agentId string, namespace string, workspaceId string, limit int, page int) ([]entity.DiscoveryHistoryEntity, error)
+	GetDiscoveryHistory(discoveryId string) (*entity.DiscoveryHistoryEntity, error)
+}
+
+func NewDiscoveryRepository(cp db.ConnectionProvider) DiscoveryRepository {
+	return &discoveryRepositoryImpl{cp: cp}
+}
+
+type discoveryRepositoryImpl struct {
+	cp db.ConnectionProvider
+}
+
+func (d discoveryRepositoryImpl) SaveNamespaceDiscovery(ent *entity.NamespaceDiscoveryEntity) error {
+	_, err := d.cp.GetConnection().Model(ent).OnConflict("(agent_id, namespace, workspace_id) DO UPDATE").Insert()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (d discoveryRepositoryImpl) GetNamespaceDiscovery(agentId string, namespace string, workspaceId string) (*entity.NamespaceDiscoveryEntity, error) {
+	result := new(entity.NamespaceDiscoveryEntity)
+	err := d.cp.GetConnection().Model(result).
+		Where("agent_id = ?", agentId).
+		Where("namespace = ?", namespace).
+		Where("workspace_id = ?", workspaceId).
+		First()
+	if err != nil {
+		if err == pg.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+// FinishNamespaceDiscovery updates the status of the running discovery and stores its result in the history in the same transaction.
+// Already finished discoveries stay untouched, false is returned if there was no running discovery.
+// Only the last keepLast discoveries of the agent, namespace and workspace are kept in the history
+func (d discoveryRepositoryImpl) FinishNamespaceDiscovery(historyEnt *entity.DiscoveryHistoryEntity, keepLast int) (bool, error) {
+	finished := false
+	err := d.cp.GetConnection().RunInTransaction(context.Background(), func(tx *pg.Tx) error {
+		discoveryEnt := new(entity.NamespaceDiscoveryEntity)
+		res, err := tx.Model(discoveryEnt).
+			Set("status = ?", historyEnt.Status).
+			Set("details = ?", historyEnt.Details).
+			Set("finished_at = ?", historyEnt.FinishedAt).
+			Where("agent_id = ?", historyEnt.AgentId).
+			Where("namespace = ?", historyEnt.Namespace).
+			Where("workspace_id = ?", historyEnt.WorkspaceId).
+			Where("status = ?", view.StatusRunning).
+			Returning("*").
+			Update()
+		if err != nil {
+			if err == pg.ErrNoRows {
+				return nil
+			}
+			return err
+		}
+		if res.RowsAffected() == 0 {
+			return nil
+		}
+		historyEnt.StartedAt = &discoveryEnt.StartedAt
+		historyEnt.StartedBy = discoveryEnt.StartedBy
+		_, err = tx.Model(historyEnt).Insert()
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`delete from discovery_history
+			where agent_id = ? and namespace = ? and workspace_id = ?
+			and discovery_id not in (
+				select discovery_id from discovery_history
+				where agent_id = ? and namespace = ? and workspace_id = ?
+				order by finished_at desc, discovery_id
+				limit ?)`,
+			historyEnt.AgentId, historyEnt.Namespace, historyEnt.WorkspaceId,
+			historyEnt.AgentId, historyEnt.Namespace, historyEnt.WorkspaceId, keepLast)
+		if err != nil {
+			return err
+		}
+		finished = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return finished, nil
+}
+
+func (d discoveryRepositoryImpl) UpdateDiscoveryHistoryServices(discoveryId string, services []view.DiscoveredService) error {
+	_, err := d.cp.GetConnection().Model(&entity.DiscoveryHistoryEntity{DiscoveryId: discoveryId, Services: services}).
+		Column("services").
+		WherePK().
+		Update()
+	if err != nil && err != pg.ErrNoRows {
+		return err
+	}
+	return nil
+}
+
+func (d discoveryRepositoryImpl) ListNamespaceDiscoveries(workspaceId string) ([]entity.NamespaceDiscoveryEntity, error) {
+	result := make([]entity.NamespaceDiscoveryEntity, 0)
+	err := d.cp.GetConnection().Model(&result).
+		Where("workspace_id = ?", workspaceId).
+		Order("agent_id", "namespace").
+		Select()
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (d discoveryRepositoryImpl) ListDiscoveryHistory(agentId string, namespace string, workspaceId string, limit int, page int) ([]entity.DiscoveryHistoryEntity, error) {
+	result := make([]entity.DiscoveryHistoryEntity, 0)
+	err := d.cp.GetConnection().Model(&result).
+		ExcludeColumn("services").
+		Where("agent_id = ?", agentId).
+		Where("namespace = ?", namespace).
+		Where("workspace_id = ?", workspaceId).
+		Order("finished_at DESC", "discovery_id").
+		Limit(limit).
+		Offset(limit * page).
+		Select()
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (d discoveryRepositoryImpl) GetDiscoveryHistory(discoveryId string) (*entity.DiscoveryHistoryEntity, error) {
+	result := new(entity.DiscoveryHistoryEntity)
+	err := d.cp.GetConnection().Model(result).
+		Where("discovery_id = ?", discoveryId).
+		First()
+	if err != nil {
+		if err == pg.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/Netcracker/qubership-apihub-agents-backend/db"
+	"github.com/Netcracker/qubership-apihub-agents-backend/entity"
+)
+
+// JobLockRepository stores leases of scheduled jobs, database time is used so clocks of replicas don't matter
+type JobLockRepository interface {
+	// TryAcquireJobLock takes the lock if it doesn't exist or its lease is expired
+	TryAcquireJobLock(jobName string, instanceId string, lease time.Duration) (bool, error)
+	// ExtendJobLock prolongs the lease, false is returned if the lock is taken by another instance
+	ExtendJobLock(jobName string, instanceId string, lease time.Duration) (bool, error)
+	// ReleaseJobLock expires the lease, but not earlier than minHold after the lock was acquired
+	ReleaseJobLock(jobName string, instanceId string, minHold time.Duration) error
+}
+
+func NewJobLockRepository(cp db.ConnectionProvider) JobLockRepository {
+	return &jobLockRepositoryImpl{cp: cp}
+}
+
+type jobLockRepositoryImpl struct {
+	cp db.ConnectionProvider
+}
+
+func (j jobLockRepositoryImpl) TryAcquireJobLock(jobName string, instanceId string, lease time.Duration) (bool, error) {
+	res, err := j.cp.GetConnection().Exec(`
+		INSERT INTO job_lock (job_name, instance_id, acquired_at, expires_at)
+		VALUES (?, ?, now(), now() + ? * interval '1 second')
+		ON CONFLICT (job_name) DO UPDATE
+		SET instance_id = EXCLUDED.instance_id, acquired_at = EXCLUDED.acquired_at, expires_at = EXCLUDED.expires_at
+		WHERE job_lock.expires_at < now()`,
+		jobName, instanceId, int(lease.Seconds()))
+	if err != nil {
+		return false, err
+	}
+	return res.RowsAffected() > 0, nil
+}
+
+func (j jobLockRepositoryImpl) ExtendJobLock(jobName string, instanceId string, lease time.Duration) (bool, error) {
+	res, err := j.cp.GetConnection().Model(&entity.JobLockEntity{}).
+		Set("expires_at = now() + ? * interval '1 second'", int(lease.Seconds())).
+		Where("job_name = ?", jobName).
+		Where("instance_id = ?", instanceId).
+		Update()
+	if err != nil {
+		return false, err
+	}
+	return res.RowsAffected() > 0, nil
+}
+
+func (j jobLockRepositoryImpl) ReleaseJobLock(jobName string, instanceId string, minHold time.Duration) error {
+	_, err := j.cp.GetConnection().Model(&entity.JobLockEntity{}).
+		Set("expires_at = greatest(now(), acquired_at + ? * interval '1 second')", int(minHold.Seconds())).
+		Where("job_name = ?", jobName).
+		Where("instance_id = ?", instanceId).
+		Update()
+	if err != nil {
+		return err
+	}
+	return nil
+}
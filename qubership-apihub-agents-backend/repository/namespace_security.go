@@ -18,6 +18,15 @@ type NamespaceSecurityRepository interface {
 	GetNamespaceSecurityCheckResults(processId string) ([]entity.NamespaceSecurityCheckResultEntity, error)
 	GetNamespaceSecurityCheckReports(agentId string, namespace string, workspaceId string, limit int, page int) ([]entity.NamespaceSecurityCheckStatusEntity, error)
 	GetNamespaceSecurityCheckStatus(processId string) (*entity.NamespaceSecurityCheckStatusEntity, error)
+	GetLatestNamespaceSecurityChecks(workspaceId string) ([]entity.NamespaceSecurityCheckStatusEntity, error)
+	// GetOutdatedNamespaceSecurityChecks returns finished checks except the last keepLast checks of each agent and namespace
+	GetOutdatedNamespaceSecurityChecks(keepLast int) ([]entity.NamespaceSecurityCheckEntity, error)
+	// GetNamespaceSecurityCheckVersions returns services of the checks which have APIHUB version published
+	GetNamespaceSecurityCheckVersions(processIds []string) ([]entity.NamespaceSecurityCheckServiceEntity, error)
+	// GetUsedNamespaceSecurityCheckVersions returns APIHUB version names (without revision) published by checks of the namespace except excludedProcessIds
+	GetUsedNamespaceSecurityCheckVersions(workspaceId string, cloudName string, namespace string, excludedProcessIds []string) ([]string, error)
+	// DeleteNamespaceSecurityChecks deletes checks with services and results
+	DeleteNamespaceSecurityChecks(processIds []string) (int, error)
 }
 
 func NewNamespaceSecurityRepository(cp db.ConnectionProvider) NamespaceSecurityRepository {
@@ -182,3 +191,116 @@ func (n namespaceSecurityRepositoryImpl) GetNamespaceSecurityCheckStatus(process
 	}
 	return result, nil
 }
+
+func (n namespaceSecurityRepositoryImpl) GetLatestNamespaceSecurityChecks(workspaceId string) ([]entity.NamespaceSecurityCheckStatusEntity, error) {
+	result := make([]entity.NamespaceSecurityCheckStatusEntity, 0)
+	query := `
+	with latest as(
+		select distinct on (agent_id, namespace) *
+		from namespace_security_check
+		where workspace_id = ?
+		order by agent_id, namespace, started_at desc
+	),
+	processed as(
+		select s.process_id, count(*) cnt
+		from namespace_security_check_service s
+		inner join latest l on s.process_id = l.process_id
+		where s.status in(?)
+		group by s.process_id
+	),
+	total as(
+		select s.process_id, count(*) cnt
+		from namespace_security_check_service s
+		inner join latest l on s.process_id = l.process_id
+		group by s.process_id
+	)
+	select coalesce(t.cnt, 0) services_total, coalesce(p.cnt, 0) services_processed, n.* from
+	latest n
+	left join processed p on
+	n.process_id = p.process_id
+	left join total t on
+	n.process_id = t.process_id;
+	`
+	_, err := n.cp.GetConnection().Query(&result, query,
+		workspaceId, pg.In([]string{string(view.StatusComplete), string(view.StatusError)}))
+	if err != nil {
+		if err == pg.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+func (n namespaceSecurityRepositoryImpl) GetOutdatedNamespaceSecurityChecks(keepLast int) ([]entity.NamespaceSecurityCheckEntity, error) {
+	result := make([]entity.NamespaceSecurityCheckEntity, 0)
+	query := `
+	select process_id, agent_id, namespace, workspace_id, cloud_name, status, details, started_at, started_by, finished_at from (
+		select n.*, row_number() over (partition by agent_id, namespace, workspace_id order by started_at desc) rn
+		from namespace_security_check n
+	) checks
+	where rn > ?
+	and status != ?
+	order by workspace_id, cloud_name, namespace, started_at;
+	`
+	_, err := n.cp.GetConnection().Query(&result, query, keepLast, view.StatusRunning)
+	if err != nil {
+		if err == pg.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+func (n namespaceSecurityRepositoryImpl) GetNamespaceSecurityCheckVersions(processIds []string) ([]entity.NamespaceSecurityCheckServiceEntity, error) {
+	result := make([]entity.NamespaceSecurityCheckServiceEntity, 0)
+	if len(processIds) == 0 {
+		return result, nil
+	}
+	err := n.cp.GetConnection().Model(&result).
+		Where("process_id in (?)", pg.In(processIds)).
+		Where("package_id != ''").
+		Where("version != ''").
+		Order("process_id", "service_id").
+		Select()
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (n namespaceSecurityRepositoryImpl) GetUsedNamespaceSecurityCheckVersions(workspaceId string, cloudName string, namespace string, excludedProcessIds []string) ([]string, error) {
+	result := make([]string, 0)
+	query := `
+	select distinct split_part(s.version, '@', 1)
+	from namespace_security_check_service s
+	inner join namespace_security_check n on s.process_id = n.process_id
+	where n.workspace_id = ?
+	and n.cloud_name = ?
+	and n.namespace = ?
+	and not (n.process_id = any(?))
+	and s.version != '';
+	`
+	_, err := n.cp.GetConnection().Query(&result, query, workspaceId, cloudName, namespace, pg.Array(excludedProcessIds))
+	if err != nil {
+		if err == pg.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+func (n namespaceSecurityRepositoryImpl) DeleteNamespaceSecurityChecks(processIds []string) (int, error) {
+	if len(processIds) == 0 {
+		return 0, nil
+	}
+	res, err := n.cp.GetConnection().Model(&entity.NamespaceSecurityCheckEntity{}).
+		Where("process_id in (?)", pg.In(processIds)).
+		Delete()
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected(), nil
+}
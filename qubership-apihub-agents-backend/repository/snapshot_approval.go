@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Netcracker/qubership-apihub-agents-backend/db"
+	"github.com/Netcracker/qubership-apihub-agents-backend/entity"
+	"github.com/go-pg/pg/v10"
+)
+
+type SnapshotApprovalRepository interface {
+	SaveSnapshotApprovers(ent *entity.SnapshotApproversEntity) error
+	GetSnapshotApprovers(workspaceId string) (*entity.SnapshotApproversEntity, error)
+	CreateSnapshotApproval(ent *entity.SnapshotApprovalEntity, serviceEnts []entity.SnapshotApprovalServiceEntity, eventEnt *entity.SnapshotApprovalEventEntity) error
+	// GetLatestSnapshotApproval returns the last submitted approval of the snapshot version
+	GetLatestSnapshotApproval(packageId string, version string) (*entity.SnapshotApprovalEntity, error)
+	GetSnapshotApprovalServices(approvalId string) ([]entity.SnapshotApprovalServiceEntity, error)
+	GetSnapshotApprovalEvents(approvalId string) ([]entity.SnapshotApprovalEventEntity, error)
+	SaveSnapshotApprovalDecision(ent *entity.SnapshotApprovalEntity, serviceEnts []entity.SnapshotApprovalServiceEntity, eventEnts []entity.SnapshotApprovalEventEntity) error
+	AddSnapshotApprovalEvent(ent *entity.SnapshotApprovalEventEntity) error
+	// DeleteSnapshotApprovals deletes approvals of all revisions of the snapshot version
+	DeleteSnapshotApprovals(packageId string, version string) error
+}
+
+func NewSnapshotApprovalRepository(cp db.ConnectionProvider) SnapshotApprovalRepository {
+	return &snapshotApprovalRepositoryImpl{cp: cp}
+}
+
+type snapshotApprovalRepositoryImpl struct {
+	cp db.ConnectionProvider
+}
+
+func (s snapshotApprovalRepositoryImpl) SaveSnapshotApprovers(ent *entity.SnapshotApproversEntity) error {
+	_, err := s.cp.GetConnection().Model(ent).OnConflict("(workspace_id) DO UPDATE").Insert()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s snapshotApprovalRepositoryImpl) GetSnapshotApprovers(workspaceId string) (*entity.SnapshotApproversEntity, error) {
+	result := new(entity.SnapshotApproversEntity)
+	err := s.cp.GetConnection().Model(result).
+		Where("workspace_id = ?", workspaceId).
+		First()
+	if err != nil {
+		if err == pg.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s snapshotApprovalRepositoryImpl) CreateSnapshotApproval(ent *entity.SnapshotApprovalEntity, serviceEnts []entity.SnapshotApprovalServiceEntity, eventEnt *entity.SnapshotApprovalEventEntity) error {
+	ctx := context.Background()
+	return s.cp.GetConnection().RunInTransaction(ctx, func(tx *pg.Tx) error {
+		_, err := tx.Model(ent).Insert()
+		if err != nil {
+			return err
+		}
+		if len(serviceEnts) > 0 {
+			_, err = tx.Model(&serviceEnts).Insert()
+			if err != nil {
+				return err
+			}
+		}
+		_, err = tx.Model(eventEnt).Insert()
+		return err
+	})
+}
+
+func (s snapshotApprovalRepositoryImpl) GetLatestSnapshotApproval(packageId string, version string) (*entity.SnapshotApprovalEntity, error) {
+	result := new(entity.SnapshotApprovalEntity)
+	err := s.cp.GetConnection().Model(result).
+		Where("package_id = ?", packageId).
+		Where("version = ?", version).
+		Order("created_at DESC").
+		First()
+	if err != nil {
+		if err == pg.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s snapshotApprovalRepositoryImpl) GetSnapshotApprovalServices(approvalId string) ([]entity.SnapshotApprovalServiceEntity, error) {
+	result := make([]entity.SnapshotApprovalServiceEntity, 0)
+	err := s.cp.GetConnection().Model(&result).
+		Where("approval_id = ?", approvalId).
+		Order("service_id").
+		Select()
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s snapshotApprovalRepositoryImpl) GetSnapshotApprovalEvents(approvalId string) ([]entity.SnapshotApprovalEventEntity, error) {
+	result := make([]entity.SnapshotApprovalEventEntity, 0)
+	err := s.cp.GetConnection().Model(&result).
+		Where("approval_id = ?", approvalId).
+		Order("created_at").
+		Select()
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s snapshotApprovalRepositoryImpl) SaveSnapshotApprovalDecision(ent *entity.SnapshotApprovalEntity, serviceEnts []entity.SnapshotApprovalServiceEntity, eventEnts []entity.SnapshotApprovalEventEntity) error {
+	ctx := context.Background()
+	return s.cp.GetConnection().RunInTransaction(ctx, func(tx *pg.Tx) error {
+		_, err := tx.Model(ent).
+			Column("status", "updated_at").
+			WherePK().
+			Update()
+		if err != nil {
+			return err
+		}
+		for i := range serviceEnts {
+			_, err = tx.Model(&serviceEnts[i]).
+				Column("status", "decided_at", "decided_by", "comment").
+				WherePK().
+				Update()
+			if err != nil {
+				return err
+			}
+		}
+		if len(eventEnts) == 0 {
+			return nil
+		}
+		_, err = tx.Model(&eventEnts).Insert()
+		return err
+	})
+}
+
+func (s snapshotApprovalRepositoryImpl) AddSnapshotApprovalEvent(ent *entity.SnapshotApprovalEventEntity) error {
+	_, err := s.cp.GetConnection().Model(ent).Insert()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s snapshotApprovalRepositoryImpl) DeleteSnapshotApprovals(packageId string, version string) error {
+	_, err := s.cp.GetConnection().Model(&entity.SnapshotApprovalEntity{}).
+		Where("package_id = ?", packageId).
+		Where("split_part(version, '@', 1) = ?", version).
+		Delete()
+	if err != nil {
+		return err
+	}
+	return nil
+}
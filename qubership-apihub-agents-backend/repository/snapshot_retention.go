@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"github.com/Netcracker/qubership-apihub-agents-backend/db"
+	"github.com/Netcracker/qubership-apihub-agents-backend/entity"
+	"github.com/go-pg/pg/v10"
+)
+
+type SnapshotRetentionRepository interface {
+	SaveSnapshotRetention(ent *entity.SnapshotRetentionEntity) error
+	GetSnapshotRetention(workspaceId string) (*entity.SnapshotRetentionEntity, error)
+}
+
+func NewSnapshotRetentionRepository(cp db.ConnectionProvider) SnapshotRetentionRepository {
+	return &snapshotRetentionRepositoryImpl{cp: cp}
+}
+
+type snapshotRetentionRepositoryImpl struct {
+	cp db.ConnectionProvider
+}
+
+func (s snapshotRetentionRepositoryImpl) SaveSnapshotRetention(ent *entity.SnapshotRetentionEntity) error {
+	_, err := s.cp.GetConnection().Model(ent).OnConflict("(workspace_id) DO UPDATE").Insert()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s snapshotRetentionRepositoryImpl) GetSnapshotRetention(workspaceId string) (*entity.SnapshotRetentionEntity, error) {
+	result := new(entity.SnapshotRetentionEntity)
+	err := s.cp.GetConnection().Model(result).
+		Where("workspace_id = ?", workspaceId).
+		First()
+	if err != nil {
+		if err == pg.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"github.com/Netcracker/qubership-apihub-agents-backend/db"
+	"github.com/Netcracker/qubership-apihub-agents-backend/entity"
+	"github.com/go-pg/pg/v10"
+)
+
+type SnapshotVersionTemplateRepository interface {
+	SaveSnapshotVersionTemplate(ent *entity.SnapshotVersionTemplateEntity) error
+	DeleteSnapshotVersionTemplate(workspaceId string) error
+	GetSnapshotVersionTemplate(workspaceId string) (*entity.SnapshotVersionTemplateEntity, error)
+	// ReserveSnapshotVersionCounter returns the next counter of the version pattern, but not less than minCounter.
+	// The counter is reserved atomically, so concurrent callers never get the same value
+	ReserveSnapshotVersionCounter(workspaceId string, versionPattern string, minCounter int) (int, error)
+}
+
+func NewSnapshotVersionTemplateRepository(cp db.ConnectionProvider) SnapshotVersionTemplateRepository {
+	return &snapshotVersionTemplateRepositoryImpl{cp: cp}
+}
+
+type snapshotVersionTemplateRepositoryImpl struct {
+	cp db.ConnectionProvider
+}
+
+func (s snapshotVersionTemplateRepositoryImpl) SaveSnapshotVersionTemplate(ent *entity.SnapshotVersionTemplateEntity) error {
+	_, err := s.cp.GetConnection().Model(ent).OnConflict("(workspace_id) DO UPDATE").Insert()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s snapshotVersionTemplateRepositoryImpl) DeleteSnapshotVersionTemplate(workspaceId string) error {
+	_, err := s.cp.GetConnection().Model(&entity.SnapshotVersionTemplateEntity{}).
+		Where("workspace_id = ?", workspaceId).
+		Delete()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s snapshotVersionTemplateRepositoryImpl) GetSnapshotVersionTemplate(workspaceId string) (*entity.SnapshotVersionTemplateEntity, error) {
+	result := new(entity.SnapshotVersionTemplateEntity)
+	err := s.cp.GetConnection().Model(result).
+		Where("workspace_id = ?", workspaceId).
+		First()
+	if err != nil {
+		if err == pg.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s snapshotVersionTemplateRepositoryImpl) ReserveSnapshotVersionCounter(workspaceId string, versionPattern string, minCounter int) (int, error) {
+	var counter int
+	_, err := s.cp.GetConnection().QueryOne(pg.Scan(&counter), `
+		INSERT INTO snapshot_version_counter (workspace_id, version_pattern, counter)
+		VALUES (?, ?, ?)
+		ON CONFLICT (workspace_id, version_pattern) DO UPDATE
+		SET counter = greatest(snapshot_version_counter.counter + 1, EXCLUDED.counter)
+		RETURNING counter`,
+		workspaceId, versionPattern, minCounter)
+	if err != nil {
+		return 0, err
+	}
+	return counter, nil
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"github.com/Netcracker/qubership-apihub-agents-backend/db"
+	"github.com/Netcracker/qubership-apihub-agents-backend/entity"
+	"github.com/go-pg/pg/v10"
+)
+
+type SpecLintRulesRepository interface {
+	SaveSpecLintRules(ent *entity.SpecLintRulesEntity) error
+	GetSpecLintRules(workspaceId string) (*entity.SpecLintRulesEntity, error)
+}
+
+func NewSpecLintRulesRepository(cp db.ConnectionProvider) SpecLintRulesRepository {
+	return &specLintRulesRepositoryImpl{cp: cp}
+}
+
+type specLintRulesRepositoryImpl struct {
+	cp db.ConnectionProvider
+}
+
+func (s specLintRulesRepositoryImpl) SaveSpecLintRules(ent *entity.SpecLintRulesEntity) error {
+	_, err := s.cp.GetConnection().Model(ent).OnConflict("(workspace_id) DO UPDATE").Insert()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s specLintRulesRepositoryImpl) GetSpecLintRules(workspaceId string) (*entity.SpecLintRulesEntity, error) {
+	result := new(entity.SpecLintRulesEntity)
+	err := s.cp.GetConnection().Model(result).
+		Where("workspace_id = ?", workspaceId).
+		First()
+	if err != nil {
+		if err == pg.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Netcracker/qubership-apihub-agents-backend/db"
+	"github.com/Netcracker/qubership-apihub-agents-backend/entity"
+	"github.com/go-pg/pg/v10"
+)
+
+type WorkspaceCopyRepository interface {
+	SaveWorkspaceCopy(ent *entity.WorkspaceCopyEntity, packageEnts []entity.WorkspaceCopyPackageEntity) error
+	UpdateWorkspaceCopyStatus(ent *entity.WorkspaceCopyEntity) error
+	UpdateWorkspaceCopyPackageStatus(ent *entity.WorkspaceCopyPackageEntity) error
+	GetWorkspaceCopy(copyId string) (*entity.WorkspaceCopyEntity, error)
+	GetWorkspaceCopyPackages(copyId string) ([]entity.WorkspaceCopyPackageEntity, error)
+	ListWorkspaceCopies(dstWorkspaceId string, limit int, page int) ([]entity.WorkspaceCopyEntity, error)
+}
+
+func NewWorkspaceCopyRepository(cp db.ConnectionProvider) WorkspaceCopyRepository {
+	return &workspaceCopyRepositoryImpl{cp: cp}
+}
+
+type workspaceCopyRepositoryImpl struct {
+	cp db.ConnectionProvider
+}
+
+func (w workspaceCopyRepositoryImpl) SaveWorkspaceCopy(ent *entity.WorkspaceCopyEntity, packageEnts []entity.WorkspaceCopyPackageEntity) error {
+	ctx := context.Background()
+	return w.cp.GetConnection().RunInTransaction(ctx, func(tx *pg.Tx) error {
+		_, err := tx.Model(ent).Insert()
+		if err != nil {
+			return err
+		}
+		if len(packageEnts) == 0 {
+			return nil
+		}
+		_, err = tx.Model(&packageEnts).Insert()
+		return err
+	})
+}
+
+func (w workspaceCopyRepositoryImpl) UpdateWorkspaceCopyStatus(ent *entity.WorkspaceCopyEntity) error {
+	_, err := w.cp.GetConnection().Model(ent).
+		Column("status", "details", "finished_at").
+		WherePK().
+		Update()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (w workspaceCopyRepositoryImpl) UpdateWorkspaceCopyPackageStatus(ent *entity.WorkspaceCopyPackageEntity) error {
+	_, err := w.cp.GetConnection().Model(ent).
+		Column("status", "details").
+		WherePK().
+		Update()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (w workspaceCopyRepositoryImpl) GetWorkspaceCopy(copyId string) (*entity.WorkspaceCopyEntity, error) {
+	result := new(entity.WorkspaceCopyEntity)
+	err := w.cp.GetConnection().Model(result).
+		Where("copy_id = ?", copyId).
+		First()
+	if err != nil {
+		if err == pg.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+func (w workspaceCopyRepositoryImpl) GetWorkspaceCopyPackages(copyId string) ([]entity.WorkspaceCopyPackageEntity, error) {
+	result := make([]entity.WorkspaceCopyPackageEntity, 0)
+	err := w.cp.GetConnection().Model(&result).
+		Where("copy_id = ?", copyId).
+		Order("seq").
+		Select()
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (w workspaceCopyRepositoryImpl) ListWorkspaceCopies(dstWorkspaceId string, limit int, page int) ([]entity.WorkspaceCopyEntity, error) {
+	result := make([]entity.WorkspaceCopyEntity, 0)
+	err := w.cp.GetConnection().Model(&result).
+		Where("dst_workspace_id = ?", dstWorkspaceId).
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(limit * page).
+		Select()
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
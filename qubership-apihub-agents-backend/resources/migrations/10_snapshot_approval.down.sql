@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS snapshot_approval_event;
+DROP TABLE IF EXISTS snapshot_approval_service;
+DROP TABLE IF EXISTS snapshot_approval;
+DROP TABLE IF EXISTS snapshot_approvers;
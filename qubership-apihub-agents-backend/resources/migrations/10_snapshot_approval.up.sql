@@ -0,0 +1,56 @@
+CREATE TABLE IF NOT EXISTS snapshot_approvers
+(
+    workspace_id varchar NOT NULL,
+    approvers jsonb NOT NULL,
+    updated_at timestamp without time zone NOT NULL,
+    updated_by varchar,
+    CONSTRAINT snapshot_approvers_pkey PRIMARY KEY (workspace_id)
+);
+
+CREATE TABLE IF NOT EXISTS snapshot_approval
+(
+    approval_id varchar NOT NULL,
+    workspace_id varchar NOT NULL,
+    cloud_name varchar NOT NULL,
+    namespace varchar NOT NULL,
+    package_id varchar NOT NULL,
+    version varchar NOT NULL,
+    status varchar NOT NULL,
+    created_at timestamp without time zone NOT NULL,
+    created_by varchar,
+    updated_at timestamp without time zone NOT NULL,
+    CONSTRAINT snapshot_approval_pkey PRIMARY KEY (approval_id)
+);
+
+CREATE INDEX IF NOT EXISTS snapshot_approval_package_id_version_idx
+    ON snapshot_approval (package_id, version, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS snapshot_approval_service
+(
+    approval_id varchar NOT NULL,
+    service_id varchar NOT NULL,
+    package_id varchar NOT NULL,
+    version varchar NOT NULL,
+    status varchar NOT NULL,
+    decided_at timestamp without time zone,
+    decided_by varchar,
+    comment varchar,
+    CONSTRAINT snapshot_approval_service_pkey PRIMARY KEY (approval_id, service_id),
+    CONSTRAINT snapshot_approval_service_approval_fkey FOREIGN KEY (approval_id) REFERENCES snapshot_approval (approval_id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS snapshot_approval_event
+(
+    event_id varchar NOT NULL,
+    approval_id varchar NOT NULL,
+    service_id varchar,
+    action varchar NOT NULL,
+    comment varchar,
+    created_at timestamp without time zone NOT NULL,
+    created_by varchar,
+    CONSTRAINT snapshot_approval_event_pkey PRIMARY KEY (event_id),
+    CONSTRAINT snapshot_approval_event_approval_fkey FOREIGN KEY (approval_id) REFERENCES snapshot_approval (approval_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS snapshot_approval_event_approval_id_idx
+    ON snapshot_approval_event (approval_id, created_at);
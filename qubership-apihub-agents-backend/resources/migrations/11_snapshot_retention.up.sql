@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS snapshot_retention
+(
+    workspace_id varchar NOT NULL,
+    policies jsonb NOT NULL,
+    updated_at timestamp without time zone NOT NULL,
+    updated_by varchar,
+    CONSTRAINT snapshot_retention_pkey PRIMARY KEY (workspace_id)
+);
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS cleanup_run
+(
+    run_id varchar NOT NULL,
+    job_type varchar NOT NULL,
+    trigger varchar NOT NULL,
+    status varchar NOT NULL,
+    details varchar,
+    started_at timestamp without time zone NOT NULL,
+    started_by varchar,
+    finished_at timestamp without time zone,
+    workspaces_scanned integer NOT NULL DEFAULT 0,
+    groups_processed integer NOT NULL DEFAULT 0,
+    deleted_snapshots integer NOT NULL DEFAULT 0,
+    groups jsonb,
+    errors jsonb,
+    CONSTRAINT cleanup_run_pkey PRIMARY KEY (run_id)
+);
+
+CREATE INDEX IF NOT EXISTS cleanup_run_job_type_idx
+    ON cleanup_run (job_type, started_at DESC);
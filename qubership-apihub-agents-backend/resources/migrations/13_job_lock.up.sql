@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS job_lock
+(
+    job_name varchar NOT NULL,
+    instance_id varchar NOT NULL,
+    acquired_at timestamp without time zone NOT NULL,
+    expires_at timestamp without time zone NOT NULL,
+    CONSTRAINT job_lock_pkey PRIMARY KEY (job_name)
+);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS namespace_security_check_agent_namespace_workspace_idx;
+ALTER TABLE cleanup_run DROP COLUMN IF EXISTS deleted_security_checks;
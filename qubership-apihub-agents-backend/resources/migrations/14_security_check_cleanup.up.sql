@@ -0,0 +1,4 @@
+ALTER TABLE cleanup_run ADD COLUMN IF NOT EXISTS deleted_security_checks integer NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS namespace_security_check_agent_namespace_workspace_idx
+    ON namespace_security_check (agent_id, namespace, workspace_id, started_at DESC);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS agent_discovery_replica
+(
+    agent_id varchar NOT NULL,
+    namespace varchar NOT NULL,
+    workspace_id varchar NOT NULL,
+    url varchar NOT NULL,
+    pinned_at timestamp without time zone NOT NULL,
+    CONSTRAINT agent_discovery_replica_pkey PRIMARY KEY (agent_id, namespace, workspace_id),
+    CONSTRAINT agent_discovery_replica_agent_id_fk FOREIGN KEY (
+        agent_id
+    ) REFERENCES agent (agent_id) ON DELETE CASCADE
+);
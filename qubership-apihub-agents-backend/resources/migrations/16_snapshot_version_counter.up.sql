@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS snapshot_version_counter
+(
+    workspace_id varchar NOT NULL,
+    version_pattern varchar NOT NULL,
+    counter integer NOT NULL,
+    CONSTRAINT snapshot_version_counter_pkey PRIMARY KEY (workspace_id, version_pattern)
+);
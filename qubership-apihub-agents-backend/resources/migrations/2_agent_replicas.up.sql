@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS agent_replica
+(
+    agent_id varchar NOT NULL,
+    instance_id varchar NOT NULL,
+    url varchar NOT NULL,
+    last_active timestamp without time zone NOT NULL,
+    backend_version varchar NOT NULL,
+    agent_version varchar,
+    CONSTRAINT agent_replica_pkey PRIMARY KEY (agent_id, instance_id),
+    CONSTRAINT agent_replica_agent_id_fk FOREIGN KEY (
+        agent_id
+    ) REFERENCES agent (agent_id) ON DELETE CASCADE
+);
+
+INSERT INTO agent_replica (agent_id, instance_id, url, last_active, backend_version, agent_version)
+SELECT agent_id, url, url, last_active, backend_version, agent_version
+FROM agent
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS namespace_discovery
+(
+    agent_id varchar NOT NULL,
+    namespace varchar NOT NULL,
+    workspace_id varchar NOT NULL,
+    status varchar NOT NULL,
+    details varchar,
+    started_at timestamp without time zone NOT NULL,
+    started_by varchar,
+    finished_at timestamp without time zone,
+    CONSTRAINT namespace_discovery_pkey PRIMARY KEY (agent_id, namespace, workspace_id)
+);
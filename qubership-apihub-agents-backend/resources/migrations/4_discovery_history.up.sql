@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS discovery_history
+(
+    discovery_id varchar NOT NULL,
+    agent_id varchar NOT NULL,
+    cloud_name varchar,
+    namespace varchar NOT NULL,
+    workspace_id varchar NOT NULL,
+    status varchar NOT NULL,
+    details varchar,
+    started_at timestamp without time zone,
+    started_by varchar,
+    finished_at timestamp without time zone NOT NULL,
+    services_count integer NOT NULL DEFAULT 0,
+    services jsonb,
+    CONSTRAINT discovery_history_pkey PRIMARY KEY (discovery_id)
+);
+
+CREATE INDEX IF NOT EXISTS discovery_history_agent_namespace_workspace_idx
+    ON discovery_history (agent_id, namespace, workspace_id, finished_at DESC);
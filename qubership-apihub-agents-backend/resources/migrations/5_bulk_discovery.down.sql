@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS bulk_discovery_target;
+DROP TABLE IF EXISTS bulk_discovery;
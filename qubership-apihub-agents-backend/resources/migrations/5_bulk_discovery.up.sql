@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS bulk_discovery
+(
+    job_id varchar NOT NULL,
+    workspace_id varchar NOT NULL,
+    status varchar NOT NULL,
+    details varchar,
+    created_at timestamp without time zone NOT NULL,
+    created_by varchar,
+    finished_at timestamp without time zone,
+    CONSTRAINT bulk_discovery_pkey PRIMARY KEY (job_id)
+);
+
+CREATE TABLE IF NOT EXISTS bulk_discovery_target
+(
+    job_id varchar NOT NULL,
+    agent_id varchar NOT NULL,
+    namespace varchar NOT NULL,
+    status varchar NOT NULL,
+    details varchar,
+    services_count integer NOT NULL DEFAULT 0,
+    started_at timestamp without time zone,
+    finished_at timestamp without time zone,
+    CONSTRAINT bulk_discovery_target_pkey PRIMARY KEY (job_id, agent_id, namespace),
+    CONSTRAINT bulk_discovery_target_job_fkey FOREIGN KEY (job_id) REFERENCES bulk_discovery (job_id) ON DELETE CASCADE
+);
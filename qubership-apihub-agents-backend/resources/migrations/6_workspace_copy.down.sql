@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS workspace_copy_package;
+DROP TABLE IF EXISTS workspace_copy;
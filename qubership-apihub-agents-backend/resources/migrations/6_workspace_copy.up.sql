@@ -0,0 +1,36 @@
+CREATE TABLE IF NOT EXISTS workspace_copy
+(
+    copy_id varchar NOT NULL,
+    src_workspace_id varchar NOT NULL,
+    dst_workspace_id varchar NOT NULL,
+    default_role varchar,
+    status varchar NOT NULL,
+    details varchar,
+    created_at timestamp without time zone NOT NULL,
+    created_by varchar,
+    finished_at timestamp without time zone,
+    CONSTRAINT workspace_copy_pkey PRIMARY KEY (copy_id)
+);
+
+CREATE INDEX IF NOT EXISTS workspace_copy_dst_workspace_id_idx
+    ON workspace_copy (dst_workspace_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS workspace_copy_package
+(
+    copy_id varchar NOT NULL,
+    package_id varchar NOT NULL,
+    seq integer NOT NULL,
+    src_package_id varchar,
+    parent_id varchar NOT NULL,
+    kind varchar NOT NULL,
+    name varchar NOT NULL,
+    alias varchar NOT NULL,
+    description varchar,
+    service_name varchar,
+    image_url varchar,
+    release_version_pattern varchar,
+    status varchar NOT NULL,
+    details varchar,
+    CONSTRAINT workspace_copy_package_pkey PRIMARY KEY (copy_id, package_id),
+    CONSTRAINT workspace_copy_package_copy_fkey FOREIGN KEY (copy_id) REFERENCES workspace_copy (copy_id) ON DELETE CASCADE
+);
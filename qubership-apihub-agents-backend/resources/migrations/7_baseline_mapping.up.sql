@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS baseline_mapping
+(
+    workspace_id varchar NOT NULL,
+    rules jsonb NOT NULL,
+    updated_at timestamp without time zone NOT NULL,
+    updated_by varchar,
+    CONSTRAINT baseline_mapping_pkey PRIMARY KEY (workspace_id)
+);
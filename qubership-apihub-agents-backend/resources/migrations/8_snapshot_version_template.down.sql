@@ -0,0 +1 @@
+DROP TABLE IF EXISTS snapshot_version_template;
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS snapshot_version_template
+(
+    workspace_id varchar NOT NULL,
+    template varchar NOT NULL,
+    updated_at timestamp without time zone NOT NULL,
+    updated_by varchar,
+    CONSTRAINT snapshot_version_template_pkey PRIMARY KEY (workspace_id)
+);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS spec_lint_rules
+(
+    workspace_id varchar NOT NULL,
+    rules jsonb NOT NULL,
+    updated_at timestamp without time zone NOT NULL,
+    updated_by varchar,
+    CONSTRAINT spec_lint_rules_pkey PRIMARY KEY (workspace_id)
+);
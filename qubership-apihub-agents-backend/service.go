@@ -103,49 +103,122 @@ func main() {
 
 	agentRepository := repository.NewAgentRepository(cp)
 	namespaceSecurityRepository := repository.NewNamespaceSecurityRepository(cp)
-
-	agentService := service.NewAgentService(agentRepository)
+	discoveryRepository := repository.NewDiscoveryRepository(cp)
+	bulkDiscoveryRepository := repository.NewBulkDiscoveryRepository(cp)
+	workspaceCopyRepository := repository.NewWorkspaceCopyRepository(cp)
+	baselineMappingRepository := repository.NewBaselineMappingRepository(cp)
+	snapshotVersionTemplateRepository := repository.NewSnapshotVersionTemplateRepository(cp)
+	specLintRulesRepository := repository.NewSpecLintRulesRepository(cp)
+	snapshotApprovalRepository := repository.NewSnapshotApprovalRepository(cp)
+	snapshotRetentionRepository := repository.NewSnapshotRetentionRepository(cp)
+	cleanupRepository := repository.NewCleanupRepository(cp)
+	jobLockRepository := repository.NewJobLockRepository(cp)
+
+	agentService := service.NewAgentService(agentRepository, agentClient)
 	permissionService := service.NewPermissionService(apihubClient)
-	discoveryService := service.NewDiscoveryService(agentClient, apihubClient, agentService, permissionService, systemInfoService)
-	snapshotService := service.NewSnapshotService(systemInfoService, apihubClient, agentClient)
+	baselineMappingService := service.NewBaselineMappingService(apihubClient, systemInfoService, permissionService, baselineMappingRepository)
+	snapshotVersionService := service.NewSnapshotVersionService(apihubClient, permissionService, snapshotVersionTemplateRepository)
+	specValidationService := service.NewSpecValidationService(apihubClient, permissionService, specLintRulesRepository)
+	snapshotApprovalService := service.NewSnapshotApprovalService(apihubClient, permissionService, snapshotApprovalRepository)
+	snapshotRetentionService := service.NewSnapshotRetentionService(apihubClient, systemInfoService, permissionService, snapshotRetentionRepository)
+	discoveryHistoryService := service.NewDiscoveryHistoryService(apihubClient, systemInfoService, agentService, permissionService, discoveryRepository)
+	discoveryEventsService := service.NewDiscoveryEventsService(agentService, discoveryHistoryService)
+	jobLockService := service.NewJobLockService(jobLockRepository)
+	workspaceCopyService := service.NewWorkspaceCopyService(apihubClient, systemInfoService, jobLockService, workspaceCopyRepository)
+	discoveryService := service.NewDiscoveryService(apihubClient, agentService, permissionService, systemInfoService, discoveryHistoryService, workspaceCopyService, baselineMappingService)
+	bulkDiscoveryService := service.NewBulkDiscoveryService(apihubClient, agentService, permissionService, discoveryService, discoveryEventsService, bulkDiscoveryRepository)
+	snapshotService := service.NewSnapshotService(systemInfoService, apihubClient, agentService, permissionService, baselineMappingService, snapshotVersionService, specValidationService, snapshotApprovalService)
 	apiKeyService := service.NewApiKeyService(apihubClient, service.MinSize, service.DefaultAge)
 	userService := service.NewUserService(apihubClient, service.MinSize, service.DefaultAge)
-	namespaceSecurityService := service.NewNamespaceSecurityService(agentClient, apihubClient, namespaceSecurityRepository, agentService, snapshotService, apiKeyService, userService, systemInfoService)
+	namespaceSecurityService := service.NewNamespaceSecurityService(agentClient, apihubClient, namespaceSecurityRepository, agentService, snapshotService, apiKeyService, userService, systemInfoService, discoveryEventsService, discoveryHistoryService)
+	agentOverviewService := service.NewAgentOverviewService(agentService, apihubClient, discoveryRepository, namespaceSecurityRepository)
 	excelService := service.NewExcelService(namespaceSecurityRepository, apihubClient)
-	cleanupService := service.NewCleanupService(apihubClient)
+	cleanupService := service.NewCleanupService(apihubClient, snapshotService, snapshotRetentionService, jobLockService, cleanupRepository, namespaceSecurityRepository)
 	err = cleanupService.CreateSnapshotsCleanupJob(systemInfoService.GetSnapshotsCleanupSchedule(), systemInfoService.GetSnapshotsTTLDays())
 	if err != nil {
 		log.Warnf("failed to create snapshots cleanup job: %v", err)
 	}
+	err = cleanupService.CreateAuthSecurityChecksCleanupJob(systemInfoService.GetAuthSecurityCheckCleanupSchedule(), systemInfoService.GetAuthSecurityCheckKeepLast())
+	if err != nil {
+		log.Warnf("failed to create auth security checks cleanup job: %v", err)
+	}
 
-	agentController := controller.NewAgentController(agentService, agentClient)
-	discoveryController := controller.NewDiscoveryController(discoveryService)
+	agentController := controller.NewAgentController(agentService, agentOverviewService)
+	discoveryController := controller.NewDiscoveryController(discoveryService, discoveryHistoryService, discoveryEventsService, bulkDiscoveryService)
+	workspaceCopyController := controller.NewWorkspaceCopyController(workspaceCopyService)
+	baselineMappingController := controller.NewBaselineMappingController(baselineMappingService)
+	snapshotVersionController := controller.NewSnapshotVersionController(snapshotVersionService)
+	specValidationController := controller.NewSpecValidationController(specValidationService)
+	snapshotApprovalController := controller.NewSnapshotApprovalController(snapshotApprovalService, agentService)
+	snapshotRetentionController := controller.NewSnapshotRetentionController(snapshotRetentionService)
 	snapshotsController := controller.NewSnapshotController(snapshotService, agentService)
-	specificationsController := controller.NewSpecificationsController(agentClient, agentService)
+	specificationsController := controller.NewSpecificationsController(agentService)
 	namespaceSecurityController := controller.NewNamespaceSecurityController(namespaceSecurityService, excelService)
 	agentProxyController := controller.NewAgentProxyController(agentService)
 	logsController := controller.NewLogsController()
+	cleanupController := controller.NewCleanupController(cleanupService)
 
 	healthController := controller.NewHealthController(readyChan)
 
 	//TODO: it is necessary to add a new permission for the entire agent’s functionality after adding the ability to extend permissions in qubership-apihub-backend
 	r.HandleFunc("/api/v2/agents", security.Secure(agentController.ListAgents)).Methods(http.MethodGet)
 	r.HandleFunc("/api/v2/agents", security.Secure(agentController.ProcessAgentSignal)).Methods(http.MethodPost)
+	r.HandleFunc("/api/v2/agents/overview", security.Secure(agentController.GetAgentsOverview)).Methods(http.MethodGet)
 	r.HandleFunc("/api/v2/agents/{id}", security.Secure(agentController.GetAgent)).Methods(http.MethodGet)
 	r.HandleFunc("/api/v2/agents/{agentId}/namespaces", security.Secure(agentController.GetAgentNamespaces)).Methods(http.MethodGet)
 	r.HandleFunc("/api/v1/agents/{agentId}/namespaces", security.Secure(agentController.GetAgentNamespaces)).Methods(http.MethodGet) //deprecated
 	r.HandleFunc("/api/v2/agents/{agentId}/namespaces/{namespace}/serviceNames", security.Secure(agentController.ListServiceNames)).Methods(http.MethodGet)
 
 	r.HandleFunc("/api/v2/agents/{agentId}/namespaces/{namespace}/workspaces/{workspaceId}/discover", security.Secure(discoveryController.StartDiscovery)).Methods(http.MethodPost)
+	r.HandleFunc("/api/v2/discovery/bulk", security.Secure(discoveryController.StartBulkDiscovery)).Methods(http.MethodPost)
+	r.HandleFunc("/api/v2/discovery/bulk/{jobId}", security.Secure(discoveryController.GetBulkDiscovery)).Methods(http.MethodGet)
+	r.HandleFunc("/api/v2/agents/{agentId}/namespaces/{namespace}/workspaces/{workspaceId}/discover/events", security.Secure(discoveryController.GetDiscoveryEvents)).Methods(http.MethodGet)
 	r.HandleFunc("/api/v2/agents/{agentId}/namespaces/{namespace}/workspaces/{workspaceId}/services", security.Secure(discoveryController.ListDiscoveredServices_deprecated)).Methods(http.MethodGet) //deprecated
 
 	r.HandleFunc("/api/v3/agents/{agentId}/namespaces/{namespace}/workspaces/{workspaceId}/services", security.Secure(discoveryController.ListDiscoveredServices)).Methods(http.MethodGet)
+	r.HandleFunc("/api/v2/agents/{agentId}/namespaces/{namespace}/workspaces/{workspaceId}/discoveries", security.Secure(discoveryController.ListDiscoveries)).Methods(http.MethodGet)
+	r.HandleFunc("/api/v2/agents/{agentId}/namespaces/{namespace}/workspaces/{workspaceId}/discoveries/compare", security.Secure(discoveryController.CompareDiscoveries)).Methods(http.MethodGet)
+	r.HandleFunc("/api/v2/agents/{agentId}/namespaces/{namespace}/workspaces/{workspaceId}/discoveries/{discoveryId}", security.Secure(discoveryController.GetDiscovery)).Methods(http.MethodGet)
+
+	r.HandleFunc("/api/v2/workspaces/{workspaceId}/structureCopies/preview", security.Secure(workspaceCopyController.PreviewWorkspaceCopy)).Methods(http.MethodPost)
+	r.HandleFunc("/api/v2/workspaces/{workspaceId}/structureCopies", security.Secure(workspaceCopyController.CopyWorkspaceStructure)).Methods(http.MethodPost)
+	r.HandleFunc("/api/v2/workspaces/{workspaceId}/structureCopies", security.Secure(workspaceCopyController.ListWorkspaceCopies)).Methods(http.MethodGet)
+	r.HandleFunc("/api/v2/workspaces/{workspaceId}/structureCopies/{copyId}", security.Secure(workspaceCopyController.GetWorkspaceCopy)).Methods(http.MethodGet)
+	r.HandleFunc("/api/v2/workspaces/{workspaceId}/structureCopies/{copyId}/resume", security.Secure(workspaceCopyController.ResumeWorkspaceCopy)).Methods(http.MethodPost)
+	r.HandleFunc("/api/v2/workspaces/{workspaceId}/structureCopies/{copyId}/rollback", security.Secure(workspaceCopyController.RollbackWorkspaceCopy)).Methods(http.MethodPost)
+	r.HandleFunc("/api/v2/workspaces/{workspaceId}/structureDrift", security.Secure(workspaceCopyController.GetWorkspaceDrift)).Methods(http.MethodGet)
+	r.HandleFunc("/api/v2/workspaces/{workspaceId}/structureSync", security.Secure(workspaceCopyController.SyncWorkspaceStructure)).Methods(http.MethodPost)
+	r.HandleFunc("/api/v2/workspaces/{workspaceId}/baselineMappingRules", security.Secure(baselineMappingController.GetBaselineMappingRules)).Methods(http.MethodGet)
+	r.HandleFunc("/api/v2/workspaces/{workspaceId}/baselineMappingRules", security.Secure(baselineMappingController.UpdateBaselineMappingRules)).Methods(http.MethodPut)
+	r.HandleFunc("/api/v2/workspaces/{workspaceId}/baselineMappingRules/test", security.Secure(baselineMappingController.TestBaselineMapping)).Methods(http.MethodPost)
 
 	r.HandleFunc("/api/v2/agents/{agentId}/namespaces/{namespace}/workspaces/{workspaceId}/services/{serviceId}/specs/{fileId}", security.Secure(specificationsController.GetServiceSpecification)).Methods(http.MethodGet)
 
 	r.HandleFunc("/api/v2/agents/{agentId}/namespaces/{namespace}/workspaces/{workspaceId}/snapshots", security.Secure(snapshotsController.CreateSnapshot)).Methods(http.MethodPost)
 	r.HandleFunc("/api/v2/agents/{agentId}/namespaces/{namespace}/workspaces/{workspaceId}/snapshots", security.Secure(snapshotsController.ListSnapshots)).Methods(http.MethodGet)
+	r.HandleFunc("/api/v2/agents/{agentId}/namespaces/{namespace}/workspaces/{workspaceId}/snapshots/validate", security.Secure(snapshotsController.ValidateSnapshot)).Methods(http.MethodPost)
+	r.HandleFunc("/api/v2/workspaces/{workspaceId}/snapshotVersionTemplate", security.Secure(snapshotVersionController.GetSnapshotVersionTemplate)).Methods(http.MethodGet)
+	r.HandleFunc("/api/v2/workspaces/{workspaceId}/snapshotVersionTemplate", security.Secure(snapshotVersionController.UpdateSnapshotVersionTemplate)).Methods(http.MethodPut)
+	r.HandleFunc("/api/v2/workspaces/{workspaceId}/snapshotVersionTemplate", security.Secure(snapshotVersionController.DeleteSnapshotVersionTemplate)).Methods(http.MethodDelete)
+	r.HandleFunc("/api/v2/workspaces/{workspaceId}/specLintRules", security.Secure(specValidationController.GetSpecLintRules)).Methods(http.MethodGet)
+	r.HandleFunc("/api/v2/workspaces/{workspaceId}/specLintRules", security.Secure(specValidationController.UpdateSpecLintRules)).Methods(http.MethodPut)
+	r.HandleFunc("/api/v2/workspaces/{workspaceId}/snapshotApprovers", security.Secure(snapshotApprovalController.GetSnapshotApprovers)).Methods(http.MethodGet)
+	r.HandleFunc("/api/v2/workspaces/{workspaceId}/snapshotApprovers", security.Secure(snapshotApprovalController.UpdateSnapshotApprovers)).Methods(http.MethodPut)
+	r.HandleFunc("/api/v2/workspaces/{workspaceId}/snapshotRetentionPolicies", security.Secure(snapshotRetentionController.GetSnapshotRetentionPolicies)).Methods(http.MethodGet)
+	r.HandleFunc("/api/v2/workspaces/{workspaceId}/snapshotRetentionPolicies", security.Secure(snapshotRetentionController.UpdateSnapshotRetentionPolicies)).Methods(http.MethodPut)
+	r.HandleFunc("/api/v2/workspaces/{workspaceId}/snapshotRetentionPolicies/dryRun", security.Secure(snapshotRetentionController.DryRunSnapshotRetention)).Methods(http.MethodGet)
+	r.HandleFunc("/api/v2/workspaces/{workspaceId}/snapshots/compare", security.Secure(snapshotsController.CompareEnvironmentSnapshots)).Methods(http.MethodGet)
+	r.HandleFunc("/api/v2/workspaces/{workspaceId}/snapshots/import", security.Secure(snapshotsController.ImportSnapshotBundle)).Methods(http.MethodPost)
+	r.HandleFunc("/api/v2/agents/{agentId}/namespaces/{namespace}/workspaces/{workspaceId}/snapshots/compare", security.Secure(snapshotsController.CompareSnapshots)).Methods(http.MethodGet)
 	r.HandleFunc("/api/v2/agents/{agentId}/namespaces/{namespace}/workspaces/{workspaceId}/snapshots/{version}", security.Secure(snapshotsController.GetSnapshot)).Methods(http.MethodGet)
+	r.HandleFunc("/api/v2/agents/{agentId}/namespaces/{namespace}/workspaces/{workspaceId}/snapshots/{version}", security.Secure(snapshotsController.DeleteSnapshot)).Methods(http.MethodDelete)
+	r.HandleFunc("/api/v2/agents/{agentId}/namespaces/{namespace}/workspaces/{workspaceId}/snapshots/{version}", security.Secure(snapshotsController.UpdateSnapshot)).Methods(http.MethodPatch)
+	r.HandleFunc("/api/v2/agents/{agentId}/namespaces/{namespace}/workspaces/{workspaceId}/snapshots/{version}/bundle", security.Secure(snapshotsController.GetSnapshotBundle)).Methods(http.MethodGet)
+	r.HandleFunc("/api/v2/agents/{agentId}/namespaces/{namespace}/workspaces/{workspaceId}/snapshots/{version}/promote", security.Secure(snapshotsController.PromoteSnapshot)).Methods(http.MethodPost)
+	r.HandleFunc("/api/v2/agents/{agentId}/namespaces/{namespace}/workspaces/{workspaceId}/snapshots/{version}/approval", security.Secure(snapshotApprovalController.SubmitSnapshotApproval)).Methods(http.MethodPost)
+	r.HandleFunc("/api/v2/agents/{agentId}/namespaces/{namespace}/workspaces/{workspaceId}/snapshots/{version}/approval", security.Secure(snapshotApprovalController.GetSnapshotApproval)).Methods(http.MethodGet)
+	r.HandleFunc("/api/v2/agents/{agentId}/namespaces/{namespace}/workspaces/{workspaceId}/snapshots/{version}/approval/decision", security.Secure(snapshotApprovalController.DecideSnapshotApproval)).Methods(http.MethodPost)
+	r.HandleFunc("/api/v2/agents/{agentId}/namespaces/{namespace}/workspaces/{workspaceId}/snapshots/{version}/approval/comments", security.Secure(snapshotApprovalController.CommentSnapshotApproval)).Methods(http.MethodPost)
 
 	r.HandleFunc("/api/v2/security/authCheck", security.Secure(namespaceSecurityController.StartAuthSecurityCheck)).Methods(http.MethodPost)
 	r.HandleFunc("/api/v3/security/authCheck", security.Secure(namespaceSecurityController.GetAuthSecurityCheckReports)).Methods(http.MethodGet)
@@ -155,6 +228,11 @@ func main() {
 	r.HandleFunc("/api/v1/debug/logs/setLevel", security.Secure(logsController.SetLogLevel)).Methods(http.MethodPost)
 	r.HandleFunc("/api/v1/debug/logs/checkLevel", security.Secure(logsController.CheckLogLevel)).Methods(http.MethodGet)
 
+	r.HandleFunc("/api/v2/cleanup/snapshots/run", security.Secure(cleanupController.RunSnapshotsCleanup)).Methods(http.MethodPost)
+	r.HandleFunc("/api/v2/cleanup/authSecurityChecks/run", security.Secure(cleanupController.RunAuthSecurityChecksCleanup)).Methods(http.MethodPost)
+	r.HandleFunc("/api/v2/cleanup/runs", security.Secure(cleanupController.ListCleanupRuns)).Methods(http.MethodGet)
+	r.HandleFunc("/api/v2/cleanup/runs/{runId}", security.Secure(cleanupController.GetCleanupRun)).Methods(http.MethodGet)
+
 	const proxyPath = "/agents/{agentId}/namespaces/{namespace}/services/{serviceId}/proxy/" //deprecated
 	if systemInfoService.InsecureProxyEnabled() {
 		r.PathPrefix(proxyPath).HandlerFunc(agentProxyController.Proxy)
@@ -1,49 +1,154 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/Netcracker/qubership-apihub-agents-backend/client"
 	"github.com/Netcracker/qubership-apihub-agents-backend/entity"
+	"github.com/Netcracker/qubership-apihub-agents-backend/exception"
 	"github.com/Netcracker/qubership-apihub-agents-backend/repository"
 	"github.com/Netcracker/qubership-apihub-agents-backend/view"
+	"github.com/shaj13/libcache"
+	log "github.com/sirupsen/logrus"
 )
 
 type AgentService interface {
 	ProcessAgentSignal(view.AgentKeepaliveMessage) (*view.AgentVersion, error)
 	ListAgents(onlyActive bool, showIncompatible bool) ([]view.AgentInstance, error)
 	GetAgent(id string) (*view.AgentInstance, error)
+	// CallAgent executes the call against active agent replicas in round-robin order and fails over to the next replica
+	// if the call fails with a transport error.
+	// Must not be used for discovery calls, discovery results are kept in memory of the replica which ran the discovery
+	CallAgent(agent view.AgentInstance, call func(agentUrl string) error) error
+	// StartDiscovery starts the namespace discovery on the replica pinned to the agent namespace and workspace,
+	// a new replica is pinned if the pinned one is not active
+	StartDiscovery(ctx context.Context, agent view.AgentInstance, namespace string, workspaceId string, failOnError bool) error
+	// ListServices returns services discovered by the pinned replica, nil is returned if the discovery is not found
+	ListServices(ctx context.Context, agent view.AgentInstance, namespace string, workspaceId string) (*view.ServiceListResponse, error)
+	ListServices_deprecated(ctx context.Context, agent view.AgentInstance, namespace string, workspaceId string) (*view.ServiceListResponse_deprecated, error)
+	// GetServiceSpecification returns the specification discovered by the pinned replica
+	GetServiceSpecification(ctx context.Context, agent view.AgentInstance, namespace string, workspaceId string, serviceId string, fileId string) ([]byte, error)
+	// GetNamespaces returns agent namespaces from cache or requests them from the agent if cache is empty or refresh is requested
+	GetNamespaces(ctx context.Context, agent view.AgentInstance, refresh bool) (*view.AgentNamespaces, error)
+	// NamespaceExists checks if the namespace is known by the agent, cached namespaces are refreshed if the namespace is not found.
+	// The checked namespaces list is returned too
+	NamespaceExists(ctx context.Context, agent view.AgentInstance, namespace string) (*view.AgentNamespaces, bool, error)
+	// ListServiceNames returns namespace service names from cache or requests them from the agent if cache is empty or refresh is requested
+	ListServiceNames(ctx context.Context, agent view.AgentInstance, namespace string, refresh bool) (*view.ServiceNamesResponse, error)
 }
 
-func NewAgentService(repository repository.AgentRepository) AgentService {
+func NewAgentService(repository repository.AgentRepository, agentClient client.AgentClient) AgentService {
+	namespacesCache := libcache.LRU.New(agentLookupCacheSize)
+	namespacesCache.SetTTL(agentNamespacesTTL)
+	namespacesCache.RegisterOnExpired(func(key, _ interface{}) {
+		namespacesCache.Delete(key)
+	})
+	serviceNamesCache := libcache.LRU.New(agentLookupCacheSize)
+	serviceNamesCache.SetTTL(agentServiceNamesTTL)
+	serviceNamesCache.RegisterOnExpired(func(key, _ interface{}) {
+		serviceNamesCache.Delete(key)
+	})
 	return &agentServiceImpl{
-		repository: repository,
+		repository:        repository,
+		agentClient:       agentClient,
+		replicaCounters:   &sync.Map{},
+		replicaCleanupAt:  &sync.Map{},
+		namespacesCache:   namespacesCache,
+		serviceNamesCache: serviceNamesCache,
 	}
 }
 
 type agentServiceImpl struct {
-	repository repository.AgentRepository
+	repository        repository.AgentRepository
+	agentClient       client.AgentClient
+	replicaCounters   *sync.Map      // map[agentId]*atomic.Uint64
+	replicaCleanupAt  *sync.Map      // map[agentId]time.Time
+	namespacesCache   libcache.Cache // map[agentId]agentCacheEntry{view.AgentNamespaces}
+	serviceNamesCache libcache.Cache // map[agentId|namespace]agentCacheEntry{view.ServiceNamesResponse}
+}
+
+// agentCacheEntry is the agent response cached together with urls of the agent replicas.
+// Agents are read from the database shared by all backend replicas, so every backend replica ignores
+// the entry once the agent is registered with other urls, not only the one which got the keepalive signal
+type agentCacheEntry struct {
+	agentUrls string
+	value     interface{}
+}
+
+func makeAgentUrlsKey(agent view.AgentInstance) string {
+	urls := []string{agent.AgentUrl}
+	for _, replica := range agent.Replicas {
+		urls = append(urls, replica.Url)
+	}
+	slices.Sort(urls)
+	return strings.Join(slices.Compact(urls), ",")
+}
+
+func loadAgentCache(cache libcache.Cache, key string, agent view.AgentInstance) (interface{}, bool) {
+	cached, exists := cache.Load(key)
+	if !exists {
+		return nil, false
+	}
+	entry := cached.(agentCacheEntry)
+	if entry.agentUrls != makeAgentUrlsKey(agent) {
+		return nil, false
+	}
+	return entry.value, true
+}
+
+func storeAgentCache(cache libcache.Cache, key string, agent view.AgentInstance, value interface{}) {
+	cache.Store(key, agentCacheEntry{agentUrls: makeAgentUrlsKey(agent), value: value})
 }
 
 const EXPECTED_AGENT_VERSION = "1.0.0"
 
+const agentReplicaRetention = time.Hour * 24
+const agentReplicaCleanupInterval = time.Minute * 10
+
+const agentLookupCacheSize = 1000
+const agentNamespacesTTL = time.Minute * 5
+const agentServiceNamesTTL = time.Minute * 2
+
 func (a agentServiceImpl) ProcessAgentSignal(message view.AgentKeepaliveMessage) (*view.AgentVersion, error) {
+	agentId := view.MakeAgentId(message.Cloud, message.Namespace)
+	now := time.Now()
 	ent := entity.AgentEntity{
-		AgentId:        view.MakeAgentId(message.Cloud, message.Namespace),
+		AgentId:        agentId,
 		Cloud:          message.Cloud,
 		Namespace:      message.Namespace,
 		Url:            message.Url,
 		BackendVersion: message.BackendVersion,
 		Name:           message.Name,
-		LastActive:     time.Now(),
+		LastActive:     now,
+		AgentVersion:   message.AgentVersion,
+	}
+	instanceId := message.InstanceId
+	if instanceId == "" {
+		instanceId = message.Url
+	}
+	replica := entity.AgentReplicaEntity{
+		AgentId:        agentId,
+		InstanceId:     instanceId,
+		Url:            message.Url,
+		LastActive:     now,
+		BackendVersion: message.BackendVersion,
 		AgentVersion:   message.AgentVersion,
 	}
 
-	err := a.repository.CreateOrUpdateAgent(ent)
+	err := a.repository.CreateOrUpdateAgentWithReplica(ent, replica)
 	if err != nil {
 		return nil, err
 	}
+	a.cleanupInactiveReplicas(agentId, now)
 	return &view.AgentVersion{Version: EXPECTED_AGENT_VERSION}, nil
 }
 
@@ -53,6 +158,19 @@ func (a agentServiceImpl) ListAgents(onlyActive bool, showIncompatible bool) ([]
 		return nil, err
 	}
 
+	agentIds := make([]string, 0, len(ents))
+	for _, ent := range ents {
+		agentIds = append(agentIds, ent.AgentId)
+	}
+	replicaEnts, err := a.repository.ListAgentReplicas(agentIds)
+	if err != nil {
+		return nil, err
+	}
+	replicasByAgent := make(map[string][]entity.AgentReplicaEntity)
+	for _, replicaEnt := range replicaEnts {
+		replicasByAgent[replicaEnt.AgentId] = append(replicasByAgent[replicaEnt.AgentId], replicaEnt)
+	}
+
 	result := make([]view.AgentInstance, 0)
 	for _, ent := range ents {
 		compErr := CheckAgentCompatibility(ent.AgentVersion)
@@ -61,6 +179,7 @@ func (a agentServiceImpl) ListAgents(onlyActive bool, showIncompatible bool) ([]
 		}
 		agentView := entity.MakeAgentView(ent)
 		agentView.CompatibilityError = compErr
+		agentView.Replicas = entity.MakeAgentReplicasView(replicasByAgent[ent.AgentId])
 		result = append(result, agentView)
 	}
 
@@ -75,11 +194,241 @@ func (a agentServiceImpl) GetAgent(id string) (*view.AgentInstance, error) {
 	if ent == nil {
 		return nil, nil
 	}
+	replicaEnts, err := a.repository.ListAgentReplicas([]string{id})
+	if err != nil {
+		return nil, err
+	}
 	res := entity.MakeAgentView(*ent)
 	res.CompatibilityError = CheckAgentCompatibility(ent.AgentVersion)
+	res.Replicas = entity.MakeAgentReplicasView(replicaEnts)
 	return &res, nil
 }
 
+func (a agentServiceImpl) CallAgent(agent view.AgentInstance, call func(agentUrl string) error) error {
+	urls := agent.ActiveReplicaUrls()
+	if len(urls) == 0 {
+		return fmt.Errorf("agent '%s' has no known replicas", agent.AgentId)
+	}
+	urls = a.roundRobinOrder(agent.AgentId, urls)
+
+	var err error
+	for i, agentUrl := range urls {
+		err = call(agentUrl)
+		if err == nil || !isAgentTransportError(err) {
+			return err
+		}
+		if i < len(urls)-1 {
+			log.Warnf("Call to agent '%s' replica %s failed, trying next replica: %s", agent.AgentId, agentUrl, err.Error())
+		}
+	}
+	return err
+}
+
+func (a agentServiceImpl) StartDiscovery(ctx context.Context, agent view.AgentInstance, namespace string, workspaceId string, failOnError bool) error {
+	_, err := a.callDiscoveryReplica(agent, namespace, workspaceId, func(agentUrl string) (bool, error) {
+		return true, a.agentClient.StartDiscovery(ctx, namespace, workspaceId, agentUrl, failOnError)
+	})
+	return err
+}
+
+func (a agentServiceImpl) ListServices(ctx context.Context, agent view.AgentInstance, namespace string, workspaceId string) (*view.ServiceListResponse, error) {
+	var serviceList *view.ServiceListResponse
+	_, err := a.callDiscoveryReplica(agent, namespace, workspaceId, func(agentUrl string) (bool, error) {
+		var err error
+		serviceList, err = a.agentClient.ListServices(ctx, namespace, workspaceId, agentUrl)
+		return serviceList != nil, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return serviceList, nil
+}
+
+func (a agentServiceImpl) ListServices_deprecated(ctx context.Context, agent view.AgentInstance, namespace string, workspaceId string) (*view.ServiceListResponse_deprecated, error) {
+	var serviceList *view.ServiceListResponse_deprecated
+	_, err := a.callDiscoveryReplica(agent, namespace, workspaceId, func(agentUrl string) (bool, error) {
+		var err error
+		serviceList, err = a.agentClient.ListServices_deprecated(ctx, namespace, workspaceId, agentUrl)
+		return serviceList != nil, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return serviceList, nil
+}
+
+func (a agentServiceImpl) GetServiceSpecification(ctx context.Context, agent view.AgentInstance, namespace string, workspaceId string, serviceId string, fileId string) ([]byte, error) {
+	var data []byte
+	var notFoundErr error
+	found, err := a.callDiscoveryReplica(agent, namespace, workspaceId, func(agentUrl string) (bool, error) {
+		var err error
+		data, err = a.agentClient.GetServiceSpecification(ctx, namespace, workspaceId, serviceId, fileId, agentUrl)
+		var customErr *exception.CustomError
+		if errors.As(err, &customErr) && customErr.Status == http.StatusNotFound {
+			notFoundErr = err
+			return false, nil
+		}
+		return err == nil, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, notFoundErr
+	}
+	return data, nil
+}
+
+// callDiscoveryReplica executes the call against the replica pinned to the agent namespace and workspace.
+// Other replicas are called if the pinned replica fails with a transport error or if there is no pinned replica and
+// the called replica doesn't know the discovery (the call returns false), the replica which returns the result gets pinned.
+// Not found result of the pinned replica is final, other replicas might keep results of outdated discoveries
+func (a agentServiceImpl) callDiscoveryReplica(agent view.AgentInstance, namespace string, workspaceId string, call func(agentUrl string) (bool, error)) (bool, error) {
+	urls := agent.ActiveReplicaUrls()
+	if len(urls) == 0 {
+		return false, fmt.Errorf("agent '%s' has no known replicas", agent.AgentId)
+	}
+	pinnedUrl := ""
+	pinned, err := a.repository.GetAgentDiscoveryReplica(agent.AgentId, namespace, workspaceId)
+	if err != nil {
+		return false, fmt.Errorf("failed to get discovery replica of agent '%s': %w", agent.AgentId, err)
+	}
+	if pinned != nil && slices.Contains(urls, pinned.Url) {
+		pinnedUrl = pinned.Url
+		urls = append([]string{pinnedUrl}, slices.DeleteFunc(slices.Clone(urls), func(url string) bool { return url == pinnedUrl })...)
+	} else {
+		urls = a.roundRobinOrder(agent.AgentId, urls)
+	}
+
+	for i, agentUrl := range urls {
+		found, callErr := call(agentUrl)
+		if callErr != nil {
+			if !isAgentTransportError(callErr) {
+				return false, callErr
+			}
+			err = callErr
+			if i < len(urls)-1 {
+				log.Warnf("Discovery call to agent '%s' replica %s failed, trying next replica: %s", agent.AgentId, agentUrl, callErr.Error())
+			}
+			continue
+		}
+		if found {
+			if agentUrl != pinnedUrl {
+				a.pinDiscoveryReplica(agent.AgentId, namespace, workspaceId, agentUrl)
+			}
+			return true, nil
+		}
+		if agentUrl == pinnedUrl {
+			return false, nil
+		}
+	}
+	return false, err
+}
+
+func (a agentServiceImpl) pinDiscoveryReplica(agentId string, namespace string, workspaceId string, agentUrl string) {
+	err := a.repository.SaveAgentDiscoveryReplica(&entity.AgentDiscoveryReplicaEntity{
+		AgentId:     agentId,
+		Namespace:   namespace,
+		WorkspaceId: workspaceId,
+		Url:         agentUrl,
+		PinnedAt:    time.Now(),
+	})
+	if err != nil {
+		log.Errorf("Failed to pin agent '%s' replica %s for namespace %s and workspace %s: %s", agentId, agentUrl, namespace, workspaceId, err.Error())
+	}
+}
+
+// roundRobinOrder returns urls starting from the next replica of the agent
+func (a agentServiceImpl) roundRobinOrder(agentId string, urls []string) []string {
+	counter, _ := a.replicaCounters.LoadOrStore(agentId, new(atomic.Uint64))
+	start := int(counter.(*atomic.Uint64).Add(1) % uint64(len(urls)))
+	return append(slices.Clone(urls[start:]), urls[:start]...)
+}
+
+// isAgentTransportError checks if the agent replica is not reachable, http errors are returned by the replica itself
+func isAgentTransportError(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+func (a agentServiceImpl) GetNamespaces(ctx context.Context, agent view.AgentInstance, refresh bool) (*view.AgentNamespaces, error) {
+	if !refresh {
+		if cached, exists := loadAgentCache(a.namespacesCache, agent.AgentId, agent); exists {
+			namespaces := cached.(view.AgentNamespaces)
+			return &namespaces, nil
+		}
+	}
+	var namespaces *view.AgentNamespaces
+	err := a.CallAgent(agent, func(agentUrl string) error {
+		var err error
+		namespaces, err = a.agentClient.GetNamespaces(ctx, agentUrl)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if namespaces == nil {
+		return nil, nil
+	}
+	storeAgentCache(a.namespacesCache, agent.AgentId, agent, *namespaces)
+	return namespaces, nil
+}
+
+func (a agentServiceImpl) NamespaceExists(ctx context.Context, agent view.AgentInstance, namespace string) (*view.AgentNamespaces, bool, error) {
+	_, cached := loadAgentCache(a.namespacesCache, agent.AgentId, agent)
+	namespaces, err := a.GetNamespaces(ctx, agent, false)
+	if err != nil {
+		return nil, false, err
+	}
+	if namespaces != nil && slices.Contains(namespaces.Namespaces, namespace) {
+		return namespaces, true, nil
+	}
+	if !cached {
+		return namespaces, false, nil
+	}
+	// the namespace might be created after the namespaces list was cached
+	namespaces, err = a.GetNamespaces(ctx, agent, true)
+	if err != nil {
+		return nil, false, err
+	}
+	return namespaces, namespaces != nil && slices.Contains(namespaces.Namespaces, namespace), nil
+}
+
+func (a agentServiceImpl) ListServiceNames(ctx context.Context, agent view.AgentInstance, namespace string, refresh bool) (*view.ServiceNamesResponse, error) {
+	cacheKey := agent.AgentId + "|" + namespace
+	if !refresh {
+		if cached, exists := loadAgentCache(a.serviceNamesCache, cacheKey, agent); exists {
+			serviceNames := cached.(view.ServiceNamesResponse)
+			return &serviceNames, nil
+		}
+	}
+	var serviceNames *view.ServiceNamesResponse
+	err := a.CallAgent(agent, func(agentUrl string) error {
+		var err error
+		serviceNames, err = a.agentClient.ListServiceNames(ctx, agentUrl, namespace)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if serviceNames == nil {
+		return nil, nil
+	}
+	storeAgentCache(a.serviceNamesCache, cacheKey, agent, *serviceNames)
+	return serviceNames, nil
+}
+
+func (a agentServiceImpl) cleanupInactiveReplicas(agentId string, now time.Time) {
+	if lastCleanup, exists := a.replicaCleanupAt.Load(agentId); exists && now.Sub(lastCleanup.(time.Time)) < agentReplicaCleanupInterval {
+		return
+	}
+	a.replicaCleanupAt.Store(agentId, now)
+	err := a.repository.DeleteAgentReplicasInactiveSince(agentId, now.Add(-agentReplicaRetention))
+	if err != nil {
+		log.Warnf("Failed to delete inactive replicas for agent '%s': %s", agentId, err.Error())
+	}
+}
+
 func CheckAgentCompatibility(actualAgentVersion string) *view.AgentCompatibilityError {
 	if EXPECTED_AGENT_VERSION == actualAgentVersion {
 		return nil
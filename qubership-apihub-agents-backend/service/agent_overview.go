@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/Netcracker/qubership-apihub-agents-backend/client"
+	"github.com/Netcracker/qubership-apihub-agents-backend/entity"
+	"github.com/Netcracker/qubership-apihub-agents-backend/exception"
+	"github.com/Netcracker/qubership-apihub-agents-backend/repository"
+	"github.com/Netcracker/qubership-apihub-agents-backend/secctx"
+	"github.com/Netcracker/qubership-apihub-agents-backend/utils"
+	"github.com/Netcracker/qubership-apihub-agents-backend/view"
+	"github.com/shaj13/libcache"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	agentsOverviewCacheSize   = 100
+	agentsOverviewTTL         = time.Minute
+	agentsOverviewConcurrency = 20
+	// agentNamespacesConcurrency limits parallel namespaces requests to agents
+	agentNamespacesConcurrency = 10
+)
+
+type AgentOverviewService interface {
+	GetAgentsOverview(ctx context.Context, workspaceId string, onlyActive bool, refresh bool) (*view.AgentsOverview, error)
+}
+
+func NewAgentOverviewService(agentService AgentService, apihubClient client.ApihubClient,
+	discoveryRepository repository.DiscoveryRepository, namespaceSecurityRepository repository.NamespaceSecurityRepository) AgentOverviewService {
+	overviewCache := libcache.LRU.New(agentsOverviewCacheSize)
+	overviewCache.SetTTL(agentsOverviewTTL)
+	overviewCache.RegisterOnExpired(func(key, _ interface{}) {
+		overviewCache.Delete(key)
+	})
+	return &agentOverviewServiceImpl{
+		agentService:                agentService,
+		apihubClient:                apihubClient,
+		discoveryRepository:         discoveryRepository,
+		namespaceSecurityRepository: namespaceSecurityRepository,
+		overviewCache:               overviewCache,
+	}
+}
+
+type agentOverviewServiceImpl struct {
+	agentService                AgentService
+	apihubClient                client.ApihubClient
+	discoveryRepository         repository.DiscoveryRepository
+	namespaceSecurityRepository repository.NamespaceSecurityRepository
+	overviewCache               libcache.Cache // map[workspaceId|onlyActive]view.AgentsOverview
+}
+
+func (a *agentOverviewServiceImpl) GetAgentsOverview(ctx context.Context, workspaceId string, onlyActive bool, refresh bool) (*view.AgentsOverview, error) {
+	// workspace is requested with user context to make sure that the user has access to it, the overview itself is shared between users
+	workspace, err := a.apihubClient.GetPackageById(ctx, workspaceId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workspace by id: %v", err.Error())
+	}
+	if workspace == nil || workspace.Kind != string(view.KindWorkspace) {
+		return nil, &exception.CustomError{
+			Status:  http.StatusNotFound,
+			Code:    exception.WorkspaceNotFound,
+			Message: exception.WorkspaceNotFoundMsg,
+			Params:  map[string]interface{}{"workspaceId": workspaceId},
+		}
+	}
+
+	cacheKey := fmt.Sprintf("%s|%v", workspaceId, onlyActive)
+	if !refresh {
+		if cached, exists := a.overviewCache.Load(cacheKey); exists {
+			overview := cached.(view.AgentsOverview)
+			return &overview, nil
+		}
+	}
+
+	overview, err := a.makeAgentsOverview(secctx.MakeSysadminContext(ctx), workspaceId, onlyActive, refresh)
+	if err != nil {
+		return nil, err
+	}
+	a.overviewCache.Store(cacheKey, *overview)
+	return overview, nil
+}
+
+func (a *agentOverviewServiceImpl) makeAgentsOverview(ctx context.Context, workspaceId string, onlyActive bool, refresh bool) (*view.AgentsOverview, error) {
+	agents, err := a.agentService.ListAgents(onlyActive, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list agents: %v", err.Error())
+	}
+	discoveryEnts, err := a.discoveryRepository.ListNamespaceDiscoveries(workspaceId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list namespace discoveries: %v", err.Error())
+	}
+	securityCheckEnts, err := a.namespaceSecurityRepository.GetLatestNamespaceSecurityChecks(workspaceId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list namespace security checks: %v", err.Error())
+	}
+	discoveries := make(map[string]map[string]entity.NamespaceDiscoveryEntity)
+	for _, ent := range discoveryEnts {
+		if discoveries[ent.AgentId] == nil {
+			discoveries[ent.AgentId] = make(map[string]entity.NamespaceDiscoveryEntity)
+		}
+		discoveries[ent.AgentId][ent.Namespace] = ent
+	}
+	securityChecks := make(map[string]map[string]entity.NamespaceSecurityCheckStatusEntity)
+	for _, ent := range securityCheckEnts {
+		if securityChecks[ent.AgentId] == nil {
+			securityChecks[ent.AgentId] = make(map[string]entity.NamespaceSecurityCheckStatusEntity)
+		}
+		securityChecks[ent.AgentId][ent.Namespace] = ent
+	}
+
+	result := view.AgentsOverview{
+		Agents:      make([]view.AgentOverview, len(agents)),
+		WorkspaceId: workspaceId,
+		CreatedAt:   time.Now(),
+	}
+	agentNamespaces := make([][]string, len(agents))
+	agentNamespacesErrors := make([]error, len(agents))
+	namespacesErrGrp := errgroup.Group{}
+	namespacesErrGrp.SetLimit(agentNamespacesConcurrency)
+	for i, agent := range agents {
+		namespacesErrGrp.Go(func() error {
+			agentNamespaces[i], agentNamespacesErrors[i] = a.getAgentNamespaces(ctx, agent, refresh)
+			return nil
+		})
+	}
+	_ = namespacesErrGrp.Wait()
+
+	errGrp, _ := errgroup.WithContext(ctx)
+	errGrp.SetLimit(agentsOverviewConcurrency)
+	for i, agent := range agents {
+		agentOverview := view.AgentOverview{AgentInstance: agent}
+		namespaces, err := agentNamespaces[i], agentNamespacesErrors[i]
+		if err != nil {
+			agentOverview.NamespacesError = err.Error()
+			// namespaces list is not available, so fallback to the namespaces known by the backend
+			for namespace := range discoveries[agent.AgentId] {
+				namespaces = append(namespaces, namespace)
+			}
+			for namespace := range securityChecks[agent.AgentId] {
+				if _, exists := discoveries[agent.AgentId][namespace]; !exists {
+					namespaces = append(namespaces, namespace)
+				}
+			}
+		} else {
+			agentOverview.NamespacesCount = len(namespaces)
+		}
+		sort.Strings(namespaces)
+		agentOverview.Namespaces = make([]view.NamespaceOverview, len(namespaces))
+		for j, namespace := range namespaces {
+			namespaceOverview := &agentOverview.Namespaces[j]
+			namespaceOverview.Namespace = namespace
+			if securityCheckEnt, exists := securityChecks[agent.AgentId][namespace]; exists {
+				securityCheckView := entity.MakeNamespaceSecurityCheckOverviewView(securityCheckEnt)
+				namespaceOverview.LastSecurityCheck = &securityCheckView
+			}
+			discoveryEnt, discoveryExists := discoveries[agent.AgentId][namespace]
+			currentAgent := agent
+			errGrp.Go(func() error {
+				if discoveryExists {
+					discoveryView := entity.MakeNamespaceDiscoveryOverviewView(a.refreshDiscoveryStatus(ctx, currentAgent, discoveryEnt))
+					namespaceOverview.LastDiscovery = &discoveryView
+				}
+				lastSnapshot, err := a.getLastSnapshot(ctx, workspaceId, currentAgent.AgentDeploymentCloud, namespaceOverview.Namespace)
+				if err != nil {
+					log.Warnf("Failed to get last snapshot for agent %s and namespace %s: %s", currentAgent.AgentId, namespaceOverview.Namespace, err.Error())
+				}
+				namespaceOverview.LastSnapshot = lastSnapshot
+				return nil
+			})
+		}
+		result.Agents[i] = agentOverview
+	}
+	err = errGrp.Wait()
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (a *agentOverviewServiceImpl) getAgentNamespaces(ctx context.Context, agent view.AgentInstance, refresh bool) ([]string, error) {
+	if agent.Status != view.AgentStatusActive {
+		return nil, fmt.Errorf("agent is not active")
+	}
+	if agent.CompatibilityError != nil && agent.CompatibilityError.Severity == view.SeverityError {
+		return nil, fmt.Errorf("%s", agent.CompatibilityError.Message)
+	}
+	namespaces, err := a.agentService.GetNamespaces(ctx, agent, refresh)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get agent namespaces: %v", err.Error())
+	}
+	if namespaces == nil {
+		return make([]string, 0), nil
+	}
+	return append([]string{}, namespaces.Namespaces...), nil
+}
+
+// refreshDiscoveryStatus requests actual status from the agent for the running discovery since backend is not notified about discovery completion.
+// The overview is read-only, so the discovery result is stored in the history by the discovery endpoints and events, not here
+func (a *agentOverviewServiceImpl) refreshDiscoveryStatus(ctx context.Context, agent view.AgentInstance, ent entity.NamespaceDiscoveryEntity) entity.NamespaceDiscoveryEntity {
+	if ent.Status != string(view.StatusRunning) || agent.Status != view.AgentStatusActive {
+		return ent
+	}
+	serviceList, err := a.agentService.ListServices(ctx, agent, ent.Namespace, ent.WorkspaceId)
+	if err != nil {
+		log.Warnf("Failed to get discovery status for agent %s and namespace %s: %s", agent.AgentId, ent.Namespace, err.Error())
+		return ent
+	}
+	if serviceList == nil || (serviceList.Status != view.StatusComplete && serviceList.Status != view.StatusError) {
+		return ent
+	}
+	finishedAt := time.Now()
+	ent.Status = string(serviceList.Status)
+	ent.Details = serviceList.Debug
+	ent.FinishedAt = &finishedAt
+	return ent
+}
+
+func (a *agentOverviewServiceImpl) getLastSnapshot(ctx context.Context, workspaceId string, cloudName string, namespace string) (*view.SnapshotListItem, error) {
+	groupId := fmt.Sprintf("%s.%s.%s.%s", workspaceId, view.DefaultSnapshotsGroupAlias, utils.ToId(cloudName), utils.ToId(namespace))
+	dashboardId := view.MakeSnapshotDashboardIdByGroupId(groupId)
+	versions, err := a.apihubClient.GetVersions(ctx, dashboardId, view.VersionSearchRequest{Limit: 1})
+	if err != nil {
+		return nil, err
+	}
+	if versions == nil || len(versions.Versions) == 0 {
+		return nil, nil
+	}
+	return &view.SnapshotListItem{
+		Version:           versions.Versions[0].Version,
+		CreatedAt:         versions.Versions[0].CreatedAt,
+		NotLatestRevision: versions.Versions[0].NotLatestRevision,
+	}, nil
+}
@@ -0,0 +1,397 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Netcracker/qubership-apihub-agents-backend/client"
+	"github.com/Netcracker/qubership-apihub-agents-backend/entity"
+	"github.com/Netcracker/qubership-apihub-agents-backend/exception"
+	"github.com/Netcracker/qubership-apihub-agents-backend/repository"
+	"github.com/Netcracker/qubership-apihub-agents-backend/secctx"
+	"github.com/Netcracker/qubership-apihub-agents-backend/view"
+	"github.com/shaj13/libcache"
+	"golang.org/x/sync/errgroup"
+)
+
+const baselineMappingCacheSize = 1000
+const baselineMappingTTL = time.Minute
+const baselineMappingConcurrency = 10
+const baselineVersionsLimit = 100
+
+// BaselineMappingService finds baseline packages for services which have no package with the same service name
+// using the mapping rules configured per workspace
+type BaselineMappingService interface {
+	GetBaselineMappingRules(ctx context.Context, workspaceId string) (*view.BaselineMappingRules, error)
+	UpdateBaselineMappingRules(ctx context.Context, workspaceId string, req view.UpdateBaselineMappingRulesReq) (*view.BaselineMappingRules, error)
+	// TestBaselineMapping resolves the baseline package for the service as discovery would do it
+	TestBaselineMapping(ctx context.Context, workspaceId string, req view.TestBaselineMappingReq) (*view.BaselineMappingResult, error)
+	// ResolveBaselines sets the baseline for discovered services which have no baseline found by the agent
+	// and explains the match for all services with the baseline
+	ResolveBaselines(ctx context.Context, workspaceId string, services []view.Service) error
+	// ResolveBaselinePackage finds the baseline package by the service name first and by the mapping rules then.
+	// Empty package id is returned if the baseline is not found
+	ResolveBaselinePackage(ctx context.Context, workspaceId string, serviceName string, labels map[string]string) (string, *view.BaselineMatch, error)
+}
+
+func NewBaselineMappingService(apihubClient client.ApihubClient, systemInfoService SystemInfoService, permissionService PermissionService, baselineMappingRepository repository.BaselineMappingRepository) BaselineMappingService {
+	rulesCache := libcache.LRU.New(baselineMappingCacheSize)
+	rulesCache.SetTTL(baselineMappingTTL)
+	rulesCache.RegisterOnExpired(func(key, _ interface{}) {
+		rulesCache.Delete(key)
+	})
+	return &baselineMappingServiceImpl{
+		apihubClient:              apihubClient,
+		systemInfoService:         systemInfoService,
+		permissionService:         permissionService,
+		baselineMappingRepository: baselineMappingRepository,
+		rulesCache:                rulesCache,
+	}
+}
+
+type baselineMappingServiceImpl struct {
+	apihubClient              client.ApihubClient
+	systemInfoService         SystemInfoService
+	permissionService         PermissionService
+	baselineMappingRepository repository.BaselineMappingRepository
+	rulesCache                libcache.Cache // map[workspaceId][]baselineMappingRule
+}
+
+// baselineMappingRule is the stored rule with the compiled pattern, the pattern is nil if it's not set or invalid
+type baselineMappingRule struct {
+	view.BaselineMappingRule
+	pattern *regexp.Regexp
+}
+
+func (b baselineMappingServiceImpl) GetBaselineMappingRules(ctx context.Context, workspaceId string) (*view.BaselineMappingRules, error) {
+	err := checkWorkspacePermission(ctx, b.apihubClient, b.permissionService, workspaceId, view.ReadPermission)
+	if err != nil {
+		return nil, err
+	}
+	ent, err := b.baselineMappingRepository.GetBaselineMapping(workspaceId)
+	if err != nil {
+		return nil, err
+	}
+	if ent == nil {
+		return &view.BaselineMappingRules{WorkspaceId: workspaceId, Rules: make([]view.BaselineMappingRule, 0)}, nil
+	}
+	result := entity.MakeBaselineMappingRulesView(*ent)
+	return &result, nil
+}
+
+func (b baselineMappingServiceImpl) UpdateBaselineMappingRules(ctx context.Context, workspaceId string, req view.UpdateBaselineMappingRulesReq) (*view.BaselineMappingRules, error) {
+	err := checkWorkspaceManagePermission(ctx, b.apihubClient, b.permissionService, workspaceId)
+	if err != nil {
+		return nil, err
+	}
+	rules := req.Rules
+	if rules == nil {
+		rules = make([]view.BaselineMappingRule, 0)
+	}
+	for i, rule := range rules {
+		if err = validateBaselineMappingRule(workspaceId, i, rule); err != nil {
+			return nil, err
+		}
+	}
+	ent := entity.BaselineMappingEntity{
+		WorkspaceId: workspaceId,
+		Rules:       rules,
+		UpdatedAt:   time.Now(),
+		UpdatedBy:   secctx.GetUserId(ctx),
+	}
+	err = b.baselineMappingRepository.SaveBaselineMapping(&ent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store baseline mapping rules: %v", err.Error())
+	}
+	b.rulesCache.Delete(workspaceId)
+	result := entity.MakeBaselineMappingRulesView(ent)
+	return &result, nil
+}
+
+func validateBaselineMappingRule(workspaceId string, index int, rule view.BaselineMappingRule) error {
+	param := func(name string) string {
+		return fmt.Sprintf("rules[%d].%s", index, name)
+	}
+	missingParams := make([]string, 0)
+	switch rule.Type {
+	case view.BaselineMappingRuleExplicit:
+		if rule.ServiceName == "" {
+			missingParams = append(missingParams, param("serviceName"))
+		}
+		if rule.PackageId == "" {
+			missingParams = append(missingParams, param("packageId"))
+		}
+	case view.BaselineMappingRuleRegex:
+		if rule.Pattern == "" {
+			missingParams = append(missingParams, param("pattern"))
+		}
+		if rule.Replacement == "" {
+			missingParams = append(missingParams, param("replacement"))
+		}
+	case view.BaselineMappingRuleLabel:
+		if rule.LabelName == "" {
+			missingParams = append(missingParams, param("labelName"))
+		}
+	default:
+		return &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.InvalidParameter,
+			Message: exception.InvalidParameterMsg,
+			Params:  map[string]interface{}{"param": param("type")},
+			Debug:   fmt.Sprintf("unknown rule type '%s'", rule.Type),
+		}
+	}
+	if len(missingParams) > 0 {
+		return &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.RequiredParamsMissing,
+			Message: exception.RequiredParamsMissingMsg,
+			Params:  map[string]interface{}{"params": strings.Join(missingParams, ", ")},
+		}
+	}
+	if rule.Pattern != "" {
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return &exception.CustomError{
+				Status:  http.StatusBadRequest,
+				Code:    exception.InvalidParameter,
+				Message: exception.InvalidParameterMsg,
+				Params:  map[string]interface{}{"param": param("pattern")},
+				Debug:   err.Error(),
+			}
+		}
+	}
+	if rule.PackageId != "" && !strings.HasPrefix(rule.PackageId, workspaceId+".") {
+		return &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.InvalidParameter,
+			Message: exception.InvalidParameterMsg,
+			Params:  map[string]interface{}{"param": param("packageId")},
+			Debug:   fmt.Sprintf("package must belong to '%s' workspace", workspaceId),
+		}
+	}
+	return nil
+}
+
+func (b baselineMappingServiceImpl) TestBaselineMapping(ctx context.Context, workspaceId string, req view.TestBaselineMappingReq) (*view.BaselineMappingResult, error) {
+	err := checkWorkspacePermission(ctx, b.apihubClient, b.permissionService, workspaceId, view.ReadPermission)
+	if err != nil {
+		return nil, err
+	}
+	packageId, match, err := b.ResolveBaselinePackage(ctx, workspaceId, req.ServiceName, req.Labels)
+	if err != nil {
+		return nil, err
+	}
+	return &view.BaselineMappingResult{
+		Found:     packageId != "",
+		PackageId: packageId,
+		Match:     match,
+	}, nil
+}
+
+func (b baselineMappingServiceImpl) ResolveBaselines(ctx context.Context, workspaceId string, services []view.Service) error {
+	rules, err := b.getRules(workspaceId)
+	if err != nil {
+		return fmt.Errorf("failed to get baseline mapping rules: %v", err.Error())
+	}
+	errGrp := errgroup.Group{}
+	errGrp.SetLimit(baselineMappingConcurrency)
+	for i := range services {
+		svc := &services[i]
+		if svc.Baseline != nil && svc.Baseline.PackageId != "" {
+			// agent finds baseline packages by the service name
+			if svc.Baseline.Match == nil {
+				svc.Baseline.Match = makeServiceNameBaselineMatch(svc.Name)
+			}
+			continue
+		}
+		if len(rules) == 0 {
+			continue
+		}
+		errGrp.Go(func() error {
+			packageId, match, err := b.applyRules(ctx, workspaceId, rules, svc.Name, svc.Labels)
+			if err != nil {
+				return err
+			}
+			if packageId == "" {
+				return nil
+			}
+			baseline, err := b.makeBaseline(ctx, packageId, match)
+			if err != nil {
+				return err
+			}
+			svc.Baseline = baseline
+			return nil
+		})
+	}
+	return errGrp.Wait()
+}
+
+func (b baselineMappingServiceImpl) ResolveBaselinePackage(ctx context.Context, workspaceId string, serviceName string, labels map[string]string) (string, *view.BaselineMatch, error) {
+	pkg, err := b.apihubClient.GetPackageByServiceName(ctx, workspaceId, serviceName)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get apihub package by service name: %v", err.Error())
+	}
+	if pkg != nil && pkg.Id != "" {
+		return pkg.Id, makeServiceNameBaselineMatch(serviceName), nil
+	}
+	rules, err := b.getRules(workspaceId)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get baseline mapping rules: %v", err.Error())
+	}
+	return b.applyRules(ctx, workspaceId, rules, serviceName, labels)
+}
+
+func makeServiceNameBaselineMatch(serviceName string) *view.BaselineMatch {
+	return &view.BaselineMatch{
+		Type:        view.BaselineMatchServiceName,
+		ServiceName: serviceName,
+		Details:     fmt.Sprintf("package service name is '%s'", serviceName),
+	}
+}
+
+// getRules returns cached rules of the workspace, patterns are compiled once when the rules are loaded
+func (b baselineMappingServiceImpl) getRules(workspaceId string) ([]baselineMappingRule, error) {
+	if cached, exists := b.rulesCache.Load(workspaceId); exists {
+		return cached.([]baselineMappingRule), nil
+	}
+	ent, err := b.baselineMappingRepository.GetBaselineMapping(workspaceId)
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]baselineMappingRule, 0)
+	if ent != nil {
+		for _, rule := range ent.Rules {
+			compiledRule := baselineMappingRule{BaselineMappingRule: rule}
+			if rule.Pattern != "" {
+				compiledRule.pattern, _ = regexp.Compile(rule.Pattern)
+			}
+			rules = append(rules, compiledRule)
+		}
+	}
+	b.rulesCache.Store(workspaceId, rules)
+	return rules, nil
+}
+
+// applyRules returns the package of the first rule which matches the service and points to an existing package
+func (b baselineMappingServiceImpl) applyRules(ctx context.Context, workspaceId string, rules []baselineMappingRule, serviceName string, labels map[string]string) (string, *view.BaselineMatch, error) {
+	for i, rule := range rules {
+		ruleIndex := i
+		var packageId string
+		var match *view.BaselineMatch
+		var err error
+		switch rule.Type {
+		case view.BaselineMappingRuleExplicit:
+			if rule.ServiceName != serviceName {
+				continue
+			}
+			packageId, err = b.getPackageId(ctx, rule.PackageId)
+			match = &view.BaselineMatch{
+				Type:        view.BaselineMatchExplicit,
+				Details:     fmt.Sprintf("service '%s' is explicitly mapped to package '%s'", serviceName, rule.PackageId),
+				ServiceName: serviceName,
+			}
+		case view.BaselineMappingRuleRegex:
+			if rule.pattern == nil || !rule.pattern.MatchString(serviceName) {
+				continue
+			}
+			rewrittenServiceName := rule.pattern.ReplaceAllString(serviceName, rule.Replacement)
+			if rewrittenServiceName == "" || rewrittenServiceName == serviceName {
+				continue
+			}
+			packageId, err = b.getPackageIdByServiceName(ctx, workspaceId, rewrittenServiceName)
+			match = &view.BaselineMatch{
+				Type:        view.BaselineMatchRegex,
+				ServiceName: rewrittenServiceName,
+				Details:     fmt.Sprintf("service name '%s' is rewritten to '%s' by pattern '%s'", serviceName, rewrittenServiceName, rule.Pattern),
+			}
+		case view.BaselineMappingRuleLabel:
+			value, exists := labels[rule.LabelName]
+			if !exists || (rule.LabelValue != "" && value != rule.LabelValue) {
+				continue
+			}
+			if rule.PackageId != "" {
+				packageId, err = b.getPackageId(ctx, rule.PackageId)
+				match = &view.BaselineMatch{
+					Type:    view.BaselineMatchLabel,
+					Details: fmt.Sprintf("service label '%s=%s' is mapped to package '%s'", rule.LabelName, value, rule.PackageId),
+				}
+			} else {
+				packageId, err = b.getPackageIdByServiceName(ctx, workspaceId, value)
+				match = &view.BaselineMatch{
+					Type:        view.BaselineMatchLabel,
+					ServiceName: value,
+					Details:     fmt.Sprintf("value of service label '%s' is used as service name '%s'", rule.LabelName, value),
+				}
+			}
+		default:
+			continue
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		if packageId != "" {
+			match.RuleIndex = &ruleIndex
+			return packageId, match, nil
+		}
+	}
+	return "", nil, nil
+}
+
+func (b baselineMappingServiceImpl) getPackageId(ctx context.Context, packageId string) (string, error) {
+	pkg, err := b.apihubClient.GetPackageById(ctx, packageId)
+	if err != nil {
+		return "", fmt.Errorf("failed to get apihub package by id: %v", err.Error())
+	}
+	if pkg == nil || pkg.Kind != string(view.KindPackage) {
+		return "", nil
+	}
+	return pkg.Id, nil
+}
+
+func (b baselineMappingServiceImpl) getPackageIdByServiceName(ctx context.Context, workspaceId string, serviceName string) (string, error) {
+	pkg, err := b.apihubClient.GetPackageByServiceName(ctx, workspaceId, serviceName)
+	if err != nil {
+		return "", fmt.Errorf("failed to get apihub package by service name: %v", err.Error())
+	}
+	if pkg == nil {
+		return "", nil
+	}
+	return pkg.Id, nil
+}
+
+func (b baselineMappingServiceImpl) makeBaseline(ctx context.Context, packageId string, match *view.BaselineMatch) (*view.Baseline, error) {
+	pkg, err := b.apihubClient.GetPackageById(ctx, packageId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get apihub package by id: %v", err.Error())
+	}
+	if pkg == nil {
+		return nil, nil
+	}
+	versions, err := b.apihubClient.GetVersions(ctx, packageId, view.VersionSearchRequest{
+		Status:    string(view.ReleaseStatus),
+		SortBy:    view.VersionSortByCreatedAt,
+		SortOrder: view.VersionSortOrderDesc,
+		Limit:     baselineVersionsLimit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get baseline package versions: %v", err.Error())
+	}
+	result := view.Baseline{
+		PackageId: packageId,
+		Name:      pkg.Name,
+		Url:       fmt.Sprintf("%s/portal/packages/%s", b.systemInfoService.GetApihubUrl(), url.PathEscape(packageId)),
+		Versions:  make([]string, 0),
+		Match:     match,
+	}
+	if versions != nil {
+		for _, version := range versions.Versions {
+			result.Versions = append(result.Versions, strings.Split(version.Version, "@")[0])
+		}
+	}
+	return &result, nil
+}
@@ -0,0 +1,291 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"sync/atomic"
+	"time"
+
+	"github.com/Netcracker/qubership-apihub-agents-backend/client"
+	"github.com/Netcracker/qubership-apihub-agents-backend/entity"
+	"github.com/Netcracker/qubership-apihub-agents-backend/exception"
+	"github.com/Netcracker/qubership-apihub-agents-backend/repository"
+	"github.com/Netcracker/qubership-apihub-agents-backend/secctx"
+	"github.com/Netcracker/qubership-apihub-agents-backend/utils"
+	"github.com/Netcracker/qubership-apihub-agents-backend/view"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+)
+
+const bulkDiscoveryConcurrency = 10
+const bulkDiscoveryTargetTimeout = 10 * time.Minute
+
+// bulkDiscoveryStatusTimeout is the time to store the job status after the last target is finished
+const bulkDiscoveryStatusTimeout = time.Minute
+
+type BulkDiscoveryService interface {
+	StartBulkDiscovery(ctx context.Context, req view.StartBulkDiscoveryReq) (string, error)
+	// GetBulkDiscovery returns the job status, the job which is running longer than all its targets may take
+	// is marked as interrupted since it was run by a stopped backend instance
+	GetBulkDiscovery(ctx context.Context, jobId string) (*view.BulkDiscoveryJob, error)
+}
+
+func NewBulkDiscoveryService(apihubClient client.ApihubClient, agentService AgentService, permissionService PermissionService, discoveryService DiscoveryService,
+	discoveryEventsService DiscoveryEventsService, bulkDiscoveryRepository repository.BulkDiscoveryRepository) BulkDiscoveryService {
+	return &bulkDiscoveryServiceImpl{
+		apihubClient:            apihubClient,
+		agentService:            agentService,
+		permissionService:       permissionService,
+		discoveryService:        discoveryService,
+		discoveryEventsService:  discoveryEventsService,
+		bulkDiscoveryRepository: bulkDiscoveryRepository,
+	}
+}
+
+type bulkDiscoveryServiceImpl struct {
+	apihubClient            client.ApihubClient
+	agentService            AgentService
+	permissionService       PermissionService
+	discoveryService        DiscoveryService
+	discoveryEventsService  DiscoveryEventsService
+	bulkDiscoveryRepository repository.BulkDiscoveryRepository
+}
+
+func (b bulkDiscoveryServiceImpl) StartBulkDiscovery(ctx context.Context, req view.StartBulkDiscoveryReq) (string, error) {
+	if len(req.Targets) == 0 && req.NamespacePattern == "" {
+		return "", &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.RequiredParamsMissing,
+			Message: exception.RequiredParamsMissingMsg,
+			Params:  map[string]interface{}{"params": "targets or namespacePattern"},
+		}
+	}
+	if req.NamespacePattern != "" {
+		if _, err := path.Match(req.NamespacePattern, ""); err != nil {
+			return "", &exception.CustomError{
+				Status:  http.StatusBadRequest,
+				Code:    exception.InvalidParameter,
+				Message: exception.InvalidParameterMsg,
+				Params:  map[string]interface{}{"param": "namespacePattern"},
+				Debug:   err.Error(),
+			}
+		}
+	}
+	workspace, err := b.apihubClient.GetPackageById(ctx, req.WorkspaceId)
+	if err != nil {
+		return "", fmt.Errorf("failed to get workspace by id: %v", err.Error())
+	}
+	if workspace == nil || workspace.Kind != string(view.KindWorkspace) {
+		return "", &exception.CustomError{
+			Status:  http.StatusNotFound,
+			Code:    exception.WorkspaceNotFound,
+			Message: exception.WorkspaceNotFoundMsg,
+			Params:  map[string]interface{}{"workspaceId": req.WorkspaceId},
+		}
+	}
+
+	targets, err := b.resolveTargets(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	if len(targets) == 0 {
+		return "", &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.NoDiscoveryTargets,
+			Message: exception.NoDiscoveryTargetsMsg,
+		}
+	}
+
+	jobEnt := entity.BulkDiscoveryEntity{
+		JobId:       uuid.NewString(),
+		WorkspaceId: req.WorkspaceId,
+		Status:      string(view.StatusRunning),
+		CreatedAt:   time.Now(),
+		CreatedBy:   secctx.GetUserId(ctx),
+	}
+	targetEnts := make([]entity.BulkDiscoveryTargetEntity, 0, len(targets))
+	for _, target := range targets {
+		targetEnts = append(targetEnts, entity.BulkDiscoveryTargetEntity{
+			JobId:     jobEnt.JobId,
+			AgentId:   target.AgentId,
+			Namespace: target.Namespace,
+			Status:    string(view.StatusNone),
+		})
+	}
+	err = b.bulkDiscoveryRepository.SaveBulkDiscovery(&jobEnt, targetEnts)
+	if err != nil {
+		return "", fmt.Errorf("failed to store bulk discovery job: %v", err.Error())
+	}
+	// discoveries are started on behalf of the user, so the security context is kept after the request is completed
+	jobCtx := context.WithoutCancel(ctx)
+	utils.SafeAsync(func() {
+		b.runBulkDiscovery(jobCtx, jobEnt, targetEnts, req.FailOnError)
+	})
+	return jobEnt.JobId, nil
+}
+
+// resolveTargets returns explicitly requested targets and namespaces of active agents matching the pattern without duplicates
+func (b bulkDiscoveryServiceImpl) resolveTargets(ctx context.Context, req view.StartBulkDiscoveryReq) ([]view.BulkDiscoveryTarget, error) {
+	result := make([]view.BulkDiscoveryTarget, 0)
+	known := make(map[view.BulkDiscoveryTarget]struct{})
+	for _, target := range req.Targets {
+		if _, exists := known[target]; !exists {
+			known[target] = struct{}{}
+			result = append(result, target)
+		}
+	}
+	if req.NamespacePattern == "" {
+		return result, nil
+	}
+	agents, err := b.agentService.ListAgents(true, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list agents: %v", err.Error())
+	}
+	for _, agent := range agents {
+		if req.Cloud != "" && agent.AgentDeploymentCloud != req.Cloud {
+			continue
+		}
+		namespaces, err := b.agentService.GetNamespaces(ctx, agent, false)
+		if err != nil {
+			log.Warnf("Failed to get namespaces of agent %s for bulk discovery: %s", agent.AgentId, err.Error())
+			continue
+		}
+		if namespaces == nil {
+			continue
+		}
+		for _, namespace := range namespaces.Namespaces {
+			if matched, _ := path.Match(req.NamespacePattern, namespace); !matched {
+				continue
+			}
+			target := view.BulkDiscoveryTarget{AgentId: agent.AgentId, Namespace: namespace}
+			if _, exists := known[target]; !exists {
+				known[target] = struct{}{}
+				result = append(result, target)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (b bulkDiscoveryServiceImpl) runBulkDiscovery(ctx context.Context, jobEnt entity.BulkDiscoveryEntity, targetEnts []entity.BulkDiscoveryTargetEntity, failOnError bool) {
+	failed := atomic.Int32{}
+	errGrp := errgroup.Group{}
+	errGrp.SetLimit(bulkDiscoveryConcurrency)
+	for i := range targetEnts {
+		targetEnt := &targetEnts[i]
+		errGrp.Go(func() error {
+			b.runTargetDiscovery(ctx, jobEnt.WorkspaceId, targetEnt, failOnError)
+			if targetEnt.Status == string(view.StatusError) {
+				failed.Add(1)
+			}
+			return nil
+		})
+	}
+	_ = errGrp.Wait()
+
+	finishedAt := time.Now()
+	jobEnt.Status = string(view.StatusComplete)
+	jobEnt.FinishedAt = &finishedAt
+	if failedCount := int(failed.Load()); failedCount > 0 {
+		jobEnt.Details = fmt.Sprintf("%d of %d targets failed", failedCount, len(targetEnts))
+		if failedCount == len(targetEnts) {
+			jobEnt.Status = string(view.StatusError)
+		}
+	}
+	err := b.bulkDiscoveryRepository.UpdateBulkDiscoveryStatus(&jobEnt)
+	if err != nil {
+		log.Errorf("Failed to store bulk discovery %s status: %s", jobEnt.JobId, err.Error())
+	}
+}
+
+func (b bulkDiscoveryServiceImpl) runTargetDiscovery(ctx context.Context, workspaceId string, targetEnt *entity.BulkDiscoveryTargetEntity, failOnError bool) {
+	startedAt := time.Now()
+	targetEnt.StartedAt = &startedAt
+	b.updateTargetStatus(targetEnt, view.StatusRunning, "", 0)
+	// the whole target is limited by the timeout, so the job duration is limited too
+	ctx, cancel := context.WithTimeout(ctx, bulkDiscoveryTargetTimeout)
+	defer cancel()
+
+	err := b.discoveryService.StartDiscovery(ctx, targetEnt.AgentId, targetEnt.Namespace, workspaceId, failOnError, false)
+	if err != nil {
+		b.updateTargetStatus(targetEnt, view.StatusError, fmt.Sprintf("failed to start discovery: %v", err.Error()), 0)
+		return
+	}
+	agent, err := b.agentService.GetAgent(targetEnt.AgentId)
+	if err != nil || agent == nil {
+		b.updateTargetStatus(targetEnt, view.StatusError, fmt.Sprintf("failed to get agent %s", targetEnt.AgentId), 0)
+		return
+	}
+	result, err := b.discoveryEventsService.WaitForDiscoveryResult(ctx, *agent, targetEnt.Namespace, workspaceId)
+	if err != nil {
+		b.updateTargetStatus(targetEnt, view.StatusError, err.Error(), 0)
+		return
+	}
+	b.updateTargetStatus(targetEnt, view.StatusComplete, result.Debug, len(result.Services))
+}
+
+func (b bulkDiscoveryServiceImpl) updateTargetStatus(targetEnt *entity.BulkDiscoveryTargetEntity, status view.Status, details string, servicesCount int) {
+	if status == view.StatusComplete || status == view.StatusError {
+		finishedAt := time.Now()
+		targetEnt.FinishedAt = &finishedAt
+	}
+	targetEnt.Status = string(status)
+	targetEnt.Details = details
+	targetEnt.ServicesCount = servicesCount
+	err := b.bulkDiscoveryRepository.UpdateBulkDiscoveryTargetStatus(targetEnt)
+	if err != nil {
+		log.Errorf("Failed to store bulk discovery %s status for agent %s, namespace %s: %s", targetEnt.JobId, targetEnt.AgentId, targetEnt.Namespace, err.Error())
+	}
+}
+
+func (b bulkDiscoveryServiceImpl) GetBulkDiscovery(ctx context.Context, jobId string) (*view.BulkDiscoveryJob, error) {
+	jobEnt, err := b.bulkDiscoveryRepository.GetBulkDiscovery(jobId)
+	if err != nil {
+		return nil, err
+	}
+	if jobEnt == nil {
+		return nil, &exception.CustomError{
+			Status:  http.StatusNotFound,
+			Code:    exception.BulkDiscoveryNotFound,
+			Message: exception.BulkDiscoveryNotFoundMsg,
+			Params:  map[string]interface{}{"jobId": jobId},
+		}
+	}
+	err = checkWorkspacePermission(ctx, b.apihubClient, b.permissionService, jobEnt.WorkspaceId, view.ReadPermission)
+	if err != nil {
+		return nil, err
+	}
+	targetEnts, err := b.bulkDiscoveryRepository.GetBulkDiscoveryTargets(jobId)
+	if err != nil {
+		return nil, err
+	}
+	if jobEnt.Status == string(view.StatusRunning) && time.Now().After(getBulkDiscoveryDeadline(*jobEnt, len(targetEnts))) {
+		interrupted, err := b.bulkDiscoveryRepository.InterruptBulkDiscovery(jobId, "bulk discovery was interrupted")
+		if err != nil {
+			return nil, err
+		}
+		if interrupted {
+			log.Warnf("Bulk discovery %s was interrupted since it's running longer than all its targets may take", jobId)
+		}
+		jobEnt, err = b.bulkDiscoveryRepository.GetBulkDiscovery(jobId)
+		if err != nil {
+			return nil, err
+		}
+		targetEnts, err = b.bulkDiscoveryRepository.GetBulkDiscoveryTargets(jobId)
+		if err != nil {
+			return nil, err
+		}
+	}
+	result := entity.MakeBulkDiscoveryJobView(*jobEnt, targetEnts)
+	return &result, nil
+}
+
+// getBulkDiscoveryDeadline returns the time when the job is finished for sure if the backend instance which runs it is alive.
+// Targets are run by bulkDiscoveryConcurrency at once and each of them is limited by bulkDiscoveryTargetTimeout
+func getBulkDiscoveryDeadline(jobEnt entity.BulkDiscoveryEntity, targetsCount int) time.Time {
+	rounds := (targetsCount + bulkDiscoveryConcurrency - 1) / bulkDiscoveryConcurrency
+	return jobEnt.CreatedAt.Add(time.Duration(rounds)*bulkDiscoveryTargetTimeout + bulkDiscoveryStatusTimeout)
+}
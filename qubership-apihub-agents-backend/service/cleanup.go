@@ -2,53 +2,94 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/Netcracker/qubership-apihub-agents-backend/client"
+	"github.com/Netcracker/qubership-apihub-agents-backend/entity"
+	"github.com/Netcracker/qubership-apihub-agents-backend/exception"
+	"github.com/Netcracker/qubership-apihub-agents-backend/repository"
 	"github.com/Netcracker/qubership-apihub-agents-backend/secctx"
+	"github.com/Netcracker/qubership-apihub-agents-backend/utils"
 	"github.com/Netcracker/qubership-apihub-agents-backend/view"
+	"github.com/google/uuid"
 	"github.com/robfig/cron/v3"
 	log "github.com/sirupsen/logrus"
 )
 
 const (
-	defaultCleanupJobTimeout = 48 * time.Hour
-	cleanupJobTimeoutBuffer  = 1 * time.Hour
+	defaultCleanupJobTimeout         = 48 * time.Hour
+	cleanupJobTimeoutBuffer          = 1 * time.Hour
+	snapshotsCleanupJobName          = "snapshotsCleanup"
+	authSecurityChecksCleanupJobName = "authSecurityChecksCleanup"
 )
 
 type CleanupService interface {
 	CreateSnapshotsCleanupJob(schedule string, ttl int) error
+	// RunSnapshotsCleanup starts the snapshots cleanup job out of schedule and returns the run id
+	RunSnapshotsCleanup(ctx context.Context) (string, error)
+	CreateAuthSecurityChecksCleanupJob(schedule string, keepLast int) error
+	// RunAuthSecurityChecksCleanup starts the auth security checks cleanup job out of schedule and returns the run id
+	RunAuthSecurityChecksCleanup(ctx context.Context) (string, error)
+	ListCleanupRuns(jobType string, limit int, page int) (*view.CleanupRuns, error)
+	GetCleanupRun(runId string) (*view.CleanupRun, error)
 }
 
-func NewCleanupService(apihubClient client.ApihubClient) CleanupService {
+func NewCleanupService(apihubClient client.ApihubClient, snapshotService SnapshotService, snapshotRetentionService SnapshotRetentionService, jobLockService JobLockService,
+	cleanupRepository repository.CleanupRepository, namespaceSecurityRepository repository.NamespaceSecurityRepository) CleanupService {
 	cronInstance := cron.New()
 	cronInstance.Start()
 	return &cleanupServiceImpl{
-		apihubClient: apihubClient,
-		cronInstance: cronInstance,
+		apihubClient:                apihubClient,
+		snapshotService:             snapshotService,
+		snapshotRetentionService:    snapshotRetentionService,
+		jobLockService:              jobLockService,
+		cleanupRepository:           cleanupRepository,
+		namespaceSecurityRepository: namespaceSecurityRepository,
+		cronInstance:                cronInstance,
 	}
 }
 
 type cleanupServiceImpl struct {
-	apihubClient client.ApihubClient
-	cronInstance *cron.Cron
+	apihubClient                client.ApihubClient
+	snapshotService             SnapshotService
+	snapshotRetentionService    SnapshotRetentionService
+	jobLockService              JobLockService
+	cleanupRepository           repository.CleanupRepository
+	namespaceSecurityRepository repository.NamespaceSecurityRepository
+	cronInstance                *cron.Cron
+	snapshotsJob                *snapshotsCleanupJob
+	authSecurityChecksJob       *authSecurityChecksCleanupJob
 }
 
 type snapshotsCleanupJob struct {
-	schedule     string
-	ttl          int
-	timeout      time.Duration
-	apihubClient client.ApihubClient
+	schedule                 string
+	ttl                      int
+	timeout                  time.Duration
+	apihubClient             client.ApihubClient
+	snapshotService          SnapshotService
+	snapshotRetentionService SnapshotRetentionService
+	jobLockService           JobLockService
+	cleanupRepository        repository.CleanupRepository
 }
 
 func (c *cleanupServiceImpl) CreateSnapshotsCleanupJob(schedule string, ttl int) error {
-	timeout := c.calculateCleanupJobTimeout(schedule)
+	timeout := c.calculateCleanupJobTimeout(schedule, view.CleanupJobSnapshots)
 	job := snapshotsCleanupJob{
-		schedule:     schedule,
-		ttl:          ttl,
-		timeout:      timeout,
-		apihubClient: c.apihubClient,
+		schedule:                 schedule,
+		ttl:                      ttl,
+		timeout:                  timeout,
+		apihubClient:             c.apihubClient,
+		snapshotService:          c.snapshotService,
+		snapshotRetentionService: c.snapshotRetentionService,
+		jobLockService:           c.jobLockService,
+		cleanupRepository:        c.cleanupRepository,
 	}
+	// the job can be started manually even if the schedule is invalid
+	c.snapshotsJob = &job
 	_, err := c.cronInstance.AddJob(schedule, &job)
 	if err != nil {
 		log.Warnf("Snapshots cleanup job wasn't added for schedule - %s. With error - %s", schedule, err)
@@ -59,12 +100,106 @@ func (c *cleanupServiceImpl) CreateSnapshotsCleanupJob(schedule string, ttl int)
 	return nil
 }
 
-func (c cleanupServiceImpl) calculateCleanupJobTimeout(schedule string) time.Duration {
+func (c *cleanupServiceImpl) RunSnapshotsCleanup(ctx context.Context) (string, error) {
+	if c.snapshotsJob == nil {
+		return "", fmt.Errorf("snapshots cleanup job is not created")
+	}
+	return c.startCleanupJob(ctx, snapshotsCleanupJobName, view.CleanupJobSnapshots, c.snapshotsJob.run)
+}
+
+func (c *cleanupServiceImpl) CreateAuthSecurityChecksCleanupJob(schedule string, keepLast int) error {
+	timeout := c.calculateCleanupJobTimeout(schedule, view.CleanupJobAuthSecurityChecks)
+	job := authSecurityChecksCleanupJob{
+		schedule:                    schedule,
+		keepLast:                    keepLast,
+		timeout:                     timeout,
+		apihubClient:                c.apihubClient,
+		snapshotService:             c.snapshotService,
+		jobLockService:              c.jobLockService,
+		cleanupRepository:           c.cleanupRepository,
+		namespaceSecurityRepository: c.namespaceSecurityRepository,
+	}
+	// the job can be started manually even if the schedule is invalid
+	c.authSecurityChecksJob = &job
+	_, err := c.cronInstance.AddJob(schedule, &job)
+	if err != nil {
+		log.Warnf("Auth security checks cleanup job wasn't added for schedule - %s. With error - %s", schedule, err)
+		return err
+	}
+	log.Infof("Auth security checks cleanup job was created with schedule - %s, last %d checks of each namespace are kept", schedule, keepLast)
+
+	return nil
+}
+
+func (c *cleanupServiceImpl) RunAuthSecurityChecksCleanup(ctx context.Context) (string, error) {
+	if c.authSecurityChecksJob == nil {
+		return "", fmt.Errorf("auth security checks cleanup job is not created")
+	}
+	return c.startCleanupJob(ctx, authSecurityChecksCleanupJobName, view.CleanupJobAuthSecurityChecks, c.authSecurityChecksJob.run)
+}
+
+// startCleanupJob acquires the job lock and starts the run out of schedule, the run id is returned
+func (c *cleanupServiceImpl) startCleanupJob(ctx context.Context, jobName string, jobType string, run func(lockCtx context.Context, runEnt *entity.CleanupRunEntity)) (string, error) {
+	// the run outlives the request, so the lock is not bound to the request context
+	lockCtx, release, err := c.jobLockService.AcquireJobLock(context.Background(), jobName)
+	if err != nil {
+		return "", err
+	}
+	if lockCtx == nil {
+		return "", &exception.CustomError{
+			Status:  http.StatusConflict,
+			Code:    exception.CleanupRunInProgress,
+			Message: exception.CleanupRunInProgressMsg,
+			Params:  map[string]interface{}{"jobType": jobType},
+		}
+	}
+	runEnt, err := startCleanupRun(c.cleanupRepository, jobType, view.CleanupTriggerManual, secctx.GetUserId(ctx))
+	if err != nil {
+		release()
+		return "", err
+	}
+	utils.SafeAsync(func() {
+		defer release()
+		run(lockCtx, runEnt)
+	})
+	return runEnt.RunId, nil
+}
+
+func (c *cleanupServiceImpl) ListCleanupRuns(jobType string, limit int, page int) (*view.CleanupRuns, error) {
+	ents, err := c.cleanupRepository.ListCleanupRuns(jobType, limit, page)
+	if err != nil {
+		return nil, err
+	}
+	result := view.CleanupRuns{Runs: make([]view.CleanupRun, 0, len(ents))}
+	for _, ent := range ents {
+		result.Runs = append(result.Runs, entity.MakeCleanupRunView(ent))
+	}
+	return &result, nil
+}
+
+func (c *cleanupServiceImpl) GetCleanupRun(runId string) (*view.CleanupRun, error) {
+	ent, err := c.cleanupRepository.GetCleanupRun(runId)
+	if err != nil {
+		return nil, err
+	}
+	if ent == nil {
+		return nil, &exception.CustomError{
+			Status:  http.StatusNotFound,
+			Code:    exception.CleanupRunNotFound,
+			Message: exception.CleanupRunNotFoundMsg,
+			Params:  map[string]interface{}{"runId": runId},
+		}
+	}
+	result := entity.MakeCleanupRunView(*ent)
+	return &result, nil
+}
+
+func (c cleanupServiceImpl) calculateCleanupJobTimeout(schedule string, jobType string) time.Duration {
 	parser := cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
 
 	sched, err := parser.Parse(schedule)
 	if err != nil {
-		log.Warnf("Failed to parse cron schedule '%s' for snapshots cleanup job: %v. Using default timeout.", schedule, err)
+		log.Warnf("Failed to parse cron schedule '%s' for %s cleanup job: %v. Using default timeout.", schedule, jobType, err)
 		return defaultCleanupJobTimeout
 	}
 
@@ -75,27 +210,103 @@ func (c cleanupServiceImpl) calculateCleanupJobTimeout(schedule string) time.Dur
 	interval := next2.Sub(next1)
 	if interval <= cleanupJobTimeoutBuffer {
 		timeout := time.Duration(float64(interval) * 0.9)
-		log.Warnf("Calculated interval from cron schedule '%s' for snapshots cleanup job is very short: %v. Using %v as timeout.",
-			schedule, interval, timeout)
+		log.Warnf("Calculated interval from cron schedule '%s' for %s cleanup job is very short: %v. Using %v as timeout.",
+			schedule, jobType, interval, timeout)
 		return timeout
 	}
 
 	timeout := interval - cleanupJobTimeoutBuffer
-	log.Infof("Calculated cleanup job timeout for snapshots cleanup job with schedule '%s': %v (interval: %v)", schedule, timeout, interval)
+	log.Infof("Calculated cleanup job timeout for %s cleanup job with schedule '%s': %v (interval: %v)", jobType, schedule, timeout, interval)
 	return timeout
 }
 
 func (j snapshotsCleanupJob) Run() {
-	ctx, cancel := context.WithTimeout(context.Background(), j.timeout)
+	runScheduledCleanupJob(j.jobLockService, j.cleanupRepository, snapshotsCleanupJobName, view.CleanupJobSnapshots, j.run)
+}
+
+// runScheduledCleanupJob is called by the scheduler of each replica, the job is run only by the replica which acquires the job lock
+func runScheduledCleanupJob(jobLockService JobLockService, cleanupRepository repository.CleanupRepository, jobName string, jobType string, run func(lockCtx context.Context, runEnt *entity.CleanupRunEntity)) {
+	lockCtx, release, err := jobLockService.AcquireJobLock(context.Background(), jobName)
+	if err != nil {
+		log.Errorf("%s %s", cleanupLogPrefix(jobType), err.Error())
+		return
+	}
+	if lockCtx == nil {
+		log.Infof("%s job is run by another replica or the previous run is still in progress, job is being skipped", cleanupLogPrefix(jobType))
+		return
+	}
+	defer release()
+	runEnt, err := startCleanupRun(cleanupRepository, jobType, view.CleanupTriggerSchedule, "")
+	if err != nil {
+		return
+	}
+	run(lockCtx, runEnt)
+}
+
+// startCleanupRun stores the run record, the run is not recorded in the history if it is failed to store it.
+// Must be called under the job lock, so runs which are still running were interrupted by replica shutdown
+func startCleanupRun(cleanupRepository repository.CleanupRepository, jobType string, trigger string, startedBy string) (*entity.CleanupRunEntity, error) {
+	err := cleanupRepository.InterruptCleanupRuns(jobType, "interrupted by replica shutdown or lost job lock")
+	if err != nil {
+		log.Errorf("%s failed to mark interrupted cleanup runs: %s", cleanupLogPrefix(jobType), err.Error())
+	}
+	runEnt := entity.CleanupRunEntity{
+		RunId:     uuid.New().String(),
+		JobType:   jobType,
+		Trigger:   trigger,
+		Status:    string(view.StatusRunning),
+		StartedAt: time.Now(),
+		StartedBy: startedBy,
+		Groups:    make([]view.CleanupRunGroup, 0),
+		Errors:    make([]string, 0),
+	}
+	err = cleanupRepository.SaveCleanupRun(&runEnt)
+	if err != nil {
+		log.Errorf("%s failed to store cleanup run: %s", cleanupLogPrefix(jobType), err.Error())
+		return nil, err
+	}
+	return &runEnt, nil
+}
+
+func finishCleanupRun(cleanupRepository repository.CleanupRepository, runEnt *entity.CleanupRunEntity, status view.Status, details string) {
+	finishedAt := time.Now()
+	runEnt.FinishedAt = &finishedAt
+	runEnt.Status = string(status)
+	runEnt.Details = details
+	err := cleanupRepository.UpdateCleanupRun(runEnt)
+	if err != nil {
+		log.Errorf("%s failed to store result of cleanup run %s: %s", cleanupLogPrefix(runEnt.JobType), runEnt.RunId, err.Error())
+	}
+}
+
+// addRunError logs the error and records it in the run
+func addRunError(runEnt *entity.CleanupRunEntity, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	log.Errorf("%s %s", cleanupLogPrefix(runEnt.JobType), msg)
+	runEnt.Errors = append(runEnt.Errors, msg)
+}
+
+func cleanupLogPrefix(jobType string) string {
+	if jobType == view.CleanupJobAuthSecurityChecks {
+		return "[AuthSecurityChecksCleanup]"
+	}
+	return "[SnapshotsCleanup]"
+}
+
+// run performs the cleanup, lockCtx is cancelled if the job lock is lost
+func (j snapshotsCleanupJob) run(lockCtx context.Context, runEnt *entity.CleanupRunEntity) {
+	ctx, cancel := context.WithTimeout(lockCtx, j.timeout)
 	defer cancel()
 	ctx = secctx.MakeSysadminContext(ctx)
 	info, err := j.apihubClient.GetSystemInfo(ctx)
 	if err != nil {
-		log.Errorf("[SnapshotsCleanup] failed to check for running migrations: %s", ctx.Err().Error())
+		addRunError(runEnt, "failed to check for running migrations: %s", err.Error())
+		finishCleanupRun(j.cleanupRepository, runEnt, view.StatusError, "failed to check for running migrations")
 		return
 	}
 	if info.MigrationInProgress {
 		log.Info("[SnapshotsCleanup] migration in progress, job is being skipped")
+		finishCleanupRun(j.cleanupRepository, runEnt, view.StatusSkipped, "migration in progress")
 		return
 	}
 	workspaces, err := j.apihubClient.GetPackages(ctx, view.PackagesSearchReq{
@@ -103,30 +314,35 @@ func (j snapshotsCleanupJob) Run() {
 	})
 	if err != nil {
 		if ctx.Err() != nil {
-			log.Errorf("[SnapshotsCleanup] cleanup job timed out or was cancelled while getting workspaces: %s", ctx.Err().Error())
-			return
+			addRunError(runEnt, "cleanup job timed out or was cancelled while getting workspaces: %s", ctx.Err().Error())
+		} else {
+			addRunError(runEnt, "failed to get workspaces: %s", err.Error())
 		}
-		log.Errorf("[SnapshotsCleanup] failed to get workspaces: %s", err.Error())
+		finishCleanupRun(j.cleanupRepository, runEnt, view.StatusError, "failed to get workspaces")
 		return
 	}
-	retention := time.Now().AddDate(0, 0, -j.ttl)
-	log.Infof("[SnapshotsCleanup] Starting deleting snapshots published earlier than %s", retention)
+	now := time.Now()
+	retention := now.AddDate(0, 0, -j.ttl)
+	log.Infof("[SnapshotsCleanup] Starting deleting expired snapshots, snapshots published earlier than %s are deleted in workspaces without retention policies", retention)
 	for _, workspace := range workspaces.Packages {
 		select {
 		case <-ctx.Done():
-			log.Errorf("[SnapshotsCleanup] cleanup job timed out or was cancelled during workspace processing: %s", ctx.Err().Error())
+			addRunError(runEnt, "cleanup job timed out or was cancelled during workspace processing: %s", ctx.Err().Error())
+			finishCleanupRun(j.cleanupRepository, runEnt, view.StatusError, "timed out")
 			return
 		default:
 		}
+		runEnt.WorkspacesScanned++
 
 		snapshotsGroupId := workspace.Id + "." + view.DefaultSnapshotsGroupAlias
 		pkg, err := j.apihubClient.GetPackageById(ctx, snapshotsGroupId)
 		if err != nil {
 			if ctx.Err() != nil {
-				log.Errorf("[SnapshotsCleanup] cleanup job timed out or was cancelled while checking '%s' runenv group existence: %s", snapshotsGroupId, ctx.Err().Error())
+				addRunError(runEnt, "cleanup job timed out or was cancelled while checking '%s' runenv group existence: %s", snapshotsGroupId, ctx.Err().Error())
+				finishCleanupRun(j.cleanupRepository, runEnt, view.StatusError, "timed out")
 				return
 			}
-			log.Errorf("[SnapshotsCleanup] Failed to check '%s' runenv group existence: %s", snapshotsGroupId, err.Error())
+			addRunError(runEnt, "Failed to check '%s' runenv group existence: %s", snapshotsGroupId, err.Error())
 			continue
 		}
 		if pkg == nil {
@@ -134,20 +350,282 @@ func (j snapshotsCleanupJob) Run() {
 			continue
 		}
 
-		jobId, err := j.apihubClient.DeleteVersionsRecursively(ctx, snapshotsGroupId, view.DeleteVersionsRecursivelyReq{OlderThanDate: retention})
+		expired, policiesConfigured, err := j.snapshotRetentionService.GetExpiredSnapshots(ctx, workspace.Id, now)
 		if err != nil {
 			if ctx.Err() != nil {
-				log.Errorf("[SnapshotsCleanup] cleanup job timed out or was cancelled while deleting old snapshots in group %s: %s", snapshotsGroupId, ctx.Err().Error())
+				addRunError(runEnt, "cleanup job timed out or was cancelled while applying retention policies of workspace %s: %s", workspace.Id, ctx.Err().Error())
+				finishCleanupRun(j.cleanupRepository, runEnt, view.StatusError, "timed out")
 				return
 			}
-			log.Errorf("[SnapshotsCleanup] Failed to delete old snapshots in group %s: %s", snapshotsGroupId, err.Error())
+			addRunError(runEnt, "Failed to apply retention policies of workspace %s: %s", workspace.Id, err.Error())
 			continue
 		}
-		if jobId == "" {
-			log.Infof("[SnapshotsCleanup] Snapshots group %s not found", snapshotsGroupId)
+		if policiesConfigured {
+			if !j.deleteExpiredSnapshots(ctx, runEnt, workspace.Id, expired) {
+				finishCleanupRun(j.cleanupRepository, runEnt, view.StatusError, "timed out")
+				return
+			}
 			continue
 		}
-		log.Infof("[SnapshotsCleanup] Cleanup snapshots for group %s has been successfully started with job id %s", snapshotsGroupId, jobId)
+		if !j.deleteOldSnapshots(ctx, runEnt, workspace.Id, snapshotsGroupId, retention, expired) {
+			finishCleanupRun(j.cleanupRepository, runEnt, view.StatusError, "timed out")
+			return
+		}
+	}
+	if len(runEnt.Errors) > 0 {
+		finishCleanupRun(j.cleanupRepository, runEnt, view.StatusError, fmt.Sprintf("%d errors occurred", len(runEnt.Errors)))
+	} else {
+		finishCleanupRun(j.cleanupRepository, runEnt, view.StatusComplete, "")
 	}
 	log.Infof("[SnapshotsCleanup] Snapshots cleanup job finished")
 }
+
+// deleteExpiredSnapshots deletes expired snapshots namespace group by namespace group.
+// Service versions referenced by the remaining snapshots are kept, so unchanged services of newer snapshots are not broken.
+// False is returned if the job is timed out
+func (j snapshotsCleanupJob) deleteExpiredSnapshots(ctx context.Context, runEnt *entity.CleanupRunEntity, workspaceId string, expired []view.ExpiredSnapshot) bool {
+	groupIds, groupVersions := groupExpiredSnapshots(expired)
+	for _, groupId := range groupIds {
+		runEnt.GroupsProcessed++
+		deleted, err := j.snapshotService.DeleteNamespaceSnapshots(ctx, groupId, groupVersions[groupId])
+		runEnt.DeletedSnapshots += len(deleted)
+		runGroup := view.CleanupRunGroup{WorkspaceId: workspaceId, GroupId: groupId, DeletedSnapshots: len(deleted)}
+		if err != nil {
+			runGroup.Status = view.StatusError
+			runGroup.Error = err.Error()
+			runEnt.Groups = append(runEnt.Groups, runGroup)
+			if ctx.Err() != nil {
+				addRunError(runEnt, "cleanup job timed out or was cancelled while deleting expired snapshots in group %s: %s", groupId, ctx.Err().Error())
+				return false
+			}
+			addRunError(runEnt, "Failed to delete expired snapshots in group %s (%d of %d deleted): %s", groupId, len(deleted), len(groupVersions[groupId]), err.Error())
+			continue
+		}
+		runGroup.Status = view.StatusComplete
+		runEnt.Groups = append(runEnt.Groups, runGroup)
+		log.Infof("[SnapshotsCleanup] %d expired snapshots have been deleted in group %s", len(deleted), groupId)
+	}
+	return true
+}
+
+// deleteOldSnapshots deletes snapshots older than SNAPSHOTS_TTL_DAYS in the workspace without retention policies by APIHUB recursive deletion.
+// Recursive deletion doesn't know about service versions referenced by newer snapshots, so namespace groups where such versions
+// are published by the old snapshots are cleaned up by deleteExpiredSnapshots, other groups are deleted recursively one by one.
+// The whole snapshots group is deleted recursively if there are no such groups. False is returned if the job is timed out
+func (j snapshotsCleanupJob) deleteOldSnapshots(ctx context.Context, runEnt *entity.CleanupRunEntity, workspaceId string, snapshotsGroupId string, retention time.Time, expired []view.ExpiredSnapshot) bool {
+	groupIds, groupVersions := groupExpiredSnapshots(expired)
+	referencedGroupIds := make(map[string]struct{})
+	recursiveGroupIds := make([]string, 0, len(groupIds))
+	for _, groupId := range groupIds {
+		referenced, err := j.snapshotService.HasReferencedSnapshots(ctx, groupId, groupVersions[groupId])
+		if err != nil {
+			if ctx.Err() != nil {
+				addRunError(runEnt, "cleanup job timed out or was cancelled while checking references to old snapshots in group %s: %s", groupId, ctx.Err().Error())
+				return false
+			}
+			// deleteExpiredSnapshots is safe for the group anyway
+			log.Warnf("[SnapshotsCleanup] Failed to check references to old snapshots in group %s: %s", groupId, err.Error())
+			referencedGroupIds[groupId] = struct{}{}
+			continue
+		}
+		if referenced {
+			referencedGroupIds[groupId] = struct{}{}
+		} else {
+			recursiveGroupIds = append(recursiveGroupIds, groupId)
+		}
+	}
+	if len(referencedGroupIds) == 0 {
+		return j.deleteGroupRecursively(ctx, runEnt, workspaceId, snapshotsGroupId, retention)
+	}
+
+	for _, groupId := range recursiveGroupIds {
+		if !j.deleteGroupRecursively(ctx, runEnt, workspaceId, groupId, retention) {
+			return false
+		}
+	}
+	referencedExpired := make([]view.ExpiredSnapshot, 0)
+	for _, snapshot := range expired {
+		if _, referenced := referencedGroupIds[snapshot.GroupId]; referenced {
+			referencedExpired = append(referencedExpired, snapshot)
+		}
+	}
+	return j.deleteExpiredSnapshots(ctx, runEnt, workspaceId, referencedExpired)
+}
+
+// deleteGroupRecursively starts APIHUB job deleting versions of the group published earlier than retention.
+// False is returned if the job is timed out
+func (j snapshotsCleanupJob) deleteGroupRecursively(ctx context.Context, runEnt *entity.CleanupRunEntity, workspaceId string, groupId string, retention time.Time) bool {
+	runEnt.GroupsProcessed++
+	jobId, err := j.apihubClient.DeleteVersionsRecursively(ctx, groupId, view.DeleteVersionsRecursivelyReq{OlderThanDate: retention})
+	if err != nil {
+		runEnt.Groups = append(runEnt.Groups, view.CleanupRunGroup{WorkspaceId: workspaceId, GroupId: groupId, Status: view.StatusError, Error: err.Error()})
+		if ctx.Err() != nil {
+			addRunError(runEnt, "cleanup job timed out or was cancelled while deleting old snapshots in group %s: %s", groupId, ctx.Err().Error())
+			return false
+		}
+		addRunError(runEnt, "Failed to delete old snapshots in group %s: %s", groupId, err.Error())
+		return true
+	}
+	if jobId == "" {
+		log.Infof("[SnapshotsCleanup] Snapshots group %s not found", groupId)
+		return true
+	}
+	runEnt.Groups = append(runEnt.Groups, view.CleanupRunGroup{WorkspaceId: workspaceId, GroupId: groupId, Status: view.StatusRunning, JobId: jobId})
+	log.Infof("[SnapshotsCleanup] Cleanup snapshots for group %s has been successfully started with job id %s", groupId, jobId)
+	return true
+}
+
+// groupExpiredSnapshots returns ids of namespace groups of the expired snapshots in the order of appearance and versions of each group
+func groupExpiredSnapshots(expired []view.ExpiredSnapshot) ([]string, map[string][]string) {
+	groupVersions := make(map[string][]string)
+	groupIds := make([]string, 0)
+	for _, snapshot := range expired {
+		if _, exists := groupVersions[snapshot.GroupId]; !exists {
+			groupIds = append(groupIds, snapshot.GroupId)
+		}
+		groupVersions[snapshot.GroupId] = append(groupVersions[snapshot.GroupId], snapshot.Version)
+	}
+	return groupIds, groupVersions
+}
+
+type authSecurityChecksCleanupJob struct {
+	schedule                    string
+	keepLast                    int
+	timeout                     time.Duration
+	apihubClient                client.ApihubClient
+	snapshotService             SnapshotService
+	jobLockService              JobLockService
+	cleanupRepository           repository.CleanupRepository
+	namespaceSecurityRepository repository.NamespaceSecurityRepository
+}
+
+func (j authSecurityChecksCleanupJob) Run() {
+	runScheduledCleanupJob(j.jobLockService, j.cleanupRepository, authSecurityChecksCleanupJobName, view.CleanupJobAuthSecurityChecks, j.run)
+}
+
+// run deletes auth security checks except the last keepLast checks of each agent and namespace together with
+// auth security check versions published by them, lockCtx is cancelled if the job lock is lost
+func (j authSecurityChecksCleanupJob) run(lockCtx context.Context, runEnt *entity.CleanupRunEntity) {
+	ctx, cancel := context.WithTimeout(lockCtx, j.timeout)
+	defer cancel()
+	ctx = secctx.MakeSysadminContext(ctx)
+	info, err := j.apihubClient.GetSystemInfo(ctx)
+	if err != nil {
+		addRunError(runEnt, "failed to check for running migrations: %s", err.Error())
+		finishCleanupRun(j.cleanupRepository, runEnt, view.StatusError, "failed to check for running migrations")
+		return
+	}
+	if info.MigrationInProgress {
+		log.Info("[AuthSecurityChecksCleanup] migration in progress, job is being skipped")
+		finishCleanupRun(j.cleanupRepository, runEnt, view.StatusSkipped, "migration in progress")
+		return
+	}
+	checks, err := j.namespaceSecurityRepository.GetOutdatedNamespaceSecurityChecks(j.keepLast)
+	if err != nil {
+		addRunError(runEnt, "failed to get outdated auth security checks: %s", err.Error())
+		finishCleanupRun(j.cleanupRepository, runEnt, view.StatusError, "failed to get outdated auth security checks")
+		return
+	}
+	log.Infof("[AuthSecurityChecksCleanup] Starting deleting %d auth security checks, last %d checks of each namespace are kept", len(checks), j.keepLast)
+
+	// checks of different agents may share the namespace group
+	groupChecks := make(map[string][]entity.NamespaceSecurityCheckEntity)
+	groupIds := make([]string, 0)
+	workspaceIds := make(map[string]struct{})
+	for _, check := range checks {
+		groupId := fmt.Sprintf("%s.%s.%s.%s", check.WorkspaceId, view.DefaultSnapshotsGroupAlias, utils.ToId(check.CloudName), utils.ToId(check.Namespace))
+		if _, exists := groupChecks[groupId]; !exists {
+			groupIds = append(groupIds, groupId)
+		}
+		groupChecks[groupId] = append(groupChecks[groupId], check)
+		workspaceIds[check.WorkspaceId] = struct{}{}
+	}
+	runEnt.WorkspacesScanned = len(workspaceIds)
+	for _, groupId := range groupIds {
+		select {
+		case <-ctx.Done():
+			addRunError(runEnt, "cleanup job timed out or was cancelled during namespace group processing: %s", ctx.Err().Error())
+			finishCleanupRun(j.cleanupRepository, runEnt, view.StatusError, "timed out")
+			return
+		default:
+		}
+		if !j.deleteGroupChecks(ctx, runEnt, groupId, groupChecks[groupId]) {
+			finishCleanupRun(j.cleanupRepository, runEnt, view.StatusError, "timed out")
+			return
+		}
+	}
+	if len(runEnt.Errors) > 0 {
+		finishCleanupRun(j.cleanupRepository, runEnt, view.StatusError, fmt.Sprintf("%d errors occurred", len(runEnt.Errors)))
+	} else {
+		finishCleanupRun(j.cleanupRepository, runEnt, view.StatusComplete, "")
+	}
+	log.Infof("[AuthSecurityChecksCleanup] Auth security checks cleanup job finished")
+}
+
+// deleteGroupChecks deletes auth security check versions of the namespace group which are not used by the kept checks
+// and then deletes the checks. Checks are kept if some of their versions failed to be deleted, so they are retried by the next run.
+// False is returned if the job is timed out
+func (j authSecurityChecksCleanupJob) deleteGroupChecks(ctx context.Context, runEnt *entity.CleanupRunEntity, groupId string, checks []entity.NamespaceSecurityCheckEntity) bool {
+	runEnt.GroupsProcessed++
+	workspaceId, cloudName, namespace := checks[0].WorkspaceId, checks[0].CloudName, checks[0].Namespace
+	runGroup := view.CleanupRunGroup{WorkspaceId: workspaceId, GroupId: groupId}
+	failGroup := func(format string, args ...interface{}) bool {
+		runGroup.Status = view.StatusError
+		runGroup.Error = fmt.Sprintf(format, args...)
+		runEnt.Groups = append(runEnt.Groups, runGroup)
+		if ctx.Err() != nil {
+			addRunError(runEnt, "cleanup job timed out or was cancelled while processing group %s: %s", groupId, ctx.Err().Error())
+			return false
+		}
+		addRunError(runEnt, "Failed to delete auth security checks of group %s: %s", groupId, runGroup.Error)
+		return true
+	}
+
+	processIds := make([]string, 0, len(checks))
+	for _, check := range checks {
+		processIds = append(processIds, check.ProcessId)
+	}
+	services, err := j.namespaceSecurityRepository.GetNamespaceSecurityCheckVersions(processIds)
+	if err != nil {
+		return failGroup("failed to get published versions: %s", err.Error())
+	}
+	usedVersions, err := j.namespaceSecurityRepository.GetUsedNamespaceSecurityCheckVersions(workspaceId, cloudName, namespace, processIds)
+	if err != nil {
+		return failGroup("failed to get versions of kept checks: %s", err.Error())
+	}
+	// checks started on the same day publish revisions of the same version
+	keptVersions := make(map[string]struct{}, len(usedVersions))
+	for _, version := range usedVersions {
+		keptVersions[version] = struct{}{}
+	}
+	versions := make([]string, 0)
+	for _, service := range services {
+		version := strings.Split(service.Version, "@")[0]
+		if !strings.HasPrefix(version, authSecurityCheckVersionPrefix) || !strings.HasPrefix(service.PackageId, groupId+".") {
+			continue
+		}
+		if _, kept := keptVersions[version]; kept || slices.Contains(versions, version) {
+			continue
+		}
+		versions = append(versions, version)
+	}
+	slices.Sort(versions)
+
+	// versions already deleted by the snapshots cleanup or by user are skipped
+	deleted, err := j.snapshotService.DeleteNamespaceSnapshots(ctx, groupId, versions)
+	runGroup.DeletedSnapshots = len(deleted)
+	runEnt.DeletedSnapshots += len(deleted)
+	if err != nil {
+		return failGroup("failed to delete versions (%d of %d deleted): %s", len(deleted), len(versions), err.Error())
+	}
+
+	deletedChecks, err := j.namespaceSecurityRepository.DeleteNamespaceSecurityChecks(processIds)
+	if err != nil {
+		return failGroup("failed to delete checks: %s", err.Error())
+	}
+	runEnt.DeletedSecurityChecks += deletedChecks
+	runGroup.Status = view.StatusComplete
+	runEnt.Groups = append(runEnt.Groups, runGroup)
+	log.Infof("[AuthSecurityChecksCleanup] %d auth security checks and %d versions have been deleted in group %s", deletedChecks, runGroup.DeletedSnapshots, groupId)
+	return true
+}
@@ -4,41 +4,45 @@ import (
 	"context"
 	"fmt"
 	"net/http"
-	"strings"
-	"sync"
 
 	"github.com/Netcracker/qubership-apihub-agents-backend/client"
 	"github.com/Netcracker/qubership-apihub-agents-backend/exception"
 	"github.com/Netcracker/qubership-apihub-agents-backend/secctx"
-	"github.com/Netcracker/qubership-apihub-agents-backend/utils"
 	"github.com/Netcracker/qubership-apihub-agents-backend/view"
+	log "github.com/sirupsen/logrus"
 )
 
 type DiscoveryService interface {
-	StartDiscovery(ctx context.Context, agentId string, namespace string, workspaceId string, failOnError bool) error
+	// StartDiscovery copies missing service packages from the default workspace and starts the discovery.
+	// Metadata of the copied packages is updated from the default workspace only if syncPackages is set
+	StartDiscovery(ctx context.Context, agentId string, namespace string, workspaceId string, failOnError bool, syncPackages bool) error
 	GetDiscoveredServices_deprecated(ctx context.Context, agentId string, namespace string, workspaceId string) (*view.ServiceListResponse_deprecated, error)
 	GetDiscoveredServices(ctx context.Context, agentId string, namespace string, workspaceId string) (*view.ServiceListResponse, error)
 }
 
-func NewDiscoveryService(agentClient client.AgentClient, apihubClient client.ApihubClient, agentService AgentService, permissionService PermissionService, systemInfoService SystemInfoService) DiscoveryService {
+func NewDiscoveryService(apihubClient client.ApihubClient, agentService AgentService, permissionService PermissionService, systemInfoService SystemInfoService, discoveryHistoryService DiscoveryHistoryService, workspaceCopyService WorkspaceCopyService, baselineMappingService BaselineMappingService) DiscoveryService {
 	return &discoveryServiceImpl{
-		defaultWorkspaceId: systemInfoService.GetDefaultWorkspaceId(),
-		agentClient:        agentClient,
-		apihubClient:       apihubClient,
-		agentService:       agentService,
-		permissionService:  permissionService,
+		defaultWorkspaceId:      systemInfoService.GetDefaultWorkspaceId(),
+		apihubClient:            apihubClient,
+		agentService:            agentService,
+		permissionService:       permissionService,
+		discoveryHistoryService: discoveryHistoryService,
+		workspaceCopyService:    workspaceCopyService,
+		baselineMappingService:  baselineMappingService,
 	}
 }
 
 type discoveryServiceImpl struct {
-	defaultWorkspaceId string
-	agentClient        client.AgentClient
-	apihubClient       client.ApihubClient
-	agentService       AgentService
-	permissionService  PermissionService
+	defaultWorkspaceId      string
+	apihubClient            client.ApihubClient
+	agentService            AgentService
+	permissionService       PermissionService
+	discoveryHistoryService DiscoveryHistoryService
+	workspaceCopyService    WorkspaceCopyService
+	baselineMappingService  BaselineMappingService
 }
 
-func (d discoveryServiceImpl) StartDiscovery(ctx context.Context, agentId string, namespace string, workspaceId string, failOnError bool) error {
+func (d discoveryServiceImpl) StartDiscovery(ctx context.Context, agentId string, namespace string, workspaceId string, failOnError bool, syncPackages bool) error {
 	agent, err := d.agentService.GetAgent(agentId)
 	if err != nil {
 		return exception.CustomError{
@@ -68,25 +72,10 @@ func (d discoveryServiceImpl) StartDiscovery(ctx context.Context, agentId string
 			Params:  map[string]interface{}{"workspaceId": workspaceId},
 		}
 	}
-	namespaces, err := d.agentClient.GetNamespaces(ctx, agent.AgentUrl)
+	_, namespaceExists, err := d.agentService.NamespaceExists(ctx, *agent, namespace)
 	if err != nil {
 		return fmt.Errorf("failed to list agent namespaces: %v", err.Error())
 	}
-	if namespaces == nil || len(namespaces.Namespaces) == 0 {
-		return &exception.CustomError{
-			Status:  http.StatusNotFound,
-			Code:    exception.NamespaceNotFound,
-			Message: exception.NamespaceNotFoundMsg,
-			Params:  map[string]interface{}{"namespace": namespace, "agentId": agentId},
-		}
-	}
-	namespaceExists := false
-	for _, ns := range namespaces.Namespaces {
-		if ns == namespace {
-			namespaceExists = true
-			break
-		}
-	}
 	if !namespaceExists {
 		return &exception.CustomError{
 			Status:  http.StatusNotFound,
@@ -96,8 +85,15 @@ func (d discoveryServiceImpl) StartDiscovery(ctx context.Context, agentId string
 		}
 	}
 
+	if syncPackages {
+		// packages are updated using the system api key, so the user must be allowed to change them
+		err = checkWorkspaceManagePermission(ctx, d.apihubClient, d.permissionService, workspaceId)
+		if err != nil {
+			return err
+		}
+	}
 	if d.defaultWorkspaceId != "" && workspaceId != d.defaultWorkspaceId {
-		namespaceServiceNames, err := d.agentClient.ListServiceNames(ctx, agent.AgentUrl, namespace)
+		namespaceServiceNames, err := d.agentService.ListServiceNames(ctx, *agent, namespace, false)
 		if err != nil {
 			return fmt.Errorf("failed to list namespace service names: %v", err.Error())
 		}
@@ -106,231 +102,44 @@ func (d discoveryServiceImpl) StartDiscovery(ctx context.Context, agentId string
 			for _, svc := range namespaceServiceNames.ServiceNames {
 				serviceNames = append(serviceNames, svc.Id)
 			}
-			err = d.copyWorkspaceServicesStructure(secctx.MakeSysadminContext(ctx), d.defaultWorkspaceId, workspaceId, serviceNames, workspace.DefaultRole)
+			workspaceCopy, err := d.workspaceCopyService.CopyWorkspaceStructure(secctx.MakeSysadminContext(ctx), workspaceId,
+				view.WorkspaceCopyReq{SrcWorkspaceId: d.defaultWorkspaceId, ServiceNames: serviceNames})
 			if err != nil {
 				return fmt.Errorf("failed to copy package services from '%v' to '%v': %v", d.defaultWorkspaceId, workspaceId, err.Error())
 			}
-		}
-	}
-
-	return d.agentClient.StartDiscovery(ctx, namespace, workspaceId, agent.AgentUrl, failOnError)
-}
-
-func (d discoveryServiceImpl) copyWorkspaceServicesStructure(ctx context.Context, srcWorkspaceId string, dstWorkspaceId string, serviceNames []string, defaultRole string) error {
-	mutex := &sync.Mutex{}
-	srcPackagesToCopy := make([]*view.PackagesInfo, 0)
-
-	wg := sync.WaitGroup{}
-	errMap := sync.Map{}
-	for _, serviceName := range serviceNames {
-		svcName := serviceName
-		wg.Add(1)
-		utils.SafeAsync(func() {
-			defer wg.Done()
-			dstPackage, err := d.apihubClient.GetPackageByServiceName(ctx, dstWorkspaceId, svcName)
-			if err != nil {
-				errMap.Store(fmt.Sprintf("failed to get apihub package by service name: %v", err.Error()), nil)
-				return
-			}
-			if dstPackage != nil {
-				return
-			}
-			srcPackage, err := d.apihubClient.GetPackageByServiceName(ctx, srcWorkspaceId, svcName)
-			if err != nil {
-				errMap.Store(fmt.Sprintf("failed to get apihub package by service name: %v", err.Error()), nil)
-				return
+			if workspaceCopy.Status == view.WorkspaceCopyStatusFailed {
+				return fmt.Errorf("failed to copy package services from '%v' to '%v': copy %s: %v", d.defaultWorkspaceId, workspaceId, workspaceCopy.CopyId, workspaceCopy.Details)
 			}
-			if srcPackage == nil {
-				return
+			if syncPackages {
+				d.syncWorkspaceStructure(secctx.MakeSysadminContext(ctx), workspaceId, serviceNames)
 			}
-			mutex.Lock()
-			srcPackagesToCopy = append(srcPackagesToCopy, srcPackage)
-			mutex.Unlock()
-		})
-	}
-	wg.Wait()
-
-	errList := make([]string, 0)
-	errMap.Range(func(key, value interface{}) bool {
-		errList = append(errList, key.(string))
-		return false
-	})
-	if len(errList) > 0 {
-		return fmt.Errorf("failed to calculate service packages to copy: %v", strings.Join(errList, ", "))
-	}
-
-	newPackages := make([]view.PackageCreateRequest, 0, len(srcPackagesToCopy))
-
-	productPackageId := fmt.Sprintf("%s.%s", dstWorkspaceId, srcWorkspaceId)
-	productPackage, err := d.apihubClient.GetPackageById(ctx, productPackageId)
-	if err != nil {
-		return fmt.Errorf("failed to get apihub package by id")
-	}
-	if productPackage == nil {
-		newPackages = append(newPackages, view.PackageCreateRequest{
-			ParentId:    dstWorkspaceId,
-			Kind:        string(view.KindGroup),
-			Name:        fmt.Sprintf("%s Product", srcWorkspaceId),
-			Alias:       srcWorkspaceId,
-			Description: fmt.Sprintf("Group to sync packages from '%v' workspace during service discovery", srcWorkspaceId),
-			DefaultRole: defaultRole,
-		})
-	} else {
-		if productPackage.Kind != string(view.KindGroup) {
-			return fmt.Errorf("unable to copy service packages from '%v' to '%v' workspace: package '%v' has invalid package type", srcWorkspaceId, dstWorkspaceId, productPackageId)
 		}
 	}
 
-	seenPackages := make(map[string]struct{})
-	seenPackages[productPackageId] = struct{}{}
-
-	errMap = sync.Map{}
-	wg = sync.WaitGroup{}
-	for _, srtPkg := range srcPackagesToCopy {
-		wg.Add(1)
-		srcPackage := srtPkg
-		utils.SafeAsync(func() {
-			defer wg.Done()
-			newPackageId := fmt.Sprintf("%s.%s", dstWorkspaceId, srcPackage.Id)
-			dstPackage, err := d.apihubClient.GetPackageById(ctx, newPackageId)
-			if err != nil {
-				errMap.Store(fmt.Sprintf("failed to get apihub package by id: %v", err.Error()), nil)
-				return
-			}
-			if dstPackage != nil {
-				errMap.Store(fmt.Sprintf("unable to copy '%v' package from '%v' to '%v' workspace: package with id='%v' and different serviceName already exists", srcPackage.Id, srcWorkspaceId, dstWorkspaceId, newPackageId), nil)
-				return
-			}
-			srcParentIds := getOrderedParentPackageIds(srcPackage.Id)
-			for _, srcParentId := range srcParentIds {
-				dstParentId := fmt.Sprintf("%s.%s", dstWorkspaceId, srcParentId)
-				if _, exists := seenPackages[dstParentId]; exists {
-					continue
-				}
-				dstParent, err := d.apihubClient.GetPackageById(ctx, dstParentId)
-				if err != nil {
-					errMap.Store(fmt.Sprintf("failed to get apihub package by id: %v", err.Error()), nil)
-					return
-				}
-				if dstParent == nil {
-					srcParent, err := d.apihubClient.GetPackageById(ctx, srcParentId)
-					if err != nil {
-						errMap.Store(fmt.Sprintf("failed to get apihub package by id: %v", err.Error()), nil)
-						return
-					}
-					if srcParent == nil {
-						errMap.Store(fmt.Sprintf("unable to copy parents structure for '%v' package: parent package '%v' doesn't exist", srcPackage, srcParentId), nil)
-						return
-					}
-					mutex.Lock()
-					if _, exists := seenPackages[dstParentId]; !exists {
-						newPackages = append(newPackages, view.PackageCreateRequest{
-							ParentId:              getParentPackageId(dstParentId),
-							Kind:                  string(view.KindGroup),
-							Name:                  srcParent.Name,
-							Alias:                 srcParent.Alias,
-							Description:           srcParent.Description,
-							ImageUrl:              srcParent.ImageUrl,
-							DefaultRole:           defaultRole,
-							ReleaseVersionPattern: srcParent.ReleaseVersionPattern,
-						})
-						seenPackages[dstParentId] = struct{}{}
-					}
-					mutex.Unlock()
-					continue
-				}
-				if dstParent.Kind != string(view.KindGroup) {
-					errMap.Store(fmt.Sprintf("unable to copy service packages from '%v' to '%v' workspace: package '%v' has invalid package type", srcWorkspaceId, dstWorkspaceId, dstParentId), nil)
-					return
-				}
-				mutex.Lock()
-				seenPackages[dstParentId] = struct{}{}
-				mutex.Unlock()
-			}
-			mutex.Lock()
-			newPackages = append(newPackages, view.PackageCreateRequest{
-				ParentId:              getParentPackageId(newPackageId),
-				Kind:                  srcPackage.Kind,
-				Name:                  srcPackage.Name,
-				Alias:                 srcPackage.Alias,
-				Description:           srcPackage.Description,
-				ImageUrl:              srcPackage.ImageUrl,
-				DefaultRole:           defaultRole,
-				ReleaseVersionPattern: srcPackage.ReleaseVersionPattern,
-				ServiceName:           srcPackage.ServiceName,
-			})
-			mutex.Unlock()
-		})
-	}
-	wg.Wait()
-
-	errList = make([]string, 0)
-	errMap.Range(func(key, value interface{}) bool {
-		errList = append(errList, key.(string))
-		return false
-	})
-	if len(errList) > 0 {
-		return fmt.Errorf("failed to calculate service packages to copy: %v", strings.Join(errList, ", "))
-	}
-
-	for _, newPackage := range newPackages {
-		if newPackage.Kind != string(view.KindGroup) {
-			continue
-		}
-		_, err := d.apihubClient.CreatePackage(ctx, newPackage)
-		if err != nil {
-			return fmt.Errorf("unable to copy service packages from '%v' to '%v' workspace: failed to create '%s.%s' group: %v", srcWorkspaceId, dstWorkspaceId, newPackage.ParentId, newPackage.Alias, err.Error())
-		}
-	}
-	wg = sync.WaitGroup{}
-	errMap = sync.Map{}
-	for _, pkg := range newPackages {
-		if pkg.Kind == string(view.KindGroup) {
-			continue
-		}
-		wg.Add(1)
-		newPackage := pkg
-		utils.SafeAsync(func() {
-			defer wg.Done()
-			_, err := d.apihubClient.CreatePackage(ctx, newPackage)
-			if err != nil {
-				errMap.Store(fmt.Sprintf("unable to copy service packages from '%v' to '%v' workspace: failed to create '%s.%s' package: %v", srcWorkspaceId, dstWorkspaceId, newPackage.ParentId, newPackage.Alias, err.Error()), nil)
-				return
-			}
-		})
+	err = d.agentService.StartDiscovery(ctx, *agent, namespace, workspaceId, failOnError)
+	if err != nil {
+		return err
 	}
-	wg.Wait()
-
-	errList = make([]string, 0)
-	errMap.Range(func(key, value interface{}) bool {
-		errList = append(errList, key.(string))
-		return false
-	})
-	if len(errList) > 0 {
-		return fmt.Errorf("failed to copy service packages: %v", strings.Join(errList, ", "))
+	err = d.discoveryHistoryService.StartDiscovery(agentId, namespace, workspaceId, secctx.GetUserId(ctx))
+	if err != nil {
+		log.Errorf("Failed to store discovery start for agent %s, namespace %s, workspace %s: %s", agentId, namespace, workspaceId, err.Error())
 	}
 	return nil
 }
 
-func getParentPackageId(packageId string) string {
-	parts := strings.Split(packageId, ".")
-	return strings.Join(parts[:len(parts)-1], ".")
-}
-
-func getOrderedParentPackageIds(packageId string) []string {
-	parts := strings.Split(packageId, ".")
-	parentIds := make([]string, 0)
-	for i, part := range parts {
-		if i == 0 {
-			parentIds = append(parentIds, part)
-			continue
-		}
-		if i == (len(parts) - 1) {
-			break
+// syncWorkspaceStructure updates metadata of the discovered service packages copied from the default workspace.
+// Sync errors don't fail the discovery
+func (d discoveryServiceImpl) syncWorkspaceStructure(ctx context.Context, workspaceId string, serviceNames []string) {
+	report, err := d.workspaceCopyService.SyncWorkspaceStructure(ctx, workspaceId, view.WorkspaceSyncReq{SrcWorkspaceId: d.defaultWorkspaceId, ServiceNames: serviceNames})
+	if err != nil {
+		log.Warnf("Failed to sync packages metadata from '%v' to '%v' workspace: %s", d.defaultWorkspaceId, workspaceId, err.Error())
+		return
+	}
+	for _, drift := range report.Packages {
+		if drift.Error != "" {
+			log.Warnf("Failed to sync package '%v' metadata from '%v' workspace: %s", drift.PackageId, d.defaultWorkspaceId, drift.Error)
 		}
-		parentIds = append(parentIds, parentIds[i-1]+"."+part)
 	}
-	return parentIds
 }
 
 func (d discoveryServiceImpl) GetDiscoveredServices_deprecated(ctx context.Context, agentId string, namespace string, workspaceId string) (*view.ServiceListResponse_deprecated, error) {
@@ -351,7 +160,7 @@ func (d discoveryServiceImpl) GetDiscoveredServices_deprecated(ctx context.Conte
 			Params:  map[string]interface{}{"id": agentId}}
 	}
 
-	serviceList, err := d.agentClient.ListServices_deprecated(ctx, namespace, workspaceId, agent.AgentUrl)
+	serviceList, err := d.agentService.ListServices_deprecated(ctx, *agent, namespace, workspaceId)
 	if err != nil {
 		return nil, fmt.Errorf("agent failed to list services: %v", err.Error())
 	}
@@ -383,11 +192,19 @@ func (d discoveryServiceImpl) GetDiscoveredServices(ctx context.Context, agentId
 			Params:  map[string]interface{}{"id": agentId}}
 	}
 
-	serviceList, err := d.agentClient.ListServices(ctx, namespace, workspaceId, agent.AgentUrl)
+	serviceList, err := d.agentService.ListServices(ctx, *agent, namespace, workspaceId)
 	if err != nil {
 		return nil, fmt.Errorf("agent failed to list services: %v", err.Error())
 	}
+	_, err = d.discoveryHistoryService.FinishDiscovery(*agent, namespace, workspaceId, serviceList)
+	if err != nil {
+		log.Errorf("Failed to finish discovery for agent %s, namespace %s, workspace %s: %s", agentId, namespace, workspaceId, err.Error())
+	}
 	if serviceList != nil && len(serviceList.Services) > 0 {
+		err = d.baselineMappingService.ResolveBaselines(ctx, workspaceId, serviceList.Services)
+		if err != nil {
+			log.Errorf("Failed to resolve baselines for agent %s, namespace %s, workspace %s: %s", agentId, namespace, workspaceId, err.Error())
+		}
 		err = d.permissionService.SetPermissionsForServices(ctx, serviceList.Services)
 		if err != nil {
 			return nil, fmt.Errorf("failed to set permissions for services: %v", err.Error())
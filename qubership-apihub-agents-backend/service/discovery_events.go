@@ -0,0 +1,267 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Netcracker/qubership-apihub-agents-backend/exception"
+	"github.com/Netcracker/qubership-apihub-agents-backend/secctx"
+	"github.com/Netcracker/qubership-apihub-agents-backend/utils"
+	"github.com/Netcracker/qubership-apihub-agents-backend/view"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	discoveryPollInterval       = 2 * time.Second
+	discoveryWatchTimeout       = 10 * time.Minute
+	discoveryPollMaxFailures    = 3
+	discoverySubscriberCapacity = 1000
+)
+
+// DiscoveryEventsService polls the agent for the discovery status once per agent, namespace and workspace
+// and fans out status and per-service progress changes to all subscribers
+type DiscoveryEventsService interface {
+	// Subscribe returns the channel with discovery events and the function to unsubscribe.
+	// The channel is closed when the discovery is finished
+	Subscribe(agentId string, namespace string, workspaceId string) (<-chan view.DiscoveryEvent, func(), error)
+	// WaitForDiscoveryResult blocks until the discovery is finished and returns its result
+	WaitForDiscoveryResult(ctx context.Context, agent view.AgentInstance, namespace string, workspaceId string) (*view.ServiceListResponse, error)
+}
+
+func NewDiscoveryEventsService(agentService AgentService, discoveryHistoryService DiscoveryHistoryService) DiscoveryEventsService {
+	return &discoveryEventsServiceImpl{
+		agentService:            agentService,
+		discoveryHistoryService: discoveryHistoryService,
+		watchers:                make(map[string]*discoveryWatcher),
+	}
+}
+
+type discoveryEventsServiceImpl struct {
+	agentService            AgentService
+	discoveryHistoryService DiscoveryHistoryService
+	watchers                map[string]*discoveryWatcher
+	mutex                   sync.Mutex
+}
+
+type discoveryWatcher struct {
+	agent       view.AgentInstance
+	namespace   string
+	workspaceId string
+
+	mutex       sync.Mutex
+	subscribers map[chan view.DiscoveryEvent]struct{}
+	status      *view.DiscoveryStatusEvent
+	services    map[string]view.DiscoveryServiceEvent
+	result      *view.ServiceListResponse
+	err         error
+}
+
+func (d *discoveryEventsServiceImpl) Subscribe(agentId string, namespace string, workspaceId string) (<-chan view.DiscoveryEvent, func(), error) {
+	agent, err := d.agentService.GetAgent(agentId)
+	if err != nil {
+		return nil, nil, err
+	}
+	if agent == nil {
+		return nil, nil, &exception.CustomError{
+			Status:  http.StatusNotFound,
+			Code:    exception.AgentNotFound,
+			Message: exception.AgentNotFoundMsg,
+			Params:  map[string]interface{}{"agentId": agentId},
+		}
+	}
+	if agent.Status != view.AgentStatusActive {
+		return nil, nil, &exception.CustomError{
+			Status:  http.StatusFailedDependency,
+			Code:    exception.InactiveAgent,
+			Message: exception.InactiveAgentMsg,
+			Params:  map[string]interface{}{"agentId": agentId},
+		}
+	}
+	_, events, unsubscribe := d.subscribe(*agent, namespace, workspaceId)
+	return events, unsubscribe, nil
+}
+
+func (d *discoveryEventsServiceImpl) WaitForDiscoveryResult(ctx context.Context, agent view.AgentInstance, namespace string, workspaceId string) (*view.ServiceListResponse, error) {
+	watcher, events, unsubscribe := d.subscribe(agent, namespace, workspaceId)
+	defer unsubscribe()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("deadline exceeded for services discovery")
+		case _, ok := <-events:
+			if ok {
+				continue
+			}
+			watcher.mutex.Lock()
+			result, err := watcher.result, watcher.err
+			watcher.mutex.Unlock()
+			if err != nil {
+				return nil, err
+			}
+			if result == nil {
+				// subscriber was dropped before the discovery is finished
+				return nil, fmt.Errorf("failed to get service list: discovery status is not available")
+			}
+			if result.Status == view.StatusError {
+				return nil, fmt.Errorf("service discovery failed: %v", result.Debug)
+			}
+			return result, nil
+		}
+	}
+}
+
+func (d *discoveryEventsServiceImpl) subscribe(agent view.AgentInstance, namespace string, workspaceId string) (*discoveryWatcher, <-chan view.DiscoveryEvent, func()) {
+	key := fmt.Sprintf("%s|%s|%s", agent.AgentId, namespace, workspaceId)
+	d.mutex.Lock()
+	watcher, exists := d.watchers[key]
+	if !exists {
+		watcher = &discoveryWatcher{
+			agent:       agent,
+			namespace:   namespace,
+			workspaceId: workspaceId,
+			subscribers: make(map[chan view.DiscoveryEvent]struct{}),
+			services:    make(map[string]view.DiscoveryServiceEvent),
+		}
+		d.watchers[key] = watcher
+		utils.SafeAsync(func() {
+			d.watch(key, watcher)
+		})
+	}
+	events := make(chan view.DiscoveryEvent, discoverySubscriberCapacity)
+	watcher.mutex.Lock()
+	// new subscriber receives the current state first
+	if watcher.status != nil {
+		events <- view.DiscoveryEvent{Type: view.DiscoveryEventStatus, Status: watcher.status}
+	}
+	for _, service := range watcher.services {
+		svc := service
+		if len(events) < cap(events)-1 {
+			events <- view.DiscoveryEvent{Type: view.DiscoveryEventService, Service: &svc}
+		}
+	}
+	watcher.subscribers[events] = struct{}{}
+	watcher.mutex.Unlock()
+	d.mutex.Unlock()
+
+	unsubscribe := func() {
+		watcher.mutex.Lock()
+		defer watcher.mutex.Unlock()
+		if _, exists := watcher.subscribers[events]; exists {
+			delete(watcher.subscribers, events)
+			close(events)
+		}
+	}
+	return watcher, events, unsubscribe
+}
+
+func (d *discoveryEventsServiceImpl) watch(key string, watcher *discoveryWatcher) {
+	ctx := secctx.MakeSysadminContext(context.Background())
+	start := time.Now()
+	failures := 0
+	for {
+		serviceList, err := d.agentService.ListServices(ctx, watcher.agent, watcher.namespace, watcher.workspaceId)
+		if err == nil && serviceList == nil {
+			err = fmt.Errorf("unexpected agent response")
+		}
+		if err != nil {
+			failures++
+			log.Warnf("Failed to get discovery status for agent %s, namespace %s, workspace %s: %s", watcher.agent.AgentId, watcher.namespace, watcher.workspaceId, err.Error())
+			if failures >= discoveryPollMaxFailures {
+				d.finish(key, watcher, nil, fmt.Errorf("failed to get service list: %v", err.Error()))
+				return
+			}
+		} else {
+			failures = 0
+			watcher.publish(serviceList)
+			if serviceList.Status == view.StatusComplete || serviceList.Status == view.StatusError {
+				_, err = d.discoveryHistoryService.FinishDiscovery(watcher.agent, watcher.namespace, watcher.workspaceId, serviceList)
+				if err != nil {
+					log.Errorf("Failed to finish discovery for agent %s, namespace %s, workspace %s: %s", watcher.agent.AgentId, watcher.namespace, watcher.workspaceId, err.Error())
+				}
+				d.finish(key, watcher, serviceList, nil)
+				return
+			}
+		}
+		if !watcher.hasSubscribers() {
+			d.finish(key, watcher, nil, nil)
+			return
+		}
+		if time.Since(start) > discoveryWatchTimeout {
+			d.finish(key, watcher, nil, fmt.Errorf("deadline exceeded for services discovery"))
+			return
+		}
+		time.Sleep(discoveryPollInterval)
+	}
+}
+
+// finish stops fan out for the watcher, all subscriber channels get closed
+func (d *discoveryEventsServiceImpl) finish(key string, watcher *discoveryWatcher, result *view.ServiceListResponse, err error) {
+	d.mutex.Lock()
+	delete(d.watchers, key)
+	d.mutex.Unlock()
+
+	watcher.mutex.Lock()
+	defer watcher.mutex.Unlock()
+	watcher.result = result
+	watcher.err = err
+	if err != nil {
+		watcher.broadcast(view.DiscoveryEvent{
+			Type:   view.DiscoveryEventStatus,
+			Status: &view.DiscoveryStatusEvent{Status: view.StatusError, Debug: err.Error(), ServicesCount: len(watcher.services)},
+		})
+	}
+	for events := range watcher.subscribers {
+		close(events)
+	}
+	watcher.subscribers = make(map[chan view.DiscoveryEvent]struct{})
+}
+
+func (w *discoveryWatcher) hasSubscribers() bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return len(w.subscribers) > 0
+}
+
+// publish sends only changes compared to the previously published state
+func (w *discoveryWatcher) publish(serviceList *view.ServiceListResponse) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	for _, service := range serviceList.Services {
+		serviceEvent := view.DiscoveryServiceEvent{
+			Id:             service.Id,
+			Name:           service.Name,
+			DocumentsCount: len(service.Documents),
+			Error:          service.Error,
+		}
+		if previous, exists := w.services[service.Id]; exists && previous == serviceEvent {
+			continue
+		}
+		w.services[service.Id] = serviceEvent
+		w.broadcast(view.DiscoveryEvent{Type: view.DiscoveryEventService, Service: &serviceEvent})
+	}
+	statusEvent := view.DiscoveryStatusEvent{
+		Status:        serviceList.Status,
+		Debug:         serviceList.Debug,
+		ServicesCount: len(serviceList.Services),
+	}
+	if w.status == nil || *w.status != statusEvent {
+		w.status = &statusEvent
+		w.broadcast(view.DiscoveryEvent{Type: view.DiscoveryEventStatus, Status: &statusEvent})
+	}
+}
+
+// broadcast must be called under the watcher lock. Subscribers which do not read events fast enough are dropped
+func (w *discoveryWatcher) broadcast(event view.DiscoveryEvent) {
+	for events := range w.subscribers {
+		select {
+		case events <- event:
+		default:
+			log.Warnf("Discovery events subscriber for agent %s, namespace %s, workspace %s is too slow, dropping it", w.agent.AgentId, w.namespace, w.workspaceId)
+			delete(w.subscribers, events)
+			close(events)
+		}
+	}
+}
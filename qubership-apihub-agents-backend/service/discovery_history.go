@@ -0,0 +1,306 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Netcracker/qubership-apihub-agents-backend/client"
+	"github.com/Netcracker/qubership-apihub-agents-backend/entity"
+	"github.com/Netcracker/qubership-apihub-agents-backend/exception"
+	"github.com/Netcracker/qubership-apihub-agents-backend/repository"
+	"github.com/Netcracker/qubership-apihub-agents-backend/secctx"
+	"github.com/Netcracker/qubership-apihub-agents-backend/utils"
+	"github.com/Netcracker/qubership-apihub-agents-backend/view"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+)
+
+const discoveryChecksumConcurrency = 10
+
+type DiscoveryHistoryService interface {
+	// StartDiscovery stores the running namespace discovery, so its result is stored in the history when the discovery is finished
+	StartDiscovery(agentId string, namespace string, workspaceId string, startedBy string) error
+	// FinishDiscovery marks the running namespace discovery as finished and stores the discovery result in the history
+	// if the agent reports that the discovery is completed. Document checksums are added to the history asynchronously
+	FinishDiscovery(agent view.AgentInstance, namespace string, workspaceId string, serviceList *view.ServiceListResponse) (bool, error)
+	ListDiscoveries(ctx context.Context, agentId string, namespace string, workspaceId string, limit int, page int) (*view.DiscoveryHistoryItems, error)
+	GetDiscovery(ctx context.Context, agentId string, namespace string, workspaceId string, discoveryId string) (*view.DiscoveryHistory, error)
+	CompareDiscoveries(ctx context.Context, agentId string, namespace string, workspaceId string, fromDiscoveryId string, toDiscoveryId string) (*view.DiscoveriesDiff, error)
+}
+
+func NewDiscoveryHistoryService(apihubClient client.ApihubClient, systemInfoService SystemInfoService, agentService AgentService, permissionService PermissionService, discoveryRepository repository.DiscoveryRepository) DiscoveryHistoryService {
+	return &discoveryHistoryServiceImpl{
+		apihubClient:        apihubClient,
+		systemInfoService:   systemInfoService,
+		agentService:        agentService,
+		permissionService:   permissionService,
+		discoveryRepository: discoveryRepository,
+	}
+}
+
+type discoveryHistoryServiceImpl struct {
+	apihubClient        client.ApihubClient
+	systemInfoService   SystemInfoService
+	agentService        AgentService
+	permissionService   PermissionService
+	discoveryRepository repository.DiscoveryRepository
+}
+
+func (d discoveryHistoryServiceImpl) StartDiscovery(agentId string, namespace string, workspaceId string, startedBy string) error {
+	return d.discoveryRepository.SaveNamespaceDiscovery(&entity.NamespaceDiscoveryEntity{
+		AgentId:     agentId,
+		Namespace:   namespace,
+		WorkspaceId: workspaceId,
+		Status:      string(view.StatusRunning),
+		StartedAt:   time.Now(),
+		StartedBy:   startedBy,
+	})
+}
+
+func (d discoveryHistoryServiceImpl) FinishDiscovery(agent view.AgentInstance, namespace string, workspaceId string, serviceList *view.ServiceListResponse) (bool, error) {
+	if serviceList == nil || (serviceList.Status != view.StatusComplete && serviceList.Status != view.StatusError) {
+		return false, nil
+	}
+	discoveryEnt, err := d.discoveryRepository.GetNamespaceDiscovery(agent.AgentId, namespace, workspaceId)
+	if err != nil {
+		return false, fmt.Errorf("failed to get discovery status: %v", err.Error())
+	}
+	if discoveryEnt == nil || discoveryEnt.Status != string(view.StatusRunning) {
+		// discovery was not started via backend or its result is already stored
+		return false, nil
+	}
+	historyEnt := entity.DiscoveryHistoryEntity{
+		DiscoveryId:   uuid.NewString(),
+		AgentId:       agent.AgentId,
+		CloudName:     agent.AgentDeploymentCloud,
+		Namespace:     namespace,
+		WorkspaceId:   workspaceId,
+		Status:        string(serviceList.Status),
+		Details:       serviceList.Debug,
+		FinishedAt:    time.Now(),
+		ServicesCount: len(serviceList.Services),
+		Services:      view.MakeDiscoveredServices(serviceList.Services, nil),
+	}
+	// the discovery is claimed before documents are downloaded, so the result is stored once by a single caller
+	finished, err := d.discoveryRepository.FinishNamespaceDiscovery(&historyEnt, d.systemInfoService.GetDiscoveryHistoryKeepLast())
+	if err != nil {
+		return false, fmt.Errorf("failed to store discovery result: %v", err.Error())
+	}
+	if !finished {
+		return false, nil
+	}
+	services := serviceList.Services
+	utils.SafeAsync(func() {
+		// documents stay without checksums if the backend is restarted before they are calculated
+		checksums := d.calculateDocumentChecksums(agent, namespace, workspaceId, services)
+		err := d.discoveryRepository.UpdateDiscoveryHistoryServices(historyEnt.DiscoveryId, view.MakeDiscoveredServices(services, checksums))
+		if err != nil {
+			log.Errorf("Failed to store document checksums of discovery %s: %s", historyEnt.DiscoveryId, err.Error())
+		}
+	})
+	return true, nil
+}
+
+// calculateDocumentChecksums downloads discovered documents from the agent and returns map[serviceId]map[fileId]checksum.
+// Documents which failed to download have no checksum
+func (d discoveryHistoryServiceImpl) calculateDocumentChecksums(agent view.AgentInstance, namespace string, workspaceId string, services []view.Service) map[string]map[string]string {
+	ctx := secctx.MakeSysadminContext(context.Background())
+	result := make(map[string]map[string]string)
+	mutex := sync.Mutex{}
+	errGrp := errgroup.Group{}
+	errGrp.SetLimit(discoveryChecksumConcurrency)
+	for _, service := range services {
+		for _, document := range service.Documents {
+			serviceId, fileId := service.Id, document.FileId
+			errGrp.Go(func() error {
+				data, err := d.agentService.GetServiceSpecification(ctx, agent, namespace, workspaceId, serviceId, fileId)
+				if err != nil {
+					log.Warnf("Failed to get document %s of service %s for discovery history: %s", fileId, serviceId, err.Error())
+					return nil
+				}
+				if data == nil {
+					return nil
+				}
+				checksum := utils.GetEncodedChecksum(data)
+				mutex.Lock()
+				if result[serviceId] == nil {
+					result[serviceId] = make(map[string]string)
+				}
+				result[serviceId][fileId] = checksum
+				mutex.Unlock()
+				return nil
+			})
+		}
+	}
+	_ = errGrp.Wait()
+	return result
+}
+
+func (d discoveryHistoryServiceImpl) ListDiscoveries(ctx context.Context, agentId string, namespace string, workspaceId string, limit int, page int) (*view.DiscoveryHistoryItems, error) {
+	err := checkWorkspacePermission(ctx, d.apihubClient, d.permissionService, workspaceId, view.ReadPermission)
+	if err != nil {
+		return nil, err
+	}
+	ents, err := d.discoveryRepository.ListDiscoveryHistory(agentId, namespace, workspaceId, limit, page)
+	if err != nil {
+		return nil, err
+	}
+	result := view.DiscoveryHistoryItems{Discoveries: make([]view.DiscoveryHistoryItem, 0, len(ents))}
+	for _, ent := range ents {
+		result.Discoveries = append(result.Discoveries, entity.MakeDiscoveryHistoryItemView(ent))
+	}
+	return &result, nil
+}
+
+func (d discoveryHistoryServiceImpl) GetDiscovery(ctx context.Context, agentId string, namespace string, workspaceId string, discoveryId string) (*view.DiscoveryHistory, error) {
+	err := checkWorkspacePermission(ctx, d.apihubClient, d.permissionService, workspaceId, view.ReadPermission)
+	if err != nil {
+		return nil, err
+	}
+	ent, err := d.getDiscoveryHistoryEntity(agentId, namespace, workspaceId, discoveryId)
+	if err != nil {
+		return nil, err
+	}
+	result := entity.MakeDiscoveryHistoryView(*ent)
+	return &result, nil
+}
+
+func (d discoveryHistoryServiceImpl) CompareDiscoveries(ctx context.Context, agentId string, namespace string, workspaceId string, fromDiscoveryId string, toDiscoveryId string) (*view.DiscoveriesDiff, error) {
+	err := checkWorkspacePermission(ctx, d.apihubClient, d.permissionService, workspaceId, view.ReadPermission)
+	if err != nil {
+		return nil, err
+	}
+	fromEnt, err := d.getDiscoveryHistoryEntity(agentId, namespace, workspaceId, fromDiscoveryId)
+	if err != nil {
+		return nil, err
+	}
+	toEnt, err := d.getDiscoveryHistoryEntity(agentId, namespace, workspaceId, toDiscoveryId)
+	if err != nil {
+		return nil, err
+	}
+	result := makeDiscoveriesDiff(entity.MakeDiscoveryHistoryView(*fromEnt), entity.MakeDiscoveryHistoryView(*toEnt))
+	return &result, nil
+}
+
+func (d discoveryHistoryServiceImpl) getDiscoveryHistoryEntity(agentId string, namespace string, workspaceId string, discoveryId string) (*entity.DiscoveryHistoryEntity, error) {
+	ent, err := d.discoveryRepository.GetDiscoveryHistory(discoveryId)
+	if err != nil {
+		return nil, err
+	}
+	if ent == nil || ent.AgentId != agentId || ent.Namespace != namespace || ent.WorkspaceId != workspaceId {
+		return nil, &exception.CustomError{
+			Status:  http.StatusNotFound,
+			Code:    exception.DiscoveryNotFound,
+			Message: exception.DiscoveryNotFoundMsg,
+			Params:  map[string]interface{}{"discoveryId": discoveryId},
+		}
+	}
+	return ent, nil
+}
+
+func makeDiscoveriesDiff(from view.DiscoveryHistory, to view.DiscoveryHistory) view.DiscoveriesDiff {
+	result := view.DiscoveriesDiff{
+		From:            from.DiscoveryHistoryItem,
+		To:              to.DiscoveryHistoryItem,
+		AddedServices:   make([]view.DiscoveredService, 0),
+		RemovedServices: make([]view.DiscoveredService, 0),
+		ChangedServices: make([]view.ServiceDiff, 0),
+	}
+	fromServices := make(map[string]view.DiscoveredService, len(from.Services))
+	for _, service := range from.Services {
+		fromServices[service.Id] = service
+	}
+	toServices := make(map[string]view.DiscoveredService, len(to.Services))
+	for _, service := range to.Services {
+		toServices[service.Id] = service
+		fromService, exists := fromServices[service.Id]
+		if !exists {
+			result.AddedServices = append(result.AddedServices, service)
+			continue
+		}
+		if serviceDiff := makeServiceDiff(fromService, service); serviceDiff != nil {
+			result.ChangedServices = append(result.ChangedServices, *serviceDiff)
+		}
+	}
+	for _, service := range from.Services {
+		if _, exists := toServices[service.Id]; !exists {
+			result.RemovedServices = append(result.RemovedServices, service)
+		}
+	}
+	return result
+}
+
+func makeServiceDiff(from view.DiscoveredService, to view.DiscoveredService) *view.ServiceDiff {
+	result := view.ServiceDiff{
+		ServiceId:   to.Id,
+		ServiceName: to.Name,
+	}
+	changed := false
+
+	fromDocuments := make(map[string]view.DiscoveredDocument, len(from.Documents))
+	for _, document := range from.Documents {
+		fromDocuments[document.FileId] = document
+	}
+	toDocuments := make(map[string]view.DiscoveredDocument, len(to.Documents))
+	for _, document := range to.Documents {
+		toDocuments[document.FileId] = document
+		fromDocument, exists := fromDocuments[document.FileId]
+		if !exists {
+			result.AddedDocuments = append(result.AddedDocuments, document)
+			changed = true
+			continue
+		}
+		// documents without checksum cannot be compared
+		if fromDocument.Checksum != "" && document.Checksum != "" && fromDocument.Checksum != document.Checksum {
+			result.ChangedDocuments = append(result.ChangedDocuments, view.DocumentDiff{
+				FileId:           document.FileId,
+				Name:             document.Name,
+				PreviousChecksum: fromDocument.Checksum,
+				CurrentChecksum:  document.Checksum,
+			})
+			changed = true
+		}
+	}
+	for _, document := range from.Documents {
+		if _, exists := toDocuments[document.FileId]; !exists {
+			result.RemovedDocuments = append(result.RemovedDocuments, document)
+			changed = true
+		}
+	}
+
+	for name, value := range to.Labels {
+		previousValue, exists := from.Labels[name]
+		if !exists {
+			result.LabelChanges = append(result.LabelChanges, view.LabelDiff{Name: name, Action: view.DiffActionAdded, CurrentValue: value})
+		} else if previousValue != value {
+			result.LabelChanges = append(result.LabelChanges, view.LabelDiff{Name: name, Action: view.DiffActionChanged, PreviousValue: previousValue, CurrentValue: value})
+		}
+	}
+	for name, value := range from.Labels {
+		if _, exists := to.Labels[name]; !exists {
+			result.LabelChanges = append(result.LabelChanges, view.LabelDiff{Name: name, Action: view.DiffActionRemoved, PreviousValue: value})
+		}
+	}
+	if len(result.LabelChanges) > 0 {
+		sort.Slice(result.LabelChanges, func(i, j int) bool {
+			return result.LabelChanges[i].Name < result.LabelChanges[j].Name
+		})
+		changed = true
+	}
+
+	if from.Error != to.Error {
+		result.PreviousError = from.Error
+		result.CurrentError = to.Error
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+	return &result
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Netcracker/qubership-apihub-agents-backend/repository"
+	"github.com/Netcracker/qubership-apihub-agents-backend/utils"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+const jobLockLease = 2 * time.Minute
+const jobLockRenewInterval = 30 * time.Second
+
+// jobLockMinHold keeps the lock after a short run, so replicas whose scheduler fires a bit later due to clock skew skip the same run
+const jobLockMinHold = time.Minute
+
+// JobLockService makes sure that a scheduled job is run by only one backend replica at a time
+type JobLockService interface {
+	// AcquireJobLock takes the job lock and prolongs its lease in background until the returned release function is called.
+	// The returned context is cancelled if the lease is lost, so the job must stop.
+	// Nil context is returned if the job is locked by another replica or by the previous run which has finished less than a minute ago
+	AcquireJobLock(ctx context.Context, jobName string) (context.Context, func(), error)
+	// AcquireLock takes the lock the same way as AcquireJobLock, but the lock is free right after it is released.
+	// It is used by request operations which must not run concurrently on different replicas
+	AcquireLock(ctx context.Context, lockName string) (context.Context, func(), error)
+}
+
+func NewJobLockService(jobLockRepository repository.JobLockRepository) JobLockService {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return &jobLockServiceImpl{
+		jobLockRepository: jobLockRepository,
+		instanceId:        fmt.Sprintf("%s-%s", hostname, uuid.New().String()),
+	}
+}
+
+type jobLockServiceImpl struct {
+	jobLockRepository repository.JobLockRepository
+	instanceId        string
+}
+
+func (j jobLockServiceImpl) AcquireJobLock(ctx context.Context, jobName string) (context.Context, func(), error) {
+	return j.acquireLock(ctx, jobName, jobLockMinHold)
+}
+
+func (j jobLockServiceImpl) AcquireLock(ctx context.Context, lockName string) (context.Context, func(), error) {
+	return j.acquireLock(ctx, lockName, 0)
+}
+
+func (j jobLockServiceImpl) acquireLock(ctx context.Context, jobName string, minHold time.Duration) (context.Context, func(), error) {
+	acquired, err := j.jobLockRepository.TryAcquireJobLock(jobName, j.instanceId, jobLockLease)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to acquire lock of job %s: %v", jobName, err.Error())
+	}
+	if !acquired {
+		return nil, nil, nil
+	}
+	log.Debugf("Lock of job %s is acquired by instance %s", jobName, j.instanceId)
+
+	lockCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	utils.SafeAsync(func() {
+		ticker := time.NewTicker(jobLockRenewInterval)
+		defer ticker.Stop()
+		extendedAt := time.Now()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				extended, err := j.jobLockRepository.ExtendJobLock(jobName, j.instanceId, jobLockLease)
+				if err != nil {
+					log.Errorf("Failed to extend lock of job %s: %s", jobName, err.Error())
+					if time.Since(extendedAt) < jobLockLease {
+						continue
+					}
+					extended = false
+				}
+				if !extended {
+					log.Warnf("Lock of job %s is lost by instance %s, the job is being cancelled", jobName, j.instanceId)
+					cancel()
+					return
+				}
+				extendedAt = time.Now()
+			}
+		}
+	})
+	releaseOnce := sync.Once{}
+	release := func() {
+		releaseOnce.Do(func() {
+			close(done)
+			cancel()
+			err := j.jobLockRepository.ReleaseJobLock(jobName, j.instanceId, minHold)
+			if err != nil {
+				log.Errorf("Failed to release lock of job %s, it is released when the lease expires: %s", jobName, err.Error())
+			}
+		})
+	}
+	return lockCtx, release, nil
+}
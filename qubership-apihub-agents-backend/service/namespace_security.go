@@ -25,28 +25,33 @@ type NamespaceSecurityService interface {
 }
 
 func NewNamespaceSecurityService(agentClient client.AgentClient, apihubClient client.ApihubClient, namespaceSecurityRepo repository.NamespaceSecurityRepository,
-	agentService AgentService, snapshotService SnapshotService, apiKeyService ApiKeyService, userService UserService, systemInfoService SystemInfoService) NamespaceSecurityService {
+	agentService AgentService, snapshotService SnapshotService, apiKeyService ApiKeyService, userService UserService, systemInfoService SystemInfoService,
+	discoveryEventsService DiscoveryEventsService, discoveryHistoryService DiscoveryHistoryService) NamespaceSecurityService {
 	return &namespaceSecurityServiceImpl{
-		agentClient:           agentClient,
-		apihubClient:          apihubClient,
-		namespaceSecurityRepo: namespaceSecurityRepo,
-		agentService:          agentService,
-		snapshotService:       snapshotService,
-		apiKeyService:         apiKeyService,
-		userService:           userService,
-		systemInfoService:     systemInfoService,
+		agentClient:             agentClient,
+		apihubClient:            apihubClient,
+		namespaceSecurityRepo:   namespaceSecurityRepo,
+		agentService:            agentService,
+		snapshotService:         snapshotService,
+		apiKeyService:           apiKeyService,
+		userService:             userService,
+		systemInfoService:       systemInfoService,
+		discoveryEventsService:  discoveryEventsService,
+		discoveryHistoryService: discoveryHistoryService,
 	}
 }
 
 type namespaceSecurityServiceImpl struct {
-	agentClient           client.AgentClient
-	apihubClient          client.ApihubClient
-	namespaceSecurityRepo repository.NamespaceSecurityRepository
-	agentService          AgentService
-	snapshotService       SnapshotService
-	apiKeyService         ApiKeyService
-	userService           UserService
-	systemInfoService     SystemInfoService
+	agentClient             client.AgentClient
+	apihubClient            client.ApihubClient
+	namespaceSecurityRepo   repository.NamespaceSecurityRepository
+	agentService            AgentService
+	snapshotService         SnapshotService
+	apiKeyService           ApiKeyService
+	userService             UserService
+	systemInfoService       SystemInfoService
+	discoveryEventsService  DiscoveryEventsService
+	discoveryHistoryService DiscoveryHistoryService
 }
 
 func (n *namespaceSecurityServiceImpl) StartAuthSecurityCheckProcess(ctx context.Context, req view.StartNamespaceSecurityCheckReq) (string, error) {
@@ -71,17 +76,10 @@ func (n *namespaceSecurityServiceImpl) StartAuthSecurityCheckProcess(ctx context
 		}
 	}
 
-	namespaces, err := n.agentClient.GetNamespaces(ctx, agent.AgentUrl)
+	namespaces, namespaceExists, err := n.agentService.NamespaceExists(ctx, *agent, req.Namespace)
 	if err != nil {
 		return "", fmt.Errorf("failed to list namespaces for agent (agentUrl = '%v')", req.AgentId)
 	}
-	namespaceExists := false
-	for _, agentNamespace := range namespaces.Namespaces {
-		if agentNamespace == req.Namespace {
-			namespaceExists = true
-			break
-		}
-	}
 	if !namespaceExists {
 		return "", &exception.CustomError{
 			Status:  http.StatusNotFound,
@@ -119,19 +117,23 @@ func (n *namespaceSecurityServiceImpl) StartAuthSecurityCheckProcess(ctx context
 		return "", fmt.Errorf("failed to store security check process entity: %v", err.Error())
 	}
 	utils.SafeAsync(func() {
-		n.startAuthSecurityCheck(namespaceSecurityCheckEntity, agent.AgentUrl)
+		n.startAuthSecurityCheck(namespaceSecurityCheckEntity, *agent)
 	})
 	return processId, nil
 }
 
-func (n *namespaceSecurityServiceImpl) startAuthSecurityCheck(securityCheck entity.NamespaceSecurityCheckEntity, agentUrl string) {
+func (n *namespaceSecurityServiceImpl) startAuthSecurityCheck(securityCheck entity.NamespaceSecurityCheckEntity, agent view.AgentInstance) {
 	systemCtx := secctx.MakeSysadminContext(context.Background())
-	err := n.agentClient.StartDiscovery(systemCtx, securityCheck.Namespace, securityCheck.WorkspaceId, agentUrl, false)
+	err := n.agentService.StartDiscovery(systemCtx, agent, securityCheck.Namespace, securityCheck.WorkspaceId, false)
 	if err != nil {
 		n.updateProcessStatus(&securityCheck, view.StatusError, fmt.Sprintf("failed to start service discovery: %v", err.Error()))
 		return
 	}
-	discoveryResult, err := n.getDiscoveryResults(systemCtx, securityCheck.Namespace, securityCheck.WorkspaceId, agentUrl)
+	err = n.discoveryHistoryService.StartDiscovery(agent.AgentId, securityCheck.Namespace, securityCheck.WorkspaceId, securityCheck.StartedBy)
+	if err != nil {
+		log.Errorf("Failed to store discovery start for agent %s, namespace %s, workspace %s: %s", agent.AgentId, securityCheck.Namespace, securityCheck.WorkspaceId, err.Error())
+	}
+	discoveryResult, err := n.getDiscoveryResults(systemCtx, securityCheck.Namespace, securityCheck.WorkspaceId, agent)
 	if err != nil {
 		n.updateProcessStatus(&securityCheck, view.StatusError, fmt.Sprintf("failed to get service discovery result: %v", err.Error()))
 		return
@@ -187,7 +189,7 @@ func (n *namespaceSecurityServiceImpl) startAuthSecurityCheck(securityCheck enti
 		Promote:       false,
 		VersionStatus: string(view.DraftStatus),
 		CloudName:     securityCheck.CloudName,
-		AgentUrl:      agentUrl,
+		Agent:         agent,
 	}
 	snapshot, err := n.snapshotService.CreateSnapshot(systemCtx, securityCheck.Namespace, securityCheck.WorkspaceId, authSecurityCheckVersionName, newSnapshot)
 	if err != nil {
@@ -276,7 +278,7 @@ func (n *namespaceSecurityServiceImpl) startAuthSecurityCheck(securityCheck enti
 					tasks <- view.EndpointsProcessTask{
 						ProcessId: securityCheck.ProcessId,
 						Namespace: securityCheck.Namespace,
-						AgentUrl:  agentUrl,
+						Agent:     agent,
 						ServiceId: svc.ServiceId,
 						PackageId: svc.PackageId,
 						Version:   version.Version,
@@ -309,29 +311,10 @@ func (n *namespaceSecurityServiceImpl) startAuthSecurityCheck(securityCheck enti
 	n.updateProcessStatus(&securityCheck, view.StatusComplete, "")
 }
 
-func (n *namespaceSecurityServiceImpl) getDiscoveryResults(ctx context.Context, namespace string, workspaceId string, agentUrl string) (*view.ServiceListResponse, error) {
-	start := time.Now()
-	var discoveryResult *view.ServiceListResponse
-	var err error
-	for {
-		discoveryResult, err = n.agentClient.ListServices(ctx, namespace, workspaceId, agentUrl)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get service list: %v", err.Error())
-		}
-		if discoveryResult == nil {
-			return nil, fmt.Errorf("failed to get service list: unexpected agent response")
-		}
-		if discoveryResult.Status == view.StatusError {
-			return nil, fmt.Errorf("service discovery failed: %v", discoveryResult.Debug)
-		}
-		if discoveryResult.Status == view.StatusComplete {
-			return discoveryResult, nil
-		}
-		if time.Since(start) > time.Minute*10 {
-			return nil, fmt.Errorf("deadline exceeded for services discovery")
-		}
-		time.Sleep(time.Second * 5)
-	}
+func (n *namespaceSecurityServiceImpl) getDiscoveryResults(ctx context.Context, namespace string, workspaceId string, agent view.AgentInstance) (*view.ServiceListResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Minute*10)
+	defer cancel()
+	return n.discoveryEventsService.WaitForDiscoveryResult(ctx, agent, namespace, workspaceId)
 }
 
 func (n *namespaceSecurityServiceImpl) updateProcessStatus(securityCheck *entity.NamespaceSecurityCheckEntity, status view.Status, details string) {
@@ -414,7 +397,11 @@ func (n *namespaceSecurityServiceImpl) processServiceEndpoints(tasks <-chan view
 			if len(restOperation.Security) > 0 {
 				operationSecurityCheckResult.ExpectedResponseCode = http.StatusUnauthorized
 			}
-			operationSecurityCheckResult.ActualResponseCode, err = n.agentClient.SendEmptyServiceRequest(task.Namespace, task.ServiceId, task.AgentUrl, restOperation.Method, restOperation.Path)
+			err = n.agentService.CallAgent(task.Agent, func(agentUrl string) error {
+				var err error
+				operationSecurityCheckResult.ActualResponseCode, err = n.agentClient.SendEmptyServiceRequest(task.Namespace, task.ServiceId, agentUrl, restOperation.Method, restOperation.Path)
+				return err
+			})
 			if err != nil {
 				operationSecurityCheckResult.Details = err.Error()
 			}
@@ -491,9 +478,11 @@ func (n *namespaceSecurityServiceImpl) GetAuthSecurityCheckStatus(processId stri
 	return &securityCheckStatusView, nil
 }
 
+const authSecurityCheckVersionPrefix = "auth_security_check_"
+
 func makeAuthSecurityCheckVersionName() string {
 	now := time.Now()
-	return fmt.Sprintf(`auth_security_check_%d.%d.%d`, now.Year(), now.Month(), now.Day())
+	return fmt.Sprintf(`%s%d.%d.%d`, authSecurityCheckVersionPrefix, now.Year(), now.Month(), now.Day())
 }
 
 func getRestOperationDetails(operation view.RestOperationView) view.RestOperationSecurity {
@@ -2,14 +2,21 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"slices"
 
 	"github.com/Netcracker/qubership-apihub-agents-backend/client"
+	"github.com/Netcracker/qubership-apihub-agents-backend/exception"
+	"github.com/Netcracker/qubership-apihub-agents-backend/secctx"
 	"github.com/Netcracker/qubership-apihub-agents-backend/view"
 )
 
 type PermissionService interface {
 	SetPermissionsForServices_deprecated(ctx context.Context, services []view.Service_deprecated) error
 	SetPermissionsForServices(ctx context.Context, services []view.Service) error
+	// HasPackagePermission checks that the user has the permission in the package, system administrator has all permissions
+	HasPackagePermission(ctx context.Context, packageId string, permission string) (bool, error)
 }
 
 func NewPermissionService(apihubClient client.ApihubClient) PermissionService {
@@ -85,3 +92,50 @@ func (p permissionServiceImpl) SetPermissionsForServices(ctx context.Context, se
 	}
 	return nil
 }
+
+func (p permissionServiceImpl) HasPackagePermission(ctx context.Context, packageId string, permission string) (bool, error) {
+	if secctx.IsSysadm(ctx) {
+		return true, nil
+	}
+	pkg, err := p.apihubClient.GetPackageById(ctx, packageId)
+	if err != nil {
+		return false, err
+	}
+	if pkg == nil {
+		return false, nil
+	}
+	return slices.Contains(pkg.UserPermissions, permission), nil
+}
+
+// checkWorkspaceManagePermission checks that the workspace exists and the user is allowed to change workspace settings
+func checkWorkspaceManagePermission(ctx context.Context, apihubClient client.ApihubClient, permissionService PermissionService, workspaceId string) error {
+	return checkWorkspacePermission(ctx, apihubClient, permissionService, workspaceId, view.CreateAndUpdatePackagePermission)
+}
+
+// checkWorkspacePermission checks that the workspace exists and the user has the permission in it
+func checkWorkspacePermission(ctx context.Context, apihubClient client.ApihubClient, permissionService PermissionService, workspaceId string, permission string) error {
+	workspace, err := apihubClient.GetPackageById(ctx, workspaceId)
+	if err != nil {
+		return fmt.Errorf("failed to get workspace by id: %v", err.Error())
+	}
+	if workspace == nil || workspace.Kind != string(view.KindWorkspace) {
+		return &exception.CustomError{
+			Status:  http.StatusNotFound,
+			Code:    exception.WorkspaceNotFound,
+			Message: exception.WorkspaceNotFoundMsg,
+			Params:  map[string]interface{}{"workspaceId": workspaceId},
+		}
+	}
+	sufficientPrivileges, err := permissionService.HasPackagePermission(ctx, workspaceId, permission)
+	if err != nil {
+		return fmt.Errorf("failed to check workspace permissions: %v", err.Error())
+	}
+	if !sufficientPrivileges {
+		return &exception.CustomError{
+			Status:  http.StatusForbidden,
+			Code:    exception.InsufficientPrivileges,
+			Message: exception.InsufficientPrivilegesMsg,
+		}
+	}
+	return nil
+}
@@ -2,13 +2,16 @@ package service
 
 import (
 	"archive/zip"
-	"bytes"
 	"context"
 	"fmt"
+	"io"
+	"maps"
 	"net/http"
 	"net/url"
+	"slices"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/Netcracker/qubership-apihub-agents-backend/client"
 	"github.com/Netcracker/qubership-apihub-agents-backend/exception"
@@ -16,51 +19,223 @@ import (
 	"github.com/Netcracker/qubership-apihub-agents-backend/utils"
 	"github.com/Netcracker/qubership-apihub-agents-backend/view"
 	"github.com/google/uuid"
+	"github.com/shaj13/libcache"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/sync/errgroup"
 )
 
+const snapshotCompareConcurrency = 10
+
+// snapshotPublishConcurrency limits the number of services processed at once, memory of publishing grows with it
+const snapshotPublishConcurrency = 10
+
+const snapshotListVersionsLimit = 100
+const snapshotSummaryCacheSize = 1000
+const snapshotSummaryTTL = time.Hour
+
 type SnapshotService interface {
 	CreateSnapshot(context context.Context, namespace string, workspaceId string, version string, snapshotDTO view.CreateSnapshotDTO) (*view.CreateSnapshotResponse, error)
-	ListSnapshots(context context.Context, namespace string, workspaceId string, page, limit int, cloudName string) (*view.SnapshotsListResponse, error)
+	// ValidateSnapshot runs the checks of snapshot creation without publishing
+	ValidateSnapshot(context context.Context, namespace string, workspaceId string, version string, snapshotDTO view.CreateSnapshotDTO) (*view.SnapshotValidation, error)
+	// ListSnapshots returns snapshots matching the request with summaries of the services changes
+	ListSnapshots(context context.Context, namespace string, workspaceId string, cloudName string, req view.SnapshotListReq) (*view.SnapshotsListResponse, error)
 	GetSnapshot(context context.Context, namespace string, workspaceId string, version string, cloudName string) (*view.Snapshot, error)
+	// CompareSnapshots compares services of two snapshots of the same namespace
+	CompareSnapshots(context context.Context, namespace string, workspaceId string, cloudName string, from string, to string) (*view.SnapshotComparison, error)
+	// CompareEnvironmentSnapshots compares services of snapshots from different clouds and namespaces
+	CompareEnvironmentSnapshots(context context.Context, workspaceId string, from view.SnapshotRef, to view.SnapshotRef) (*view.SnapshotComparison, error)
+	// GetSnapshotBundle returns the manifest of the snapshot bundle, use WriteSnapshotBundle to get the archive
+	GetSnapshotBundle(context context.Context, namespace string, workspaceId string, version string, cloudName string) (*view.SnapshotBundleManifest, error)
+	WriteSnapshotBundle(context context.Context, manifest *view.SnapshotBundleManifest, w io.Writer) error
+	// ImportSnapshotBundle publishes the snapshot from the bundle archive to the workspace
+	ImportSnapshotBundle(context context.Context, workspaceId string, bundle io.ReaderAt, size int64, req view.ImportSnapshotBundleReq) (*view.CreateSnapshotResponse, error)
+	// PromoteSnapshot publishes service versions of the snapshot to their baseline packages
+	PromoteSnapshot(context context.Context, namespace string, workspaceId string, version string, cloudName string, req view.PromoteSnapshotReq) (*view.CreateSnapshotResponse, error)
+	// DeleteSnapshot deletes the snapshot with service versions published by it
+	DeleteSnapshot(context context.Context, namespace string, workspaceId string, version string, cloudName string) (*view.DeleteSnapshotResponse, error)
+	// DeleteNamespaceSnapshots deletes snapshots of the namespace group without permission checks, is used by the cleanup job.
+	// Service versions referenced by the remaining snapshots are kept, versions which don't exist are skipped
+	DeleteNamespaceSnapshots(context context.Context, namespaceGroupId string, versions []string) ([]view.DeleteSnapshotResponse, error)
+	// HasReferencedSnapshots returns true if service versions published by the snapshots are referenced by other snapshots of the namespace group
+	HasReferencedSnapshots(context context.Context, namespaceGroupId string, versions []string) (bool, error)
+	UpdateSnapshot(context context.Context, namespace string, workspaceId string, version string, cloudName string, req view.UpdateSnapshotReq) (*view.SnapshotListItem, error)
 }
 
-func NewSnapshotService(systemInfoService SystemInfoService, apihubClient client.ApihubClient, agentClient client.AgentClient) SnapshotService {
-	return &snapshotServiceImpl{systemInfoService: systemInfoService, apihubClient: apihubClient, agentClient: agentClient}
+func NewSnapshotService(systemInfoService SystemInfoService, apihubClient client.ApihubClient, agentService AgentService, permissionService PermissionService, baselineMappingService BaselineMappingService, snapshotVersionService SnapshotVersionService, specValidationService SpecValidationService, snapshotApprovalService SnapshotApprovalService) SnapshotService {
+	summaryCache := libcache.LRU.New(snapshotSummaryCacheSize)
+	summaryCache.SetTTL(snapshotSummaryTTL)
+	summaryCache.RegisterOnExpired(func(key, _ interface{}) {
+		summaryCache.Delete(key)
+	})
+	return &snapshotServiceImpl{systemInfoService: systemInfoService, apihubClient: apihubClient, agentService: agentService, permissionService: permissionService, baselineMappingService: baselineMappingService, snapshotVersionService: snapshotVersionService, specValidationService: specValidationService, snapshotApprovalService: snapshotApprovalService, summaryCache: summaryCache}
 }
 
 type snapshotServiceImpl struct {
-	systemInfoService SystemInfoService
-	apihubClient      client.ApihubClient
-	agentClient       client.AgentClient
+	systemInfoService       SystemInfoService
+	apihubClient            client.ApihubClient
+	agentService            AgentService
+	permissionService       PermissionService
+	baselineMappingService  BaselineMappingService
+	snapshotVersionService  SnapshotVersionService
+	specValidationService   SpecValidationService
+	snapshotApprovalService SnapshotApprovalService
+	summaryCache            libcache.Cache // map[dashboardId|version]view.SnapshotSummary
 }
 
-func (s *snapshotServiceImpl) ListSnapshots(ctx context.Context, namespace string, workspaceId string, page, limit int, cloudName string) (*view.SnapshotsListResponse, error) {
+func (s *snapshotServiceImpl) ListSnapshots(ctx context.Context, namespace string, workspaceId string, cloudName string, req view.SnapshotListReq) (*view.SnapshotsListResponse, error) {
 	groupId := fmt.Sprintf("%s.%s.%s.%s", workspaceId, view.DefaultSnapshotsGroupAlias, utils.ToId(cloudName), utils.ToId(namespace)) // Generate group id for namespace
 	dashboardId := view.MakeSnapshotDashboardIdByGroupId(groupId)
 	versionReq := view.VersionSearchRequest{
-		Page:  page,
-		Limit: limit,
+		Status:     req.Status,
+		TextFilter: req.TextFilter,
+		SortBy:     req.SortBy,
+		SortOrder:  req.SortOrder,
+		Limit:      snapshotListVersionsLimit,
+	}
+
+	var versions []view.PublishedVersionListView
+	var totalCount *int
+	if hasClientSideSnapshotFilters(req) {
+		// all versions are loaded to apply filters which are not supported by APIHUB and to count them, the number of snapshots is limited by TTL
+		for versionReq.Page = 0; ; versionReq.Page++ {
+			dashboardVersions, err := s.apihubClient.GetVersions(ctx, dashboardId, versionReq)
+			if err != nil {
+				return nil, err
+			}
+			if dashboardVersions == nil {
+				break
+			}
+			for _, version := range dashboardVersions.Versions {
+				if matchSnapshotListReq(version, req) {
+					versions = append(versions, version)
+				}
+			}
+			if len(dashboardVersions.Versions) < versionReq.Limit {
+				break
+			}
+		}
+		count := len(versions)
+		totalCount = &count
+		if from := req.Page * req.Limit; from < len(versions) {
+			versions = versions[from:min(from+req.Limit, len(versions))]
+		} else {
+			versions = nil
+		}
+	} else {
+		versionReq.Page, versionReq.Limit = req.Page, req.Limit
+		dashboardVersions, err := s.apihubClient.GetVersions(ctx, dashboardId, versionReq)
+		if err != nil {
+			return nil, err
+		}
+		if dashboardVersions != nil {
+			versions = dashboardVersions.Versions
+		}
+	}
+
+	snapshots := make([]view.SnapshotListItem, 0, len(versions))
+	for _, version := range versions {
+		sn := view.SnapshotListItem{
+			Version:           version.Version,
+			CreatedAt:         version.CreatedAt,
+			NotLatestRevision: version.NotLatestRevision,
+			Status:            version.Status,
+			CreatedBy:         &version.CreatedBy,
+			Labels:            version.VersionLabels,
+			PreviousVersion:   version.PreviousVersion,
+		}
+		snapshots = append(snapshots, sn)
+	}
+	errGrp := errgroup.Group{}
+	errGrp.SetLimit(snapshotCompareConcurrency)
+	for i := range snapshots {
+		errGrp.Go(func() error {
+			summary, err := s.getSnapshotSummary(ctx, dashboardId, snapshots[i].Version)
+			if err != nil {
+				log.Errorf("Failed to get summary of snapshot %s of package %s: %s", snapshots[i].Version, dashboardId, err.Error())
+				return nil
+			}
+			snapshots[i].Summary = summary
+			return nil
+		})
+	}
+	errGrp.Wait()
+
+	return &view.SnapshotsListResponse{Snapshots: snapshots, PackageId: dashboardId, TotalCount: totalCount}, nil
+}
+
+// hasClientSideSnapshotFilters returns true if the request has filters which are not supported by APIHUB versions search
+func hasClientSideSnapshotFilters(req view.SnapshotListReq) bool {
+	return req.CreatedBy != "" || req.CreatedAfter != nil || req.CreatedBefore != nil
+}
+
+func matchSnapshotListReq(version view.PublishedVersionListView, req view.SnapshotListReq) bool {
+	if req.CreatedAfter != nil && version.CreatedAt.Before(*req.CreatedAfter) {
+		return false
+	}
+	if req.CreatedBefore != nil && version.CreatedAt.After(*req.CreatedBefore) {
+		return false
+	}
+	if req.CreatedBy != "" && !strings.EqualFold(version.CreatedBy.Id, req.CreatedBy) &&
+		!strings.Contains(strings.ToLower(version.CreatedBy.Name), strings.ToLower(req.CreatedBy)) {
+		return false
 	}
+	return true
+}
 
-	dashboardVersions, err := s.apihubClient.GetVersions(ctx, dashboardId, versionReq)
+// getSnapshotSummary sums up changes of the snapshot services, the summary is cached since the published revision is not changed.
+// The summary is not cached until all service versions are published and their changes are calculated
+func (s *snapshotServiceImpl) getSnapshotSummary(ctx context.Context, dashboardId string, version string) (*view.SnapshotSummary, error) {
+	cacheKey := dashboardId + "|" + version
+	if cached, exists := s.summaryCache.Load(cacheKey); exists {
+		summary := cached.(view.SnapshotSummary)
+		return &summary, nil
+	}
+	references, err := s.apihubClient.GetVersionReferences(ctx, dashboardId, version)
 	if err != nil {
 		return nil, err
 	}
-
-	snapshots := make([]view.SnapshotListItem, 0)
-	if dashboardVersions != nil {
-		for _, version := range dashboardVersions.Versions {
-			sn := view.SnapshotListItem{
-				Version:   version.Version,
-				CreatedAt: version.CreatedAt,
+	summary := view.SnapshotSummary{}
+	// references are not found while the dashboard build is pending
+	complete := references != nil
+	if references != nil {
+		changes := make([]*view.ChangeSummary, len(references.References))
+		errGrp := errgroup.Group{}
+		errGrp.SetLimit(snapshotCompareConcurrency)
+		for i, ref := range references.References {
+			packageRef, exists := references.Packages[ref.PackageRef]
+			if !exists {
+				complete = false
+				continue
+			}
+			errGrp.Go(func() error {
+				packageVersion, err := s.apihubClient.GetVersion(ctx, packageRef.RefPackageId, packageRef.RefPackageVersion)
+				if err != nil {
+					return err
+				}
+				if packageVersion != nil {
+					changes[i] = packageVersion.ChangeSummary
+				}
+				return nil
+			})
+			summary.ServiceCount++
+		}
+		err = errGrp.Wait()
+		if err != nil {
+			return nil, err
+		}
+		for _, change := range changes {
+			if change != nil {
+				summary.Changes.Add(*change)
+			} else {
+				complete = false
 			}
-			snapshots = append(snapshots, sn)
 		}
 	}
-
-	return &view.SnapshotsListResponse{Snapshots: snapshots, PackageId: dashboardId}, nil
+	summary.BreakingChanges = summary.Changes.Breaking
+	if complete {
+		s.summaryCache.Store(cacheKey, summary)
+	}
+	return &summary, nil
 }
 
 func (s *snapshotServiceImpl) GetSnapshot(ctx context.Context, namespace string, workspaceId string, version string, cloudName string) (*view.Snapshot, error) {
@@ -127,6 +302,7 @@ func (s *snapshotServiceImpl) GetSnapshot(ctx context.Context, namespace string,
 			viewBaselineUrl := ""
 			baselineFound := false
 			baselineVersionFound := false
+			var baselineMatch *view.BaselineMatch
 			if packageVersion.PreviousVersionPackageId != "" {
 				baselineFound = true
 				if packageVersion.PreviousVersion != "" {
@@ -152,26 +328,31 @@ func (s *snapshotServiceImpl) GetSnapshot(ctx context.Context, namespace string,
 					}
 				}
 			} else {
-				baselinePackage, err := s.apihubClient.GetPackageByServiceName(ctx, workspaceId, pkg.Name) // pkg.Name in snapshot package = service name in baseline package
+				// pkg.Name in snapshot package = service name in baseline package
+				baselinePackageId, match, err := s.baselineMappingService.ResolveBaselinePackage(ctx, workspaceId, pkg.Name, parseVersionLabels(packageVersion.VersionLabels))
 				if err != nil {
-					log.Errorf("Package by servicename - %s not found", pkg.Name)
+					log.Errorf("Failed to resolve baseline package for service %s: %s", pkg.Name, err.Error())
 					errors.Store(pkg.Name, err)
 					return
 				}
-				if baselinePackage != nil && baselinePackage.Id != "" {
+				if baselinePackageId != "" {
 					baselineFound = true
+					baselineMatch = match
 				}
 			}
 
+			// unchanged services reference the version published by the previous snapshot, not the snapshot version
+			serviceVersionForUrl := strings.Split(refPackageVersion, "@")[0]
 			serviceWithChanges := view.ServiceWithChanges{
 				Id:                       strings.ToLower(pkg.Alias), // service name is not suitable, since it's empty for snapshot
 				PackageId:                refPackageId,
 				PreviousVersionPackageId: packageVersion.PreviousVersionPackageId,
 				ViewChangesUrl:           comparisonUrl,
-				ViewSnapshotUrl:          fmt.Sprintf("%s/portal/packages/%s/%s/overview/summary", s.systemInfoService.GetApihubUrl(), refPackageId, url.PathEscape(groupVersionForUrl)),
+				ViewSnapshotUrl:          fmt.Sprintf("%s/portal/packages/%s/%s/overview/summary", s.systemInfoService.GetApihubUrl(), refPackageId, url.PathEscape(serviceVersionForUrl)),
 				ViewBaselineUrl:          viewBaselineUrl,
 				BaselineFound:            baselineFound,
 				BaselineVersionFound:     baselineVersionFound,
+				BaselineMatch:            baselineMatch,
 				ApiTypes:                 packageVersion.ApiTypes,
 			}
 			if packageVersion.ChangeSummary != nil {
@@ -197,6 +378,208 @@ func (s *snapshotServiceImpl) GetSnapshot(ctx context.Context, namespace string,
 	return &result, nil
 }
 
+func (s *snapshotServiceImpl) CompareSnapshots(ctx context.Context, namespace string, workspaceId string, cloudName string, from string, to string) (*view.SnapshotComparison, error) {
+	groupId := fmt.Sprintf("%s.%s.%s.%s", workspaceId, view.DefaultSnapshotsGroupAlias, utils.ToId(cloudName), utils.ToId(namespace)) // Generate group id for namespace
+	dashboardId := view.MakeSnapshotDashboardIdByGroupId(groupId)
+
+	return s.compareSnapshots(ctx, dashboardId, from, dashboardId, to)
+}
+
+func (s *snapshotServiceImpl) CompareEnvironmentSnapshots(ctx context.Context, workspaceId string, from view.SnapshotRef, to view.SnapshotRef) (*view.SnapshotComparison, error) {
+	fromGroupId := fmt.Sprintf("%s.%s.%s.%s", workspaceId, view.DefaultSnapshotsGroupAlias, utils.ToId(from.CloudName), utils.ToId(from.Namespace))
+	toGroupId := fmt.Sprintf("%s.%s.%s.%s", workspaceId, view.DefaultSnapshotsGroupAlias, utils.ToId(to.CloudName), utils.ToId(to.Namespace))
+
+	result, err := s.compareSnapshots(ctx, view.MakeSnapshotDashboardIdByGroupId(fromGroupId), from.Version, view.MakeSnapshotDashboardIdByGroupId(toGroupId), to.Version)
+	if err != nil {
+		return nil, err
+	}
+	result.From.CloudName, result.From.Namespace = from.CloudName, from.Namespace
+	result.To.CloudName, result.To.Namespace = to.CloudName, to.Namespace
+	return result, nil
+}
+
+// compareSnapshots matches snapshot services by package alias, so snapshots of different namespaces can be compared as well
+func (s *snapshotServiceImpl) compareSnapshots(ctx context.Context, fromDashboardId string, from string, toDashboardId string, to string) (*view.SnapshotComparison, error) {
+	fromSide, fromRefs, err := s.getSnapshotRefs(ctx, fromDashboardId, from)
+	if err != nil {
+		return nil, err
+	}
+	toSide, toRefs, err := s.getSnapshotRefs(ctx, toDashboardId, to)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceIds := make([]string, 0, len(fromRefs)+len(toRefs))
+	for id := range fromRefs {
+		serviceIds = append(serviceIds, id)
+	}
+	for id := range toRefs {
+		if _, exists := fromRefs[id]; !exists {
+			serviceIds = append(serviceIds, id)
+		}
+	}
+	slices.Sort(serviceIds)
+
+	result := view.SnapshotComparison{
+		From:     *fromSide,
+		To:       *toSide,
+		Services: make([]view.SnapshotServiceComparison, len(serviceIds)),
+	}
+	errGrp := errgroup.Group{}
+	errGrp.SetLimit(snapshotCompareConcurrency)
+	for i, id := range serviceIds {
+		fromRef, fromExists := fromRefs[id]
+		toRef, toExists := toRefs[id]
+		serviceComparison := view.SnapshotServiceComparison{
+			Id:            id,
+			FromPackageId: fromRef.RefPackageId,
+			FromVersion:   fromRef.RefPackageVersion,
+			ToPackageId:   toRef.RefPackageId,
+			ToVersion:     toRef.RefPackageVersion,
+		}
+		switch {
+		case !fromExists:
+			serviceComparison.Status = view.SnapshotServiceAdded
+		case !toExists:
+			serviceComparison.Status = view.SnapshotServiceRemoved
+		case fromRef.RefPackageId == toRef.RefPackageId && fromRef.RefPackageVersion == toRef.RefPackageVersion:
+			serviceComparison.Status = view.SnapshotServiceUnchanged
+		default:
+			serviceComparison.Status = view.SnapshotServiceChanged
+		}
+		result.Services[i] = serviceComparison
+		if serviceComparison.Status == view.SnapshotServiceChanged {
+			errGrp.Go(func() error {
+				return s.compareSnapshotServiceVersions(ctx, fromRef, toRef, &result.Services[i])
+			})
+		}
+	}
+	err = errGrp.Wait()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, svc := range result.Services {
+		switch svc.Status {
+		case view.SnapshotServiceAdded:
+			result.Summary.Added++
+		case view.SnapshotServiceRemoved:
+			result.Summary.Removed++
+		case view.SnapshotServiceUnchanged:
+			result.Summary.Unchanged++
+		case view.SnapshotServiceChanged:
+			result.Summary.Changed++
+			if svc.Changes != nil {
+				result.Summary.Changes.Add(*svc.Changes)
+			}
+		}
+	}
+	return &result, nil
+}
+
+// getSnapshotRefs returns snapshot service package versions by service id
+func (s *snapshotServiceImpl) getSnapshotRefs(ctx context.Context, dashboardId string, version string) (*view.SnapshotComparisonSide, map[string]view.PackageVersionRef, error) {
+	versionContent, refs, err := getSnapshotVersionRefs(ctx, s.apihubClient, dashboardId, version)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	versionForUrl := versionContent.Version
+	if !versionContent.NotLatestRevision {
+		versionForUrl = strings.Split(versionContent.Version, "@")[0]
+	}
+	publishedAtStr, _ := versionContent.PublishedAt.UTC().MarshalText()
+	return &view.SnapshotComparisonSide{
+		PackageId:       dashboardId,
+		Version:         versionContent.Version,
+		PublishedAt:     string(publishedAtStr),
+		ViewSnapshotUrl: fmt.Sprintf("%s/portal/packages/%s/%s/overview/summary", s.systemInfoService.GetApihubUrl(), dashboardId, url.PathEscape(versionForUrl)),
+	}, refs, nil
+}
+
+// getSnapshotVersionRefs returns the snapshot dashboard version and its service package versions by service id
+func getSnapshotVersionRefs(ctx context.Context, apihubClient client.ApihubClient, dashboardId string, version string) (*view.VersionContent, map[string]view.PackageVersionRef, error) {
+	versionContent, err := apihubClient.GetVersion(ctx, dashboardId, version)
+	if err != nil {
+		return nil, nil, err
+	}
+	if versionContent == nil {
+		return nil, nil, &exception.CustomError{
+			Status:  http.StatusNotFound,
+			Code:    exception.SnapshotNotFound,
+			Message: exception.SnapshotNotFoundMsg,
+			Params:  map[string]interface{}{"version": version},
+			Debug:   fmt.Sprintf("dashboard %s", dashboardId),
+		}
+	}
+	references, err := apihubClient.GetVersionReferences(ctx, dashboardId, version)
+	if err != nil {
+		return nil, nil, err
+	}
+	refs := make(map[string]view.PackageVersionRef)
+	if references != nil {
+		for _, ref := range references.References {
+			packageRef, exists := references.Packages[ref.PackageRef]
+			if !exists {
+				continue
+			}
+			refs[getSnapshotServiceId(packageRef.RefPackageId)] = packageRef
+		}
+	}
+	return versionContent, refs, nil
+}
+
+// getSnapshotServiceId returns the service id which is the lower-cased alias of the snapshot package, same as in GetSnapshot
+func getSnapshotServiceId(packageId string) string {
+	return strings.ToLower(packageId[strings.LastIndex(packageId, ".")+1:])
+}
+
+func (s *snapshotServiceImpl) compareSnapshotServiceVersions(ctx context.Context, fromRef view.PackageVersionRef, toRef view.PackageVersionRef, result *view.SnapshotServiceComparison) error {
+	fromVersionContent, err := s.apihubClient.GetVersion(ctx, fromRef.RefPackageId, fromRef.RefPackageVersion)
+	if err != nil {
+		return err
+	}
+	toVersionContent, err := s.apihubClient.GetVersion(ctx, toRef.RefPackageId, toRef.RefPackageVersion)
+	if err != nil {
+		return err
+	}
+	var apiTypes []string
+	if fromVersionContent != nil {
+		apiTypes = append(apiTypes, fromVersionContent.ApiTypes...)
+	}
+	if toVersionContent != nil {
+		apiTypes = append(apiTypes, toVersionContent.ApiTypes...)
+	}
+	slices.Sort(apiTypes)
+	result.ApiTypes = slices.Compact(apiTypes)
+
+	toVersionForUrl := toRef.RefPackageVersion
+	if !toRef.NotLatestRevision {
+		toVersionForUrl = strings.Split(toRef.RefPackageVersion, "@")[0]
+	}
+	fromVersionForUrl := fromRef.RefPackageVersion
+	if !fromRef.NotLatestRevision {
+		fromVersionForUrl = strings.Split(fromRef.RefPackageVersion, "@")[0]
+	}
+	apiType := selectDefaultApiType(apiTypes)
+	if apiType != "" {
+		result.ViewChangesUrl = fmt.Sprintf("%s/portal/packages/%s/%s/compare?apiType=%s&package=%s&version=%s",
+			s.systemInfoService.GetApihubUrl(), toRef.RefPackageId, url.PathEscape(toVersionForUrl), apiType, fromRef.RefPackageId, url.QueryEscape(fromVersionForUrl))
+	}
+
+	changesSummary, err := s.apihubClient.GetVersionChangesSummary(ctx, toRef.RefPackageId, toRef.RefPackageVersion, fromRef.RefPackageId, fromRef.RefPackageVersion)
+	if err != nil {
+		return err
+	}
+	if changesSummary != nil {
+		changes := changesSummary.GetChangeSummary()
+		result.Changes = &changes
+	} else {
+		log.Debugf("Comparison of %s@%s with %s@%s is not found", toRef.RefPackageId, toRef.RefPackageVersion, fromRef.RefPackageId, fromRef.RefPackageVersion)
+	}
+	return nil
+}
+
 func (s *snapshotServiceImpl) CreateSnapshot(ctx context.Context, namespace string, workspaceId string, version string, snapshotDTO view.CreateSnapshotDTO) (*view.CreateSnapshotResponse, error) {
 	log.Infof("Creating snapshot for namespace %s", namespace)
 
@@ -216,8 +599,23 @@ func (s *snapshotServiceImpl) CreateSnapshot(ctx context.Context, namespace stri
 	if versionNameValidationError != nil {
 		return nil, versionNameValidationError
 	}
+	if snapshotDTO.Promote && snapshotDTO.VersionStatus == string(view.ReleaseStatus) {
+		approvers, err := s.snapshotApprovalService.GetSnapshotApprovers(workspaceId)
+		if err != nil {
+			return nil, err
+		}
+		if len(approvers.Approvers) > 0 {
+			// live specifications may differ from the approved ones, so only the approved snapshot can be promoted to release
+			return nil, &exception.CustomError{
+				Status:  http.StatusBadRequest,
+				Code:    exception.ReleasePromotionRequiresApproval,
+				Message: exception.ReleasePromotionRequiresApprovalMsg,
+				Params:  map[string]interface{}{"workspaceId": workspaceId},
+			}
+		}
+	}
 
-	serviceListResponse, err := s.agentClient.ListServices(ctx, namespace, workspaceId, snapshotDTO.AgentUrl)
+	serviceListResponse, err := s.listSnapshotServices(ctx, namespace, workspaceId, snapshotDTO.Agent)
 	if err != nil {
 		return nil, err
 	}
@@ -231,7 +629,192 @@ func (s *snapshotServiceImpl) CreateSnapshot(ctx context.Context, namespace stri
 		return nil, fmt.Errorf("create snapshot failed: no (selected) services in namespace %s, try to run discovery", namespace)
 	}
 
-	return s.startSnapshot(ctx, namespace, workspaceId, version, serviceListResponse.Services, snapshotDTO)
+	targetPackageIds := getSnapshotTargetPackageIds(namespace, workspaceId, serviceListResponse.Services, snapshotDTO)
+	version, err = s.resolveSnapshotVersion(ctx, namespace, workspaceId, version, serviceListResponse.Services, targetPackageIds, snapshotDTO)
+	if err != nil {
+		return nil, err
+	}
+
+	var specs [][][]byte
+	var specValidation []view.ServiceSpecValidation
+	if snapshotDTO.ValidateSpecs {
+		// validated specifications are kept to publish exactly the same content
+		specs, err = s.downloadSnapshotSpecs(ctx, namespace, workspaceId, serviceListResponse.Services, snapshotDTO.Agent)
+		if err != nil {
+			return nil, err
+		}
+		specValidation, err = s.validateSnapshotSpecs(workspaceId, serviceListResponse.Services, specs)
+		if err != nil {
+			return nil, err
+		}
+		if snapshotDTO.FailOnSpecErrors {
+			failedServiceIds := make([]string, 0)
+			for _, svcValidation := range specValidation {
+				if svcValidation.Errors > 0 {
+					failedServiceIds = append(failedServiceIds, svcValidation.ServiceId)
+				}
+			}
+			if len(failedServiceIds) > 0 {
+				log.Infof("Create snapshot failed: specifications of services %v have errors", failedServiceIds)
+				return nil, &exception.CustomError{
+					Status:  http.StatusBadRequest,
+					Code:    exception.SpecValidationFailed,
+					Message: exception.SpecValidationFailedMsg,
+					Params:  map[string]interface{}{"services": strings.Join(failedServiceIds, ", "), "findings": specValidation},
+				}
+			}
+		}
+	}
+
+	var checksums []string
+	if snapshotDTO.SkipUnchanged && !snapshotDTO.Promote {
+		checksums, err = s.getSnapshotSpecsChecksums(ctx, namespace, workspaceId, serviceListResponse.Services, specs, snapshotDTO.Agent)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result, err := s.startSnapshot(ctx, namespace, workspaceId, version, serviceListResponse.Services, specs, checksums, snapshotDTO)
+	if err != nil {
+		return nil, err
+	}
+	result.SpecValidation = specValidation
+	return result, nil
+}
+
+// downloadSnapshotSpecs returns specifications of the services, so that they can be checked and published without fetching them again
+func (s *snapshotServiceImpl) downloadSnapshotSpecs(ctx context.Context, namespace string, workspaceId string, services []view.Service, agent view.AgentInstance) ([][][]byte, error) {
+	specs := make([][][]byte, len(services))
+	errGrp := errgroup.Group{}
+	errGrp.SetLimit(snapshotValidationConcurrency)
+	for i, svc := range services {
+		errGrp.Go(func() error {
+			var err error
+			specs[i], err = s.getServiceSpecs(ctx, namespace, workspaceId, svc, agent)
+			return err
+		})
+	}
+	err := errGrp.Wait()
+	if err != nil {
+		return nil, err
+	}
+	return specs, nil
+}
+
+// getSnapshotSpecsChecksums calculates checksums of the service documents, specs are downloaded if not set and are not kept in memory
+func (s *snapshotServiceImpl) getSnapshotSpecsChecksums(ctx context.Context, namespace string, workspaceId string, services []view.Service, specs [][][]byte, agent view.AgentInstance) ([]string, error) {
+	checksums := make([]string, len(services))
+	errGrp := errgroup.Group{}
+	errGrp.SetLimit(snapshotPublishConcurrency)
+	for i, svc := range services {
+		errGrp.Go(func() error {
+			var svcSpecs [][]byte
+			if specs != nil {
+				svcSpecs = specs[i]
+			} else {
+				var err error
+				svcSpecs, err = s.getServiceSpecs(ctx, namespace, workspaceId, svc, agent)
+				if err != nil {
+					return err
+				}
+			}
+			checksums[i] = getServiceSpecsChecksum(svc.Documents, svcSpecs)
+			return nil
+		})
+	}
+	err := errGrp.Wait()
+	if err != nil {
+		return nil, err
+	}
+	return checksums, nil
+}
+
+func (s *snapshotServiceImpl) validateSnapshotSpecs(workspaceId string, services []view.Service, specs [][][]byte) ([]view.ServiceSpecValidation, error) {
+	result := make([]view.ServiceSpecValidation, len(services))
+	for i, svc := range services {
+		svcValidation, err := s.specValidationService.ValidateServiceSpecs(workspaceId, svc.Id, svc.Documents, specs[i])
+		if err != nil {
+			return nil, err
+		}
+		result[i] = *svcValidation
+	}
+	return result, nil
+}
+
+func (s *snapshotServiceImpl) getServiceSpecs(ctx context.Context, namespace string, workspaceId string, svc view.Service, agent view.AgentInstance) ([][]byte, error) {
+	result := make([][]byte, len(svc.Documents))
+	for i, spec := range svc.Documents {
+		var err error
+		result[i], err = s.agentService.GetServiceSpecification(ctx, agent, namespace, workspaceId, svc.Id, spec.FileId)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get specification %s of service %s: %v", spec.FileId, svc.Id, err.Error())
+		}
+	}
+	return result, nil
+}
+
+// listSnapshotServices returns discovered services with baselines resolved by the workspace mapping rules
+func (s *snapshotServiceImpl) listSnapshotServices(ctx context.Context, namespace string, workspaceId string, agent view.AgentInstance) (*view.ServiceListResponse, error) {
+	serviceListResponse, err := s.agentService.ListServices(ctx, agent, namespace, workspaceId)
+	if err != nil {
+		return nil, err
+	}
+	err = s.baselineMappingService.ResolveBaselines(ctx, workspaceId, serviceListResponse.Services)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve service baselines: %v", err.Error())
+	}
+	return serviceListResponse, nil
+}
+
+// getSnapshotTargetPackageIds returns packages the service versions are published to
+func getSnapshotTargetPackageIds(namespace string, workspaceId string, services []view.Service, snapshotDTO view.CreateSnapshotDTO) []string {
+	groupId := fmt.Sprintf("%s.%s.%s.%s", workspaceId, view.DefaultSnapshotsGroupAlias, utils.ToId(snapshotDTO.CloudName), utils.ToId(namespace))
+	targetPackageIds := make([]string, 0, len(services))
+	for _, svc := range services {
+		if snapshotDTO.Promote {
+			targetPackageIds = append(targetPackageIds, svc.Baseline.PackageId)
+		} else {
+			targetPackageIds = append(targetPackageIds, groupId+"."+utils.ToId(svc.Id))
+		}
+	}
+	return targetPackageIds
+}
+
+// resolveSnapshotVersion generates the version by the workspace template if it's empty and validates release version
+func (s *snapshotServiceImpl) resolveSnapshotVersion(ctx context.Context, namespace string, workspaceId string, version string, services []view.Service, targetPackageIds []string, snapshotDTO view.CreateSnapshotDTO) (string, error) {
+	var err error
+	if version == "" {
+		versionPackageIds := targetPackageIds
+		if !snapshotDTO.Promote {
+			groupId := fmt.Sprintf("%s.%s.%s.%s", workspaceId, view.DefaultSnapshotsGroupAlias, utils.ToId(snapshotDTO.CloudName), utils.ToId(namespace))
+			versionPackageIds = []string{view.MakeSnapshotDashboardIdByGroupId(groupId)}
+		}
+		version, err = s.snapshotVersionService.ResolveSnapshotVersion(ctx, workspaceId, snapshotDTO.CloudName, namespace, services, versionPackageIds)
+		if err != nil {
+			return "", err
+		}
+		log.Infof("Snapshot version %s is generated for namespace %s", version, namespace)
+	}
+	if snapshotDTO.VersionStatus == string(view.ReleaseStatus) {
+		err = s.snapshotVersionService.ValidateReleaseVersion(ctx, version, targetPackageIds)
+		if err != nil {
+			return "", err
+		}
+	}
+	return version, nil
+}
+
+// parseVersionLabels restores service labels from snapshot version labels in "key:value" format
+func parseVersionLabels(versionLabels []string) map[string]string {
+	result := make(map[string]string, len(versionLabels))
+	for _, label := range versionLabels {
+		key, value, found := strings.Cut(label, ":")
+		if !found {
+			continue
+		}
+		result[key] = value
+	}
+	return result
 }
 
 func validateVersionName(versionName string) error {
@@ -279,7 +862,9 @@ func filterService(allService []view.Service, requiredServices []string, promote
 	return result
 }
 
-func (s *snapshotServiceImpl) startSnapshot(ctx context.Context, namespace string, workspaceId string, version string, services []view.Service, snapshotDTO view.CreateSnapshotDTO) (*view.CreateSnapshotResponse, error) {
+// startSnapshot publishes the snapshot asynchronously, specs contain already downloaded specifications of the services or nil if they are not downloaded yet.
+// Services with the same checksums as their last published versions are not published if checksums are set
+func (s *snapshotServiceImpl) startSnapshot(ctx context.Context, namespace string, workspaceId string, version string, services []view.Service, specs [][][]byte, checksums []string, snapshotDTO view.CreateSnapshotDTO) (*view.CreateSnapshotResponse, error) {
 	// TODO: handle errors!
 
 	var packageIds []string
@@ -302,17 +887,52 @@ func (s *snapshotServiceImpl) startSnapshot(ctx context.Context, namespace strin
 		}
 	}
 
+	refs := make([]view.BCRef, 0, len(packageIds))
+	var unchangedServices []view.SnapshotUnchangedService
+	if checksums != nil && !snapshotDTO.Promote {
+		unchangedVersions, err := s.getUnchangedServiceVersions(ctx, packageIds, services, checksums, snapshotDTO.VersionStatus, snapshotDTO.PreviousVersion)
+		if err != nil {
+			return nil, fmt.Errorf("prepare snapshot failed: %s", err.Error())
+		}
+		var changedSpecs [][][]byte
+		changedServices := make([]view.Service, 0, len(services))
+		for i, svc := range services {
+			if unchangedVersions[i] == "" {
+				changedServices = append(changedServices, svc)
+				if specs != nil {
+					changedSpecs = append(changedSpecs, specs[i])
+				}
+				refs = append(refs, view.BCRef{RefId: packageIds[i], Version: version})
+				continue
+			}
+			log.Infof("Service %s is not changed since version %s, it will be referenced in snapshot %s", svc.Id, unchangedVersions[i], version)
+			unchangedServices = append(unchangedServices, view.SnapshotUnchangedService{ServiceId: svc.Id, PackageId: packageIds[i], Version: unchangedVersions[i]})
+			refs = append(refs, view.BCRef{RefId: packageIds[i], Version: unchangedVersions[i]})
+		}
+		services, specs = changedServices, changedSpecs
+	} else {
+		for _, pkgId := range packageIds {
+			refs = append(refs, view.BCRef{
+				RefId:   pkgId,
+				Version: version,
+			})
+		}
+	}
+
 	configs := make([]view.BuildConfig, len(services))
 
 	wg := sync.WaitGroup{}
+	workers := make(chan struct{}, snapshotPublishConcurrency)
 	for it, svcIt := range services {
 		svc := svcIt
 		i := it
 		packageId := groupId + "." + utils.ToId(svc.Id)
 		wg.Add(1)
+		workers <- struct{}{}
 		utils.SafeAsync(func() {
 			svcPreviousVersion := snapshotDTO.PreviousVersion
 			defer wg.Done()
+			defer func() { <-workers }()
 			if svcPreviousVersion != "" {
 				if svc.Baseline == nil {
 					svcPreviousVersion = ""
@@ -399,13 +1019,6 @@ func (s *snapshotServiceImpl) startSnapshot(ctx context.Context, namespace strin
 		})
 	}
 
-	var refs []view.BCRef
-	for _, pkgId := range packageIds {
-		refs = append(refs, view.BCRef{
-			RefId:   pkgId,
-			Version: version,
-		})
-	}
 	var groupBuildConfig *view.BuildConfig
 	if snapshotDTO.Promote {
 		//no group publish
@@ -433,36 +1046,35 @@ func (s *snapshotServiceImpl) startSnapshot(ctx context.Context, namespace strin
 		ctx := context.WithValue(context.Background(), "secCtx", secCtx)
 		publishIds := make([]string, len(services))
 		wg := sync.WaitGroup{}
+		workers := make(chan struct{}, snapshotPublishConcurrency)
 		for svcIndIt, svcIt := range services {
 			svcInd := svcIndIt
 			svc := svcIt
 			wg.Add(1)
+			workers <- struct{}{}
 			utils.SafeAsync(func() {
 				defer wg.Done()
+				defer func() { <-workers }()
 
-				zipBuf := bytes.Buffer{}
-				zw := zip.NewWriter(&zipBuf)
-
-				for specInd, spec := range svc.Documents {
-					specBytes, err := s.agentClient.GetServiceSpecification(ctx, namespace, workspaceId, svc.Id, spec.FileId, snapshotDTO.AgentUrl)
-					if err != nil {
-						log.Errorf("error: unable to get specification %s: %s", svc.Id, err.Error())
-						return
-					}
-					fileName := configs[svcInd].Files[specInd].FileId
-					err = addFileToZip(zw, fileName, specBytes)
-					if err != nil {
-						log.Errorf("error: unable to add spec %s to src archive: %s", fileName, err.Error())
-						return
-					}
-				}
-				err = zw.Close()
-				if err != nil {
-					log.Errorf("error: unable to close src archive: %s", err.Error())
-					return
+				var svcSpecs [][]byte
+				if specs != nil {
+					svcSpecs = specs[svcInd]
 				}
-
-				_, err = s.apihubClient.Publish(ctx, configs[svcInd], zipBuf.Bytes(), snapshotDTO.ClientBuild, snapshotDTO.BuilderId, false, nil)
+				// the archive is streamed to the publish request, so only the current spec of each worker is kept in memory
+				zipReader, zipWriter := io.Pipe()
+				// configs are returned in the response, so the checksum is set to a copy
+				config := configs[svcInd]
+				config.Metadata = maps.Clone(config.Metadata)
+				utils.SafeAsync(func() {
+					// the checksum is calculated from the published content, specs might change since the checksums for skipUnchanged were calculated
+					checksum, err := s.writeServiceSpecsZip(ctx, zipWriter, namespace, workspaceId, svc, svcSpecs, config.Files, snapshotDTO.Agent)
+					if err == nil {
+						config.Metadata[view.SpecChecksumMetadataKey] = checksum
+					}
+					zipWriter.CloseWithError(err)
+				})
+				_, err := s.apihubClient.Publish(ctx, &config, zipReader, snapshotDTO.ClientBuild, snapshotDTO.BuilderId, false, nil)
+				zipReader.Close()
 				if err != nil {
 					log.Errorf("Failed to send publish request: %s", err.Error())
 					return
@@ -474,7 +1086,7 @@ func (s *snapshotServiceImpl) startSnapshot(ctx context.Context, namespace strin
 
 		if !snapshotDTO.Promote {
 			//TODO this will produce an error if at least one service is not sent to publish
-			_, err = s.apihubClient.Publish(ctx, *groupBuildConfig, nil, false, "", false, publishIds)
+			_, err = s.apihubClient.Publish(ctx, groupBuildConfig, nil, false, "", false, publishIds)
 			if err != nil {
 				log.Errorf("Failed to send publish request: %s", err.Error())
 				return
@@ -493,7 +1105,8 @@ func (s *snapshotServiceImpl) startSnapshot(ctx context.Context, namespace strin
 				PackageId: groupBuildConfig.PackageId,
 				PublishId: groupBuildConfig.PublishId,
 			},
-			Services: configs,
+			Services:          configs,
+			UnchangedServices: unchangedServices,
 		}, nil
 	}
 }
@@ -681,12 +1294,45 @@ func (s *snapshotServiceImpl) preparePackages(ctx context.Context, services []vi
 	return ids, nil
 }
 
-func addFileToZip(zw *zip.Writer, name string, content []byte) error {
+// writeServiceSpecsZip writes the sources archive of the service and returns the checksum of the written specs,
+// specs are fetched from the agent one by one if not set
+func (s *snapshotServiceImpl) writeServiceSpecsZip(ctx context.Context, w io.Writer, namespace string, workspaceId string, svc view.Service, specs [][]byte, files []view.BCFile, agent view.AgentInstance) (string, error) {
+	zw := zip.NewWriter(w)
+	checksum := newSpecsChecksum()
+	for specInd, spec := range svc.Documents {
+		var specBytes []byte
+		if specs != nil {
+			specBytes = specs[specInd]
+		} else {
+			var err error
+			specBytes, err = s.agentService.GetServiceSpecification(ctx, agent, namespace, workspaceId, svc.Id, spec.FileId)
+			if err != nil {
+				log.Errorf("error: unable to get specification %s: %s", svc.Id, err.Error())
+				return "", err
+			}
+		}
+		fileName := files[specInd].FileId
+		err := addFileToZip(zw, fileName, specBytes, checksum.addDocument(spec.FileId))
+		if err != nil {
+			log.Errorf("error: unable to add spec %s to src archive: %s", fileName, err.Error())
+			return "", err
+		}
+	}
+	err := zw.Close()
+	if err != nil {
+		log.Errorf("error: unable to close src archive: %s", err.Error())
+		return "", err
+	}
+	return checksum.String(), nil
+}
+
+// addFileToZip adds the file to the archive, the content is also written to the additional writers
+func addFileToZip(zw *zip.Writer, name string, content []byte, writers ...io.Writer) error {
 	mdFw, err := zw.Create(name)
 	if err != nil {
 		return err
 	}
-	_, err = mdFw.Write(content)
+	_, err = io.MultiWriter(append([]io.Writer{mdFw}, writers...)...).Write(content)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,427 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Netcracker/qubership-apihub-agents-backend/client"
+	"github.com/Netcracker/qubership-apihub-agents-backend/entity"
+	"github.com/Netcracker/qubership-apihub-agents-backend/exception"
+	"github.com/Netcracker/qubership-apihub-agents-backend/repository"
+	"github.com/Netcracker/qubership-apihub-agents-backend/secctx"
+	"github.com/Netcracker/qubership-apihub-agents-backend/utils"
+	"github.com/Netcracker/qubership-apihub-agents-backend/view"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// SnapshotApprovalService manages the optional sign-off of snapshots by workspace approvers before promotion to release
+type SnapshotApprovalService interface {
+	GetSnapshotApprovers(workspaceId string) (*view.SnapshotApprovers, error)
+	UpdateSnapshotApprovers(ctx context.Context, workspaceId string, req view.UpdateSnapshotApproversReq) (*view.SnapshotApprovers, error)
+	SubmitSnapshotApproval(ctx context.Context, namespace string, workspaceId string, version string, cloudName string, req view.SubmitSnapshotApprovalReq) (*view.SnapshotApproval, error)
+	GetSnapshotApproval(ctx context.Context, namespace string, workspaceId string, version string, cloudName string) (*view.SnapshotApproval, error)
+	DecideSnapshotApproval(ctx context.Context, namespace string, workspaceId string, version string, cloudName string, req view.SnapshotApprovalDecisionReq) (*view.SnapshotApproval, error)
+	CommentSnapshotApproval(ctx context.Context, namespace string, workspaceId string, version string, cloudName string, req view.SnapshotApprovalCommentReq) (*view.SnapshotApproval, error)
+	// CheckSnapshotApproved returns an error if the workspace has approvers and the services are not approved in the last revision of the snapshot
+	CheckSnapshotApproved(ctx context.Context, namespace string, workspaceId string, version string, cloudName string, serviceIds []string) error
+	// DeleteSnapshotApprovals deletes approvals of the deleted snapshot, so they are not applied to a new snapshot with the same version
+	DeleteSnapshotApprovals(dashboardId string, version string) error
+}
+
+func NewSnapshotApprovalService(apihubClient client.ApihubClient, permissionService PermissionService, snapshotApprovalRepository repository.SnapshotApprovalRepository) SnapshotApprovalService {
+	return &snapshotApprovalServiceImpl{
+		apihubClient:               apihubClient,
+		permissionService:          permissionService,
+		snapshotApprovalRepository: snapshotApprovalRepository,
+	}
+}
+
+type snapshotApprovalServiceImpl struct {
+	apihubClient               client.ApihubClient
+	permissionService          PermissionService
+	snapshotApprovalRepository repository.SnapshotApprovalRepository
+}
+
+func (s snapshotApprovalServiceImpl) GetSnapshotApprovers(workspaceId string) (*view.SnapshotApprovers, error) {
+	ent, err := s.snapshotApprovalRepository.GetSnapshotApprovers(workspaceId)
+	if err != nil {
+		return nil, err
+	}
+	if ent == nil {
+		return &view.SnapshotApprovers{WorkspaceId: workspaceId, Approvers: make([]string, 0)}, nil
+	}
+	result := entity.MakeSnapshotApproversView(*ent)
+	return &result, nil
+}
+
+func (s snapshotApprovalServiceImpl) UpdateSnapshotApprovers(ctx context.Context, workspaceId string, req view.UpdateSnapshotApproversReq) (*view.SnapshotApprovers, error) {
+	err := checkWorkspaceManagePermission(ctx, s.apihubClient, s.permissionService, workspaceId)
+	if err != nil {
+		return nil, err
+	}
+	approvers := make([]string, 0, len(req.Approvers))
+	for _, approver := range req.Approvers {
+		if approver = strings.TrimSpace(approver); approver != "" && !slices.Contains(approvers, approver) {
+			approvers = append(approvers, approver)
+		}
+	}
+	ent := entity.SnapshotApproversEntity{
+		WorkspaceId: workspaceId,
+		Approvers:   approvers,
+		UpdatedAt:   time.Now(),
+		UpdatedBy:   secctx.GetUserId(ctx),
+	}
+	err = s.snapshotApprovalRepository.SaveSnapshotApprovers(&ent)
+	if err != nil {
+		return nil, err
+	}
+	result := entity.MakeSnapshotApproversView(ent)
+	return &result, nil
+}
+
+func (s snapshotApprovalServiceImpl) SubmitSnapshotApproval(ctx context.Context, namespace string, workspaceId string, version string, cloudName string, req view.SubmitSnapshotApprovalReq) (*view.SnapshotApproval, error) {
+	err := s.checkSnapshotGroupPermission(ctx, makeSnapshotGroupId(workspaceId, cloudName, namespace), view.MakeManageVersionPermission(string(view.DraftStatus)))
+	if err != nil {
+		return nil, err
+	}
+	approvers, err := s.getApprovers(workspaceId)
+	if err != nil {
+		return nil, err
+	}
+	if len(approvers) == 0 {
+		return nil, &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.SnapshotApproversNotConfigured,
+			Message: exception.SnapshotApproversNotConfiguredMsg,
+			Params:  map[string]interface{}{"workspaceId": workspaceId},
+		}
+	}
+	dashboardId := makeSnapshotDashboardId(workspaceId, cloudName, namespace)
+	versionContent, refs, err := getSnapshotVersionRefs(ctx, s.apihubClient, dashboardId, version)
+	if err != nil {
+		return nil, err
+	}
+	existingEnt, err := s.snapshotApprovalRepository.GetLatestSnapshotApproval(dashboardId, versionContent.Version)
+	if err != nil {
+		return nil, err
+	}
+	if existingEnt != nil && existingEnt.Status != string(view.SnapshotApprovalRejected) {
+		return nil, &exception.CustomError{
+			Status:  http.StatusConflict,
+			Code:    exception.SnapshotApprovalAlreadySubmitted,
+			Message: exception.SnapshotApprovalAlreadySubmittedMsg,
+			Params:  map[string]interface{}{"version": versionContent.Version, "status": existingEnt.Status},
+		}
+	}
+
+	now := time.Now()
+	ent := entity.SnapshotApprovalEntity{
+		ApprovalId:  uuid.New().String(),
+		WorkspaceId: workspaceId,
+		CloudName:   cloudName,
+		Namespace:   namespace,
+		PackageId:   dashboardId,
+		Version:     versionContent.Version,
+		Status:      string(view.SnapshotApprovalPending),
+		CreatedAt:   now,
+		CreatedBy:   secctx.GetUserId(ctx),
+		UpdatedAt:   now,
+	}
+	serviceEnts := make([]entity.SnapshotApprovalServiceEntity, 0, len(refs))
+	for serviceId, ref := range refs {
+		serviceEnts = append(serviceEnts, entity.SnapshotApprovalServiceEntity{
+			ApprovalId: ent.ApprovalId,
+			ServiceId:  serviceId,
+			PackageId:  ref.RefPackageId,
+			Version:    ref.RefPackageVersion,
+			Status:     string(view.SnapshotApprovalPending),
+		})
+	}
+	slices.SortFunc(serviceEnts, func(a, b entity.SnapshotApprovalServiceEntity) int {
+		return strings.Compare(a.ServiceId, b.ServiceId)
+	})
+	eventEnt := makeSnapshotApprovalEventEntity(ctx, ent.ApprovalId, "", view.SnapshotApprovalActionSubmitted, req.Comment, now)
+	err = s.snapshotApprovalRepository.CreateSnapshotApproval(&ent, serviceEnts, &eventEnt)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("Snapshot %s of namespace %s is submitted for approval by %s", ent.Version, namespace, ent.CreatedBy)
+	result := entity.MakeSnapshotApprovalView(ent, serviceEnts, []entity.SnapshotApprovalEventEntity{eventEnt})
+	return &result, nil
+}
+
+func (s snapshotApprovalServiceImpl) GetSnapshotApproval(ctx context.Context, namespace string, workspaceId string, version string, cloudName string) (*view.SnapshotApproval, error) {
+	err := s.checkSnapshotGroupPermission(ctx, makeSnapshotGroupId(workspaceId, cloudName, namespace), view.ReadPermission)
+	if err != nil {
+		return nil, err
+	}
+	ent, err := s.getSnapshotApproval(ctx, namespace, workspaceId, version, cloudName)
+	if err != nil {
+		return nil, err
+	}
+	return s.makeSnapshotApprovalView(*ent)
+}
+
+func (s snapshotApprovalServiceImpl) DecideSnapshotApproval(ctx context.Context, namespace string, workspaceId string, version string, cloudName string, req view.SnapshotApprovalDecisionReq) (*view.SnapshotApproval, error) {
+	if req.Status != view.SnapshotApprovalApproved && req.Status != view.SnapshotApprovalRejected {
+		return nil, &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.InvalidParameter,
+			Message: exception.InvalidParameterMsg,
+			Params:  map[string]interface{}{"param": "status"},
+			Debug:   fmt.Sprintf("status must be %s or %s", view.SnapshotApprovalApproved, view.SnapshotApprovalRejected),
+		}
+	}
+	approvers, err := s.getApprovers(workspaceId)
+	if err != nil {
+		return nil, err
+	}
+	userId := secctx.GetUserId(ctx)
+	if !slices.Contains(approvers, userId) {
+		return nil, &exception.CustomError{
+			Status:  http.StatusForbidden,
+			Code:    exception.NotSnapshotApprover,
+			Message: exception.NotSnapshotApproverMsg,
+			Params:  map[string]interface{}{"userId": userId, "workspaceId": workspaceId},
+		}
+	}
+	ent, err := s.getSnapshotApproval(ctx, namespace, workspaceId, version, cloudName)
+	if err != nil {
+		return nil, err
+	}
+	serviceEnts, err := s.snapshotApprovalRepository.GetSnapshotApprovalServices(ent.ApprovalId)
+	if err != nil {
+		return nil, err
+	}
+	for _, serviceId := range req.Services {
+		if !slices.ContainsFunc(serviceEnts, func(serviceEnt entity.SnapshotApprovalServiceEntity) bool {
+			return serviceEnt.ServiceId == strings.ToLower(serviceId)
+		}) {
+			return nil, &exception.CustomError{
+				Status:  http.StatusBadRequest,
+				Code:    exception.InvalidParameter,
+				Message: exception.InvalidParameterMsg,
+				Params:  map[string]interface{}{"param": "services"},
+				Debug:   fmt.Sprintf("service %s is not found in snapshot %s", serviceId, ent.Version),
+			}
+		}
+	}
+
+	now := time.Now()
+	decidedServiceEnts := make([]entity.SnapshotApprovalServiceEntity, 0, len(serviceEnts))
+	eventEnts := make([]entity.SnapshotApprovalEventEntity, 0, len(serviceEnts))
+	for i := range serviceEnts {
+		serviceEnt := &serviceEnts[i]
+		if len(req.Services) > 0 && !slices.ContainsFunc(req.Services, func(serviceId string) bool {
+			return strings.ToLower(serviceId) == serviceEnt.ServiceId
+		}) {
+			continue
+		}
+		serviceEnt.Status = string(req.Status)
+		serviceEnt.DecidedAt = &now
+		serviceEnt.DecidedBy = userId
+		serviceEnt.Comment = req.Comment
+		decidedServiceEnts = append(decidedServiceEnts, *serviceEnt)
+		action := view.SnapshotApprovalActionApproved
+		if req.Status == view.SnapshotApprovalRejected {
+			action = view.SnapshotApprovalActionRejected
+		}
+		eventEnts = append(eventEnts, makeSnapshotApprovalEventEntity(ctx, ent.ApprovalId, serviceEnt.ServiceId, action, req.Comment, now))
+	}
+	ent.Status = string(getSnapshotApprovalStatus(serviceEnts))
+	ent.UpdatedAt = now
+	err = s.snapshotApprovalRepository.SaveSnapshotApprovalDecision(ent, decidedServiceEnts, eventEnts)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("Services of snapshot %s of namespace %s are %s by %s, approval status is %s", ent.Version, namespace, req.Status, userId, ent.Status)
+	return s.makeSnapshotApprovalView(*ent)
+}
+
+// CommentSnapshotApproval is allowed to approvers and users who manage draft snapshots of the namespace
+func (s snapshotApprovalServiceImpl) CommentSnapshotApproval(ctx context.Context, namespace string, workspaceId string, version string, cloudName string, req view.SnapshotApprovalCommentReq) (*view.SnapshotApproval, error) {
+	approvers, err := s.getApprovers(workspaceId)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(approvers, secctx.GetUserId(ctx)) {
+		err = s.checkSnapshotGroupPermission(ctx, makeSnapshotGroupId(workspaceId, cloudName, namespace), view.MakeManageVersionPermission(string(view.DraftStatus)))
+		if err != nil {
+			return nil, err
+		}
+	}
+	ent, err := s.getSnapshotApproval(ctx, namespace, workspaceId, version, cloudName)
+	if err != nil {
+		return nil, err
+	}
+	eventEnt := makeSnapshotApprovalEventEntity(ctx, ent.ApprovalId, strings.ToLower(req.ServiceId), view.SnapshotApprovalActionCommented, req.Comment, time.Now())
+	err = s.snapshotApprovalRepository.AddSnapshotApprovalEvent(&eventEnt)
+	if err != nil {
+		return nil, err
+	}
+	return s.makeSnapshotApprovalView(*ent)
+}
+
+func (s snapshotApprovalServiceImpl) CheckSnapshotApproved(ctx context.Context, namespace string, workspaceId string, version string, cloudName string, serviceIds []string) error {
+	approvers, err := s.getApprovers(workspaceId)
+	if err != nil {
+		return err
+	}
+	if len(approvers) == 0 {
+		return nil
+	}
+	notApprovedErr := func(version string, services []string) error {
+		return &exception.CustomError{
+			Status:  http.StatusForbidden,
+			Code:    exception.SnapshotNotApproved,
+			Message: exception.SnapshotNotApprovedMsg,
+			Params:  map[string]interface{}{"version": version, "services": strings.Join(services, ", ")},
+		}
+	}
+	dashboardId := makeSnapshotDashboardId(workspaceId, cloudName, namespace)
+	versionContent, err := s.apihubClient.GetVersion(ctx, dashboardId, version)
+	if err != nil {
+		return err
+	}
+	if versionContent == nil {
+		return notApprovedErr(version, serviceIds)
+	}
+	ent, err := s.snapshotApprovalRepository.GetLatestSnapshotApproval(dashboardId, versionContent.Version)
+	if err != nil {
+		return err
+	}
+	if ent == nil {
+		return notApprovedErr(versionContent.Version, serviceIds)
+	}
+	serviceEnts, err := s.snapshotApprovalRepository.GetSnapshotApprovalServices(ent.ApprovalId)
+	if err != nil {
+		return err
+	}
+	var notApprovedServices []string
+	for _, serviceId := range serviceIds {
+		if !slices.ContainsFunc(serviceEnts, func(serviceEnt entity.SnapshotApprovalServiceEntity) bool {
+			return serviceEnt.ServiceId == strings.ToLower(serviceId) && serviceEnt.Status == string(view.SnapshotApprovalApproved)
+		}) {
+			notApprovedServices = append(notApprovedServices, serviceId)
+		}
+	}
+	if len(notApprovedServices) > 0 {
+		return notApprovedErr(versionContent.Version, notApprovedServices)
+	}
+	return nil
+}
+
+func (s snapshotApprovalServiceImpl) DeleteSnapshotApprovals(dashboardId string, version string) error {
+	return s.snapshotApprovalRepository.DeleteSnapshotApprovals(dashboardId, strings.Split(version, "@")[0])
+}
+
+// checkSnapshotGroupPermission checks that the user has the permission in the namespace group of the snapshot
+func (s snapshotApprovalServiceImpl) checkSnapshotGroupPermission(ctx context.Context, groupId string, permission string) error {
+	sufficientPrivileges, err := s.permissionService.HasPackagePermission(ctx, groupId, permission)
+	if err != nil {
+		return fmt.Errorf("failed to check namespace group permissions: %v", err.Error())
+	}
+	if !sufficientPrivileges {
+		return &exception.CustomError{
+			Status:  http.StatusForbidden,
+			Code:    exception.InsufficientPrivileges,
+			Message: exception.InsufficientPrivilegesMsg,
+			Debug:   fmt.Sprintf("%s permission is required in group %s", permission, groupId),
+		}
+	}
+	return nil
+}
+
+func (s snapshotApprovalServiceImpl) getApprovers(workspaceId string) ([]string, error) {
+	ent, err := s.snapshotApprovalRepository.GetSnapshotApprovers(workspaceId)
+	if err != nil {
+		return nil, err
+	}
+	if ent == nil {
+		return nil, nil
+	}
+	return ent.Approvers, nil
+}
+
+// getSnapshotApproval returns the last approval of the latest snapshot revision
+func (s snapshotApprovalServiceImpl) getSnapshotApproval(ctx context.Context, namespace string, workspaceId string, version string, cloudName string) (*entity.SnapshotApprovalEntity, error) {
+	dashboardId := makeSnapshotDashboardId(workspaceId, cloudName, namespace)
+	versionContent, err := s.apihubClient.GetVersion(ctx, dashboardId, version)
+	if err != nil {
+		return nil, err
+	}
+	if versionContent == nil {
+		return nil, &exception.CustomError{
+			Status:  http.StatusNotFound,
+			Code:    exception.SnapshotNotFound,
+			Message: exception.SnapshotNotFoundMsg,
+			Params:  map[string]interface{}{"version": version},
+			Debug:   fmt.Sprintf("dashboard %s", dashboardId),
+		}
+	}
+	ent, err := s.snapshotApprovalRepository.GetLatestSnapshotApproval(dashboardId, versionContent.Version)
+	if err != nil {
+		return nil, err
+	}
+	if ent == nil {
+		return nil, &exception.CustomError{
+			Status:  http.StatusNotFound,
+			Code:    exception.SnapshotApprovalNotFound,
+			Message: exception.SnapshotApprovalNotFoundMsg,
+			Params:  map[string]interface{}{"version": versionContent.Version},
+		}
+	}
+	return ent, nil
+}
+
+func (s snapshotApprovalServiceImpl) makeSnapshotApprovalView(ent entity.SnapshotApprovalEntity) (*view.SnapshotApproval, error) {
+	serviceEnts, err := s.snapshotApprovalRepository.GetSnapshotApprovalServices(ent.ApprovalId)
+	if err != nil {
+		return nil, err
+	}
+	eventEnts, err := s.snapshotApprovalRepository.GetSnapshotApprovalEvents(ent.ApprovalId)
+	if err != nil {
+		return nil, err
+	}
+	result := entity.MakeSnapshotApprovalView(ent, serviceEnts, eventEnts)
+	return &result, nil
+}
+
+func makeSnapshotApprovalEventEntity(ctx context.Context, approvalId string, serviceId string, action view.SnapshotApprovalAction, comment string, createdAt time.Time) entity.SnapshotApprovalEventEntity {
+	return entity.SnapshotApprovalEventEntity{
+		EventId:    uuid.New().String(),
+		ApprovalId: approvalId,
+		ServiceId:  serviceId,
+		Action:     string(action),
+		Comment:    comment,
+		CreatedAt:  createdAt,
+		CreatedBy:  secctx.GetUserId(ctx),
+	}
+}
+
+// getSnapshotApprovalStatus returns rejected if at least one service is rejected and approved if all services are approved
+func getSnapshotApprovalStatus(serviceEnts []entity.SnapshotApprovalServiceEntity) view.SnapshotApprovalStatus {
+	result := view.SnapshotApprovalApproved
+	for _, serviceEnt := range serviceEnts {
+		switch view.SnapshotApprovalStatus(serviceEnt.Status) {
+		case view.SnapshotApprovalRejected:
+			return view.SnapshotApprovalRejected
+		case view.SnapshotApprovalPending:
+			result = view.SnapshotApprovalPending
+		}
+	}
+	return result
+}
+
+func makeSnapshotGroupId(workspaceId string, cloudName string, namespace string) string {
+	return fmt.Sprintf("%s.%s.%s.%s", workspaceId, view.DefaultSnapshotsGroupAlias, utils.ToId(cloudName), utils.ToId(namespace))
+}
+
+func makeSnapshotDashboardId(workspaceId string, cloudName string, namespace string) string {
+	return view.MakeSnapshotDashboardIdByGroupId(makeSnapshotGroupId(workspaceId, cloudName, namespace))
+}
@@ -0,0 +1,375 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Netcracker/qubership-apihub-agents-backend/exception"
+	"github.com/Netcracker/qubership-apihub-agents-backend/secctx"
+	"github.com/Netcracker/qubership-apihub-agents-backend/utils"
+	"github.com/Netcracker/qubership-apihub-agents-backend/view"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+)
+
+const snapshotBundleConcurrency = 10
+const snapshotBundleDocumentsLimit = 100
+
+func (s *snapshotServiceImpl) GetSnapshotBundle(ctx context.Context, namespace string, workspaceId string, version string, cloudName string) (*view.SnapshotBundleManifest, error) {
+	groupId := fmt.Sprintf("%s.%s.%s.%s", workspaceId, view.DefaultSnapshotsGroupAlias, utils.ToId(cloudName), utils.ToId(namespace))
+	dashboardId := view.MakeSnapshotDashboardIdByGroupId(groupId)
+
+	side, refs, err := s.getSnapshotRefs(ctx, dashboardId, version)
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := s.GetSnapshot(ctx, namespace, workspaceId, version, cloudName)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceIds := make([]string, 0, len(refs))
+	for id := range refs {
+		serviceIds = append(serviceIds, id)
+	}
+	slices.Sort(serviceIds)
+
+	manifest := view.SnapshotBundleManifest{
+		FormatVersion: view.SnapshotBundleFormatVersion,
+		ApihubUrl:     s.systemInfoService.GetApihubUrl(),
+		WorkspaceId:   workspaceId,
+		CloudName:     cloudName,
+		Namespace:     namespace,
+		PackageId:     dashboardId,
+		Version:       side.Version,
+		PublishedAt:   side.PublishedAt,
+		ExportedAt:    time.Now(),
+		Services:      make([]view.SnapshotBundleService, len(serviceIds)),
+		Snapshot:      snapshot,
+	}
+	errGrp := errgroup.Group{}
+	errGrp.SetLimit(snapshotBundleConcurrency)
+	for i, id := range serviceIds {
+		ref := refs[id]
+		errGrp.Go(func() error {
+			svc, err := s.getSnapshotBundleService(ctx, id, ref)
+			if err != nil {
+				return err
+			}
+			manifest.Services[i] = *svc
+			return nil
+		})
+	}
+	err = errGrp.Wait()
+	if err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+func (s *snapshotServiceImpl) getSnapshotBundleService(ctx context.Context, serviceId string, ref view.PackageVersionRef) (*view.SnapshotBundleService, error) {
+	pkg, err := s.apihubClient.GetPackageById(ctx, ref.RefPackageId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get package %s: %v", ref.RefPackageId, err.Error())
+	}
+	packageVersion, err := s.apihubClient.GetVersion(ctx, ref.RefPackageId, ref.RefPackageVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get version %s of package %s: %v", ref.RefPackageVersion, ref.RefPackageId, err.Error())
+	}
+	if pkg == nil || packageVersion == nil {
+		return nil, fmt.Errorf("version %s of package %s not found", ref.RefPackageVersion, ref.RefPackageId)
+	}
+	result := view.SnapshotBundleService{
+		Id:                       serviceId,
+		Name:                     pkg.Name,
+		PackageId:                ref.RefPackageId,
+		Version:                  ref.RefPackageVersion,
+		Status:                   packageVersion.Status,
+		Labels:                   packageVersion.VersionLabels,
+		PreviousVersion:          packageVersion.PreviousVersion,
+		PreviousVersionPackageId: packageVersion.PreviousVersionPackageId,
+		Files:                    make([]view.SnapshotBundleFile, 0),
+	}
+	if result.Labels == nil {
+		result.Labels = make([]string, 0)
+	}
+	for page := 0; ; page++ {
+		documents, err := s.apihubClient.GetVersionDocuments(ctx, ref.RefPackageId, ref.RefPackageVersion, snapshotBundleDocumentsLimit, page)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get documents of version %s of package %s: %v", ref.RefPackageVersion, ref.RefPackageId, err.Error())
+		}
+		if documents == nil {
+			break
+		}
+		for _, document := range documents.Documents {
+			result.Files = append(result.Files, view.SnapshotBundleFile{
+				FileId: document.FileId,
+				Slug:   document.Slug,
+				Path:   fmt.Sprintf("services/%s/%s", serviceId, document.FileId),
+				Type:   document.Type,
+				Format: document.Format,
+			})
+		}
+		if len(documents.Documents) < snapshotBundleDocumentsLimit {
+			break
+		}
+	}
+	return &result, nil
+}
+
+// WriteSnapshotBundle streams the bundle archive, spec files are fetched from APIHUB one by one
+func (s *snapshotServiceImpl) WriteSnapshotBundle(ctx context.Context, manifest *view.SnapshotBundleManifest, w io.Writer) error {
+	zw := zip.NewWriter(w)
+	manifestBytes, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = addFileToZip(zw, view.SnapshotBundleManifestFile, manifestBytes)
+	if err != nil {
+		return err
+	}
+	for _, svc := range manifest.Services {
+		for _, file := range svc.Files {
+			content, err := s.apihubClient.GetVersionDocumentRaw(ctx, svc.PackageId, svc.Version, file.Slug)
+			if err != nil {
+				return err
+			}
+			if content == nil {
+				return fmt.Errorf("document %s of version %s of package %s not found", file.Slug, svc.Version, svc.PackageId)
+			}
+			err = addFileToZip(zw, file.Path, content)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return zw.Close()
+}
+
+func (s *snapshotServiceImpl) ImportSnapshotBundle(ctx context.Context, workspaceId string, bundle io.ReaderAt, size int64, req view.ImportSnapshotBundleReq) (*view.CreateSnapshotResponse, error) {
+	err := checkWorkspaceManagePermission(ctx, s.apihubClient, s.permissionService, workspaceId)
+	if err != nil {
+		return nil, err
+	}
+	invalidBundle := func(reason string) error {
+		return &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.InvalidSnapshotBundle,
+			Message: exception.InvalidSnapshotBundleMsg,
+			Params:  map[string]interface{}{"reason": reason},
+		}
+	}
+
+	zr, err := zip.NewReader(bundle, size)
+	if err != nil {
+		return nil, invalidBundle(err.Error())
+	}
+	bundleFiles := make(map[string]*zip.File, len(zr.File))
+	for _, file := range zr.File {
+		bundleFiles[file.Name] = file
+	}
+	manifest, err := readSnapshotBundleManifest(bundleFiles[view.SnapshotBundleManifestFile])
+	if err != nil {
+		return nil, invalidBundle(err.Error())
+	}
+	if manifest.FormatVersion != view.SnapshotBundleFormatVersion {
+		return nil, invalidBundle(fmt.Sprintf("unsupported format version %d", manifest.FormatVersion))
+	}
+	cloudName, namespace, version := manifest.CloudName, manifest.Namespace, strings.Split(manifest.Version, "@")[0]
+	if req.CloudName != "" {
+		cloudName = req.CloudName
+	}
+	if req.Namespace != "" {
+		namespace = req.Namespace
+	}
+	if req.Version != "" {
+		version = req.Version
+	}
+	if cloudName == "" || namespace == "" {
+		return nil, invalidBundle("cloud name and namespace are not set")
+	}
+	err = validateVersionName(version)
+	if err != nil {
+		return nil, err
+	}
+	// release versions are created only by the promotion which checks allowed statuses, release version pattern and approval
+	if req.Status != "" && !slices.Contains([]view.VersionStatus{view.DraftStatus, view.ArchivedStatus}, view.VersionStatus(req.Status)) {
+		return nil, &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.InvalidParameter,
+			Message: exception.InvalidParameterMsg,
+			Params:  map[string]interface{}{"param": "status"},
+			Debug:   fmt.Sprintf("allowed values are %s and %s, use the snapshot promotion to release", view.DraftStatus, view.ArchivedStatus),
+		}
+	}
+	if len(manifest.Services) == 0 {
+		return nil, invalidBundle("bundle has no services")
+	}
+	services := make([]view.Service, len(manifest.Services))
+	serviceIds := make(map[string]bool, len(manifest.Services))
+	for i, svc := range manifest.Services {
+		if svc.Id == "" {
+			return nil, invalidBundle("service id is not set")
+		}
+		if serviceIds[strings.ToLower(svc.Id)] {
+			return nil, invalidBundle(fmt.Sprintf("service %s is listed more than once", svc.Id))
+		}
+		serviceIds[strings.ToLower(svc.Id)] = true
+		if svc.Status != "" && !slices.Contains([]view.VersionStatus{view.DraftStatus, view.ReleaseStatus, view.ArchivedStatus}, view.VersionStatus(svc.Status)) {
+			return nil, invalidBundle(fmt.Sprintf("unknown status %s of service %s", svc.Status, svc.Id))
+		}
+		for _, file := range svc.Files {
+			if _, exists := bundleFiles[file.Path]; !exists {
+				return nil, invalidBundle(fmt.Sprintf("file %s of service %s not found", file.Path, svc.Id))
+			}
+		}
+		services[i] = view.Service{Id: svc.Id, Name: svc.Name}
+	}
+
+	sysCtx := secctx.MakeSysadminContext(ctx) // Create groups using api-key since user may not have enough privileges
+	groupId, err := s.prepareNamespaceGroup(sysCtx, namespace, workspaceId, cloudName)
+	if err != nil {
+		return nil, fmt.Errorf("prepare snapshot failed: %s", err.Error())
+	}
+	dashboardId, err := s.prepareDashboard(sysCtx, groupId)
+	if err != nil {
+		return nil, fmt.Errorf("prepare snapshot failed: %s", err.Error())
+	}
+	packageIds, err := s.preparePackages(sysCtx, services, groupId)
+	if err != nil {
+		return nil, fmt.Errorf("prepare snapshot failed: %s", err.Error())
+	}
+
+	configs := make([]view.BuildConfig, len(manifest.Services))
+	refs := make([]view.BCRef, len(manifest.Services))
+	for i, svc := range manifest.Services {
+		status := req.Status
+		if status == "" {
+			status = svc.Status
+		}
+		if status == "" || status == string(view.ReleaseStatus) {
+			status = string(view.DraftStatus)
+		}
+		configs[i] = view.BuildConfig{
+			PackageId:        packageIds[i],
+			Version:          version,
+			Status:           status,
+			Files:            make([]view.BCFile, 0, len(svc.Files)),
+			Refs:             make([]view.BCRef, 0),
+			PublishId:        uuid.New().String(),
+			ServiceId:        svc.Id,
+			ApihubPackageUrl: fmt.Sprintf("%s/portal/packages/%s/%s/overview/summary", s.systemInfoService.GetApihubUrl(), packageIds[i], url.PathEscape(version)),
+			CreatedBy:        secctx.GetUserId(ctx),
+			Metadata: map[string]interface{}{
+				"versionLabels": svc.Labels,
+				"cloudName":     cloudName,
+				"namespace":     namespace,
+				"importedFrom":  fmt.Sprintf("%s %s@%s", manifest.ApihubUrl, svc.PackageId, svc.Version),
+			},
+			BuildType: view.BuildType,
+		}
+		for _, file := range svc.Files {
+			configs[i].Files = append(configs[i].Files, view.BCFile{FileId: file.FileId, Publish: true, Labels: make([]string, 0)})
+		}
+		refs[i] = view.BCRef{RefId: packageIds[i], Version: version}
+	}
+
+	// the bundle is not available after the request is completed, so publish requests are sent synchronously
+	publishIds := make([]string, len(configs))
+	errGrp := errgroup.Group{}
+	errGrp.SetLimit(snapshotPublishConcurrency)
+	for i, svc := range manifest.Services {
+		errGrp.Go(func() error {
+			zipReader, zipWriter := io.Pipe()
+			utils.SafeAsync(func() {
+				checksum, err := writeBundleServiceZip(zipWriter, bundleFiles, svc)
+				if err == nil {
+					configs[i].Metadata[view.SpecChecksumMetadataKey] = checksum
+				}
+				zipWriter.CloseWithError(err)
+			})
+			_, err := s.apihubClient.Publish(ctx, &configs[i], zipReader, false, "", false, nil)
+			zipReader.Close()
+			if err != nil {
+				return fmt.Errorf("failed to publish service %s: %v", svc.Id, err.Error())
+			}
+			publishIds[i] = configs[i].PublishId
+			return nil
+		})
+	}
+	err = errGrp.Wait()
+	if err != nil {
+		return nil, err
+	}
+
+	groupBuildConfig := view.BuildConfig{
+		PackageId: dashboardId,
+		Version:   version,
+		Status:    string(view.DraftStatus),
+		Files:     make([]view.BCFile, 0),
+		Refs:      refs,
+		PublishId: uuid.New().String(),
+		CreatedBy: secctx.GetUserId(ctx),
+		Metadata:  map[string]interface{}{},
+		BuildType: view.BuildType,
+	}
+	_, err = s.apihubClient.Publish(ctx, &groupBuildConfig, nil, false, "", false, publishIds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to publish snapshot: %v", err.Error())
+	}
+	log.Infof("Snapshot %s imported to workspace %s from %s", version, workspaceId, manifest.ApihubUrl)
+
+	return &view.CreateSnapshotResponse{
+		Snapshot: &view.GroupBuildConfig{
+			PackageId: groupBuildConfig.PackageId,
+			PublishId: groupBuildConfig.PublishId,
+		},
+		Services: configs,
+	}, nil
+}
+
+func readSnapshotBundleManifest(file *zip.File) (*view.SnapshotBundleManifest, error) {
+	if file == nil {
+		return nil, fmt.Errorf("%s not found", view.SnapshotBundleManifestFile)
+	}
+	rc, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	var manifest view.SnapshotBundleManifest
+	err = json.NewDecoder(rc).Decode(&manifest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", view.SnapshotBundleManifestFile, err.Error())
+	}
+	return &manifest, nil
+}
+
+func writeBundleServiceZip(w io.Writer, bundleFiles map[string]*zip.File, svc view.SnapshotBundleService) (string, error) {
+	zw := zip.NewWriter(w)
+	checksum := newSpecsChecksum()
+	for _, file := range svc.Files {
+		rc, err := bundleFiles[file.Path].Open()
+		if err != nil {
+			return "", err
+		}
+		fw, err := zw.Create(file.FileId)
+		if err == nil {
+			_, err = io.Copy(io.MultiWriter(fw, checksum.addDocument(file.FileId)), rc)
+		}
+		rc.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+	return checksum.String(), zw.Close()
+}
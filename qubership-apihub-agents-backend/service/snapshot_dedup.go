@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Netcracker/qubership-apihub-agents-backend/utils"
+	"github.com/Netcracker/qubership-apihub-agents-backend/view"
+	"golang.org/x/sync/errgroup"
+)
+
+const snapshotDedupConcurrency = 10
+const snapshotDedupVersionsLimit = 10
+
+// specsChecksum calculates the checksum of the service documents while they are written, file ids are included to detect renamed files.
+// Streamed documents are not kept in memory, the result is the same as utils.GetEncodedChecksum of the same parts
+type specsChecksum struct {
+	hash hash.Hash
+}
+
+func newSpecsChecksum() *specsChecksum {
+	return &specsChecksum{hash: md5.New()}
+}
+
+// addDocument starts the next document, the returned writer accepts the document content
+func (c *specsChecksum) addDocument(fileId string) io.Writer {
+	c.hash.Write(specsChecksumDocumentHeader(fileId))
+	return c.hash
+}
+
+func specsChecksumDocumentHeader(fileId string) []byte {
+	return []byte(fileId + "\x00")
+}
+
+func (c *specsChecksum) String() string {
+	return hex.EncodeToString(c.hash.Sum(nil))
+}
+
+// getServiceSpecsChecksum calculates the checksum of the downloaded service documents
+func getServiceSpecsChecksum(documents []view.Document, contents [][]byte) string {
+	parts := make([][]byte, 0, 2*len(documents))
+	for i, document := range documents {
+		parts = append(parts, specsChecksumDocumentHeader(document.FileId), contents[i])
+	}
+	return utils.GetEncodedChecksum(parts...)
+}
+
+// getUnchangedServiceVersions returns the last published version of each service package if it has the same documents checksum, labels, status
+// and baseline version which the new snapshot would compare the service with. An empty string is returned for the services which have to be published.
+func (s *snapshotServiceImpl) getUnchangedServiceVersions(ctx context.Context, packageIds []string, services []view.Service, checksums []string, status string, previousVersion string) ([]string, error) {
+	// the cleanup job keeps service versions referenced by remaining snapshots, the age limit only prevents keeping old versions alive forever
+	publishedAfter := time.Now().Add(-time.Duration(s.systemInfoService.GetSnapshotsTTLDays()) * 24 * time.Hour / 2)
+	result := make([]string, len(services))
+	errGrp := errgroup.Group{}
+	errGrp.SetLimit(snapshotDedupConcurrency)
+	for i, svc := range services {
+		errGrp.Go(func() error {
+			version, err := s.getUnchangedServiceVersion(ctx, packageIds[i], svc, checksums[i], status, previousVersion, publishedAfter)
+			if err != nil {
+				return err
+			}
+			result[i] = version
+			return nil
+		})
+	}
+	err := errGrp.Wait()
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *snapshotServiceImpl) getUnchangedServiceVersion(ctx context.Context, packageId string, svc view.Service, checksum string, status string, previousVersion string, publishedAfter time.Time) (string, error) {
+	versions, err := s.apihubClient.GetVersions(ctx, packageId, view.VersionSearchRequest{Limit: snapshotDedupVersionsLimit})
+	if err != nil {
+		return "", fmt.Errorf("failed to get versions of package %s: %v", packageId, err.Error())
+	}
+	if versions == nil {
+		return "", nil
+	}
+	var lastVersion *view.PublishedVersionListView
+	for i, version := range versions.Versions {
+		// security check versions are temporary
+		if !strings.HasPrefix(version.Version, authSecurityCheckVersionPrefix) {
+			lastVersion = &versions.Versions[i]
+			break
+		}
+	}
+	if lastVersion == nil || lastVersion.Status != status || lastVersion.CreatedAt.Before(publishedAfter) {
+		return "", nil
+	}
+	versionContent, err := s.apihubClient.GetVersion(ctx, packageId, lastVersion.Version)
+	if err != nil {
+		return "", fmt.Errorf("failed to get version %s of package %s: %v", lastVersion.Version, packageId, err.Error())
+	}
+	if versionContent == nil {
+		return "", nil
+	}
+	if lastChecksum, _ := versionContent.Metadata[view.SpecChecksumMetadataKey].(string); lastChecksum != checksum {
+		return "", nil
+	}
+	labels := make([]string, 0, len(svc.Labels))
+	for k, v := range svc.Labels {
+		labels = append(labels, fmt.Sprintf("%s:%s", k, v))
+	}
+	lastLabels := slices.Clone(versionContent.VersionLabels)
+	slices.Sort(labels)
+	slices.Sort(lastLabels)
+	if !slices.Equal(labels, lastLabels) {
+		return "", nil
+	}
+	previousVersion, previousVersionPackageId, err := s.getServiceBaselineVersion(ctx, svc, previousVersion)
+	if err != nil {
+		return "", err
+	}
+	// changes of the reused version are calculated against its own baseline
+	if strings.Split(versionContent.PreviousVersion, "@")[0] != previousVersion || versionContent.PreviousVersionPackageId != previousVersionPackageId {
+		return "", nil
+	}
+	return lastVersion.Version, nil
+}
+
+// getServiceBaselineVersion returns the previous version and its package which the service version is published with,
+// the same way as startSnapshot does it: draft and missing baseline versions are not used
+func (s *snapshotServiceImpl) getServiceBaselineVersion(ctx context.Context, svc view.Service, previousVersion string) (string, string, error) {
+	if previousVersion == "" || svc.Baseline == nil {
+		return "", "", nil
+	}
+	baselineVersion, err := s.apihubClient.GetVersion(ctx, svc.Baseline.PackageId, previousVersion)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get previous version %s of package %s: %v", previousVersion, svc.Baseline.PackageId, err.Error())
+	}
+	if baselineVersion == nil || baselineVersion.Status == string(view.DraftStatus) {
+		return "", "", nil
+	}
+	return strings.Split(previousVersion, "@")[0], svc.Baseline.PackageId, nil
+}
@@ -0,0 +1,318 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/Netcracker/qubership-apihub-agents-backend/exception"
+	"github.com/Netcracker/qubership-apihub-agents-backend/secctx"
+	"github.com/Netcracker/qubership-apihub-agents-backend/utils"
+	"github.com/Netcracker/qubership-apihub-agents-backend/view"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+)
+
+// DeleteSnapshot deletes the snapshot dashboard version and service versions published by the snapshot.
+// Service versions referenced by other snapshots of the namespace are kept
+func (s *snapshotServiceImpl) DeleteSnapshot(ctx context.Context, namespace string, workspaceId string, version string, cloudName string) (*view.DeleteSnapshotResponse, error) {
+	groupId := fmt.Sprintf("%s.%s.%s.%s", workspaceId, view.DefaultSnapshotsGroupAlias, utils.ToId(cloudName), utils.ToId(namespace))
+	dashboardId := view.MakeSnapshotDashboardIdByGroupId(groupId)
+
+	versionContent, err := s.apihubClient.GetVersion(ctx, dashboardId, version)
+	if err != nil {
+		return nil, err
+	}
+	if versionContent == nil {
+		return nil, &exception.CustomError{
+			Status:  http.StatusNotFound,
+			Code:    exception.SnapshotNotFound,
+			Message: exception.SnapshotNotFoundMsg,
+			Params:  map[string]interface{}{"version": version},
+			Debug:   fmt.Sprintf("dashboard %s", dashboardId),
+		}
+	}
+	err = s.checkSnapshotGroupPermission(ctx, groupId, versionContent.Status)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.DeleteNamespaceSnapshots(secctx.MakeSysadminContext(ctx), groupId, []string{versionContent.Version}) // snapshot packages are created using api-key
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, &exception.CustomError{
+			Status:  http.StatusNotFound,
+			Code:    exception.SnapshotNotFound,
+			Message: exception.SnapshotNotFoundMsg,
+			Params:  map[string]interface{}{"version": version},
+			Debug:   fmt.Sprintf("dashboard %s", dashboardId),
+		}
+	}
+	log.Infof("Snapshot %s of namespace %s is deleted by %s, %d service versions are deleted", versionContent.Version, namespace, secctx.GetUserId(ctx), len(result[0].DeletedServices))
+	return &result[0], nil
+}
+
+func (s *snapshotServiceImpl) DeleteNamespaceSnapshots(ctx context.Context, namespaceGroupId string, versions []string) ([]view.DeleteSnapshotResponse, error) {
+	if len(versions) == 0 {
+		return make([]view.DeleteSnapshotResponse, 0), nil
+	}
+	dashboardId := view.MakeSnapshotDashboardIdByGroupId(namespaceGroupId)
+	deletedVersions := make(map[string]struct{}, len(versions))
+	for _, version := range versions {
+		deletedVersions[strings.Split(version, "@")[0]] = struct{}{}
+	}
+	otherRefs, err := s.getOtherSnapshotsRefs(ctx, dashboardId, deletedVersions)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]view.DeleteSnapshotResponse, 0, len(versions))
+	for _, version := range versions {
+		versionContent, refs, err := getSnapshotVersionRefs(ctx, s.apihubClient, dashboardId, version)
+		if err != nil {
+			if customError, ok := err.(*exception.CustomError); ok && customError.Code == exception.SnapshotNotFound {
+				// already deleted concurrently
+				continue
+			}
+			return result, err
+		}
+		deleted, err := s.deleteSnapshot(ctx, namespaceGroupId, versionContent.Version, refs, otherRefs)
+		if err != nil {
+			return result, err
+		}
+		result = append(result, *deleted)
+	}
+	return result, nil
+}
+
+func (s *snapshotServiceImpl) HasReferencedSnapshots(ctx context.Context, namespaceGroupId string, versions []string) (bool, error) {
+	if len(versions) == 0 {
+		return false, nil
+	}
+	dashboardId := view.MakeSnapshotDashboardIdByGroupId(namespaceGroupId)
+	snapshotVersions := make(map[string]struct{}, len(versions))
+	for _, version := range versions {
+		snapshotVersions[strings.Split(version, "@")[0]] = struct{}{}
+	}
+	otherRefs, err := s.getOtherSnapshotsRefs(ctx, dashboardId, snapshotVersions)
+	if err != nil {
+		return false, err
+	}
+	for ref := range otherRefs {
+		// service versions published by the snapshot have the snapshot version
+		packageId, version, _ := strings.Cut(ref, "|")
+		if _, exists := snapshotVersions[version]; exists && strings.HasPrefix(packageId, namespaceGroupId+".") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// deleteSnapshot deletes the dashboard version and service versions published by the snapshot which are not in otherRefs
+func (s *snapshotServiceImpl) deleteSnapshot(ctx context.Context, groupId string, version string, refs map[string]view.PackageVersionRef, otherRefs map[string]struct{}) (*view.DeleteSnapshotResponse, error) {
+	dashboardId := view.MakeSnapshotDashboardIdByGroupId(groupId)
+	snapshotVersion := strings.Split(version, "@")[0]
+	result := view.DeleteSnapshotResponse{
+		PackageId:       dashboardId,
+		Version:         version,
+		DeletedServices: make([]view.SnapshotServiceVersion, 0),
+		KeptServices:    make([]view.SnapshotServiceVersion, 0),
+	}
+	serviceIds := make([]string, 0, len(refs))
+	for serviceId := range refs {
+		serviceIds = append(serviceIds, serviceId)
+	}
+	slices.Sort(serviceIds)
+	for _, serviceId := range serviceIds {
+		ref := refs[serviceId]
+		serviceVersion := view.SnapshotServiceVersion{ServiceId: serviceId, PackageId: ref.RefPackageId, Version: ref.RefPackageVersion}
+		refVersion := strings.Split(ref.RefPackageVersion, "@")[0]
+		// unchanged services reference versions published by previous snapshots
+		_, referenced := otherRefs[ref.RefPackageId+"|"+refVersion]
+		if referenced || refVersion != snapshotVersion || !strings.HasPrefix(ref.RefPackageId, groupId+".") {
+			result.KeptServices = append(result.KeptServices, serviceVersion)
+		} else {
+			result.DeletedServices = append(result.DeletedServices, serviceVersion)
+		}
+	}
+
+	err := s.apihubClient.DeleteVersion(ctx, dashboardId, snapshotVersion)
+	if err != nil {
+		return nil, err
+	}
+	errGrp := errgroup.Group{}
+	errGrp.SetLimit(snapshotCompareConcurrency)
+	for _, serviceVersion := range result.DeletedServices {
+		errGrp.Go(func() error {
+			return s.apihubClient.DeleteVersion(ctx, serviceVersion.PackageId, snapshotVersion)
+		})
+	}
+	err = errGrp.Wait()
+	if err != nil {
+		return nil, fmt.Errorf("snapshot %s is deleted, but failed to delete service versions: %v", version, err.Error())
+	}
+	s.summaryCache.Delete(dashboardId + "|" + version)
+	err = s.snapshotApprovalService.DeleteSnapshotApprovals(dashboardId, snapshotVersion)
+	if err != nil {
+		log.Errorf("Failed to delete approvals of snapshot %s of package %s: %s", version, dashboardId, err.Error())
+	}
+	return &result, nil
+}
+
+// UpdateSnapshot changes status or labels of the snapshot dashboard version
+func (s *snapshotServiceImpl) UpdateSnapshot(ctx context.Context, namespace string, workspaceId string, version string, cloudName string, req view.UpdateSnapshotReq) (*view.SnapshotListItem, error) {
+	groupId := fmt.Sprintf("%s.%s.%s.%s", workspaceId, view.DefaultSnapshotsGroupAlias, utils.ToId(cloudName), utils.ToId(namespace))
+	dashboardId := view.MakeSnapshotDashboardIdByGroupId(groupId)
+
+	if req.Status == nil && req.Labels == nil {
+		return nil, &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.RequiredParamsMissing,
+			Message: exception.RequiredParamsMissingMsg,
+			Params:  map[string]interface{}{"params": "status, labels"},
+		}
+	}
+	if req.Status != nil && !slices.Contains([]view.VersionStatus{view.DraftStatus, view.ReleaseStatus, view.ArchivedStatus}, view.VersionStatus(*req.Status)) {
+		return nil, &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.InvalidParameter,
+			Message: exception.InvalidParameterMsg,
+			Params:  map[string]interface{}{"param": "status"},
+			Debug:   fmt.Sprintf("allowed values are %s, %s and %s", view.DraftStatus, view.ReleaseStatus, view.ArchivedStatus),
+		}
+	}
+	versionContent, err := s.apihubClient.GetVersion(ctx, dashboardId, version)
+	if err != nil {
+		return nil, err
+	}
+	if versionContent == nil {
+		return nil, &exception.CustomError{
+			Status:  http.StatusNotFound,
+			Code:    exception.SnapshotNotFound,
+			Message: exception.SnapshotNotFoundMsg,
+			Params:  map[string]interface{}{"version": version},
+			Debug:   fmt.Sprintf("dashboard %s", dashboardId),
+		}
+	}
+	statuses := []string{versionContent.Status}
+	if req.Status != nil {
+		statuses = append(statuses, *req.Status)
+	}
+	err = s.checkSnapshotGroupPermission(ctx, groupId, statuses...)
+	if err != nil {
+		return nil, err
+	}
+	if req.Status != nil && *req.Status == string(view.ReleaseStatus) && versionContent.Status != string(view.ReleaseStatus) {
+		_, refs, err := getSnapshotVersionRefs(ctx, s.apihubClient, dashboardId, versionContent.Version)
+		if err != nil {
+			return nil, err
+		}
+		serviceIds := make([]string, 0, len(refs))
+		for serviceId := range refs {
+			serviceIds = append(serviceIds, serviceId)
+		}
+		slices.Sort(serviceIds)
+		err = s.snapshotApprovalService.CheckSnapshotApproved(ctx, namespace, workspaceId, versionContent.Version, cloudName, serviceIds)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	updatedVersion, err := s.apihubClient.UpdateVersionMeta(secctx.MakeSysadminContext(ctx), dashboardId, strings.Split(versionContent.Version, "@")[0], view.VersionPatchReq{
+		Status:        req.Status,
+		VersionLabels: req.Labels,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if updatedVersion == nil {
+		return nil, fmt.Errorf("version %s of package %s not found", versionContent.Version, dashboardId)
+	}
+	log.Infof("Snapshot %s of namespace %s is updated by %s", versionContent.Version, namespace, secctx.GetUserId(ctx))
+	return &view.SnapshotListItem{
+		Version:           versionContent.Version,
+		CreatedAt:         versionContent.PublishedAt,
+		NotLatestRevision: versionContent.NotLatestRevision,
+		Status:            updatedVersion.Status,
+		CreatedBy:         &versionContent.PublishedBy,
+		Labels:            updatedVersion.VersionLabels,
+		PreviousVersion:   versionContent.PreviousVersion,
+	}, nil
+}
+
+// checkSnapshotGroupPermission checks that the user is allowed to manage versions with the statuses in the namespace group
+func (s *snapshotServiceImpl) checkSnapshotGroupPermission(ctx context.Context, groupId string, statuses ...string) error {
+	for _, status := range statuses {
+		sufficientPrivileges, err := s.permissionService.HasPackagePermission(ctx, groupId, view.MakeManageVersionPermission(status))
+		if err != nil {
+			return fmt.Errorf("failed to check namespace group permissions: %v", err.Error())
+		}
+		if !sufficientPrivileges {
+			return &exception.CustomError{
+				Status:  http.StatusForbidden,
+				Code:    exception.InsufficientPrivileges,
+				Message: exception.InsufficientPrivilegesMsg,
+				Debug:   fmt.Sprintf("%s permission is required in group %s", view.MakeManageVersionPermission(status), groupId),
+			}
+		}
+	}
+	return nil
+}
+
+// getOtherSnapshotsRefs returns service package versions (without revision) referenced by snapshots of the dashboard except excludedVersions
+func (s *snapshotServiceImpl) getOtherSnapshotsRefs(ctx context.Context, dashboardId string, excludedVersions map[string]struct{}) (map[string]struct{}, error) {
+	var otherVersions []string
+	versionReq := view.VersionSearchRequest{Limit: snapshotListVersionsLimit}
+	for versionReq.Page = 0; ; versionReq.Page++ {
+		dashboardVersions, err := s.apihubClient.GetVersions(ctx, dashboardId, versionReq)
+		if err != nil {
+			return nil, err
+		}
+		if dashboardVersions == nil {
+			break
+		}
+		for _, dashboardVersion := range dashboardVersions.Versions {
+			if _, excluded := excludedVersions[strings.Split(dashboardVersion.Version, "@")[0]]; !excluded {
+				otherVersions = append(otherVersions, dashboardVersion.Version)
+			}
+		}
+		if len(dashboardVersions.Versions) < versionReq.Limit {
+			break
+		}
+	}
+
+	otherRefs := make([][]string, len(otherVersions))
+	errGrp := errgroup.Group{}
+	errGrp.SetLimit(snapshotCompareConcurrency)
+	for i, otherVersion := range otherVersions {
+		errGrp.Go(func() error {
+			references, err := s.apihubClient.GetVersionReferences(ctx, dashboardId, otherVersion)
+			if err != nil {
+				return err
+			}
+			if references == nil {
+				return nil
+			}
+			for _, ref := range references.References {
+				if packageRef, exists := references.Packages[ref.PackageRef]; exists {
+					otherRefs[i] = append(otherRefs[i], packageRef.RefPackageId+"|"+strings.Split(packageRef.RefPackageVersion, "@")[0])
+				}
+			}
+			return nil
+		})
+	}
+	err := errGrp.Wait()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get references of other snapshots: %v", err.Error())
+	}
+	result := make(map[string]struct{})
+	for _, refs := range otherRefs {
+		for _, ref := range refs {
+			result[ref] = struct{}{}
+		}
+	}
+	return result, nil
+}
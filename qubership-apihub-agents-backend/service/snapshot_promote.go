@@ -0,0 +1,216 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/Netcracker/qubership-apihub-agents-backend/exception"
+	"github.com/Netcracker/qubership-apihub-agents-backend/secctx"
+	"github.com/Netcracker/qubership-apihub-agents-backend/utils"
+	"github.com/Netcracker/qubership-apihub-agents-backend/view"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+)
+
+// PromoteSnapshot publishes the documents of the snapshot service versions to the baseline packages as is, without discovery
+func (s *snapshotServiceImpl) PromoteSnapshot(ctx context.Context, namespace string, workspaceId string, version string, cloudName string, req view.PromoteSnapshotReq) (*view.CreateSnapshotResponse, error) {
+	dashboardId := makeSnapshotDashboardId(workspaceId, cloudName, namespace)
+	side, refs, err := s.getSnapshotRefs(ctx, dashboardId, version)
+	if err != nil {
+		return nil, err
+	}
+	serviceIds := make([]string, 0, len(refs))
+	if len(req.Services) > 0 {
+		for _, id := range req.Services {
+			if _, exists := refs[strings.ToLower(id)]; !exists {
+				return nil, &exception.CustomError{
+					Status:  http.StatusBadRequest,
+					Code:    exception.InvalidParameter,
+					Message: exception.InvalidParameterMsg,
+					Params:  map[string]interface{}{"param": "services"},
+					Debug:   fmt.Sprintf("service %s is not found in snapshot %s", id, side.Version),
+				}
+			}
+			serviceIds = append(serviceIds, strings.ToLower(id))
+		}
+	} else {
+		for id := range refs {
+			serviceIds = append(serviceIds, id)
+		}
+	}
+	slices.Sort(serviceIds)
+	serviceIds = slices.Compact(serviceIds)
+
+	targetVersion := req.Version
+	if targetVersion == "" {
+		targetVersion = strings.Split(side.Version, "@")[0]
+	}
+	err = validateVersionName(targetVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	services := make([]view.SnapshotBundleService, len(serviceIds))
+	baselineIds := make([]string, len(serviceIds))
+	errGrp := errgroup.Group{}
+	errGrp.SetLimit(snapshotBundleConcurrency)
+	for i, id := range serviceIds {
+		errGrp.Go(func() error {
+			svc, err := s.getSnapshotBundleService(ctx, id, refs[id])
+			if err != nil {
+				return err
+			}
+			services[i] = *svc
+			// the baseline is resolved the same way as by discovery, but the service may be not deployed anymore
+			baselineIds[i], _, err = s.baselineMappingService.ResolveBaselinePackage(ctx, workspaceId, svc.Name, parseVersionLabels(svc.Labels))
+			return err
+		})
+	}
+	err = errGrp.Wait()
+	if err != nil {
+		return nil, err
+	}
+	var noBaselineServices []string
+	for i, svc := range services {
+		if baselineIds[i] == "" {
+			noBaselineServices = append(noBaselineServices, svc.Id)
+		}
+	}
+	if len(noBaselineServices) > 0 {
+		return nil, &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.BaselineNotFound,
+			Message: exception.BaselineNotFoundMsg,
+			Params:  map[string]interface{}{"services": strings.Join(noBaselineServices, ", ")},
+		}
+	}
+
+	promoteStatuses, err := s.apihubClient.GetUserPackagesPromoteStatuses(ctx, view.PackagesReq{Packages: baselineIds})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user promote statuses: %v", err.Error())
+	}
+	var notAllowedPackages []string
+	for _, packageId := range baselineIds {
+		if !slices.Contains(promoteStatuses[packageId], req.Status) {
+			notAllowedPackages = append(notAllowedPackages, packageId)
+		}
+	}
+	if len(notAllowedPackages) > 0 {
+		return nil, &exception.CustomError{
+			Status:  http.StatusForbidden,
+			Code:    exception.PromoteStatusNotAllowed,
+			Message: exception.PromoteStatusNotAllowedMsg,
+			Params:  map[string]interface{}{"status": req.Status, "packages": strings.Join(notAllowedPackages, ", ")},
+		}
+	}
+	if req.Status == string(view.ReleaseStatus) {
+		err = s.snapshotApprovalService.CheckSnapshotApproved(ctx, namespace, workspaceId, side.Version, cloudName, serviceIds)
+		if err != nil {
+			return nil, err
+		}
+		err = s.snapshotVersionService.ValidateReleaseVersion(ctx, targetVersion, baselineIds)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	configs := make([]view.BuildConfig, len(services))
+	errGrp = errgroup.Group{}
+	errGrp.SetLimit(snapshotBundleConcurrency)
+	for i, svc := range services {
+		errGrp.Go(func() error {
+			previousVersion := req.PreviousVersion
+			if previousVersion != "" {
+				previousVersionContent, err := s.apihubClient.GetVersion(ctx, baselineIds[i], previousVersion)
+				if err != nil {
+					return fmt.Errorf("failed to get previous version %s of package %s: %v", previousVersion, baselineIds[i], err.Error())
+				}
+				if previousVersionContent == nil || previousVersionContent.Status == string(view.DraftStatus) {
+					previousVersion = ""
+				}
+			}
+			configs[i] = view.BuildConfig{
+				PackageId:        baselineIds[i],
+				Version:          targetVersion,
+				PreviousVersion:  previousVersion,
+				Status:           req.Status,
+				Files:            make([]view.BCFile, 0, len(svc.Files)),
+				Refs:             make([]view.BCRef, 0),
+				PublishId:        uuid.New().String(),
+				ServiceId:        svc.Id,
+				CreatedBy:        secctx.GetUserId(ctx),
+				ApihubPackageUrl: fmt.Sprintf("%s/portal/packages/%s/%s/overview/summary", s.systemInfoService.GetApihubUrl(), baselineIds[i], url.PathEscape(targetVersion)),
+				Metadata: map[string]interface{}{
+					"versionLabels": svc.Labels,
+					"cloudName":     cloudName,
+					"namespace":     namespace,
+					"promotedFrom":  fmt.Sprintf("%s@%s", svc.PackageId, svc.Version),
+				},
+				BuildType: view.BuildType,
+			}
+			for _, file := range svc.Files {
+				configs[i].Files = append(configs[i].Files, view.BCFile{FileId: file.FileId, Publish: true, Labels: make([]string, 0)})
+			}
+			return nil
+		})
+	}
+	err = errGrp.Wait()
+	if err != nil {
+		return nil, err
+	}
+
+	utils.SafeAsync(func() {
+		//a new context is required, as the request context will be canceled after the response is sent
+		secCtx := ctx.Value("secCtx")
+		ctx := context.WithValue(context.Background(), "secCtx", secCtx)
+		errGrp := errgroup.Group{}
+		errGrp.SetLimit(snapshotPublishConcurrency)
+		for i, svc := range services {
+			errGrp.Go(func() error {
+				zipReader, zipWriter := io.Pipe()
+				utils.SafeAsync(func() {
+					zipWriter.CloseWithError(s.writeVersionDocumentsZip(ctx, zipWriter, svc))
+				})
+				_, err := s.apihubClient.Publish(ctx, &configs[i], zipReader, false, "", false, nil)
+				zipReader.Close()
+				if err != nil {
+					log.Errorf("Failed to send publish request for service %s promotion: %s", svc.Id, err.Error())
+				}
+				return nil
+			})
+		}
+		errGrp.Wait()
+	})
+	log.Infof("Snapshot %s of namespace %s is promoted to %s version %s", side.Version, namespace, req.Status, targetVersion)
+
+	return &view.CreateSnapshotResponse{
+		Snapshot: nil,
+		Services: configs,
+	}, nil
+}
+
+// writeVersionDocumentsZip writes the sources archive with the documents of the published service version, documents are fetched from APIHUB one by one
+func (s *snapshotServiceImpl) writeVersionDocumentsZip(ctx context.Context, w io.Writer, svc view.SnapshotBundleService) error {
+	zw := zip.NewWriter(w)
+	for _, file := range svc.Files {
+		content, err := s.apihubClient.GetVersionDocumentRaw(ctx, svc.PackageId, svc.Version, file.Slug)
+		if err != nil {
+			return err
+		}
+		if content == nil {
+			return fmt.Errorf("document %s of version %s of package %s not found", file.Slug, svc.Version, svc.PackageId)
+		}
+		err = addFileToZip(zw, file.FileId, content)
+		if err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
@@ -0,0 +1,344 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Netcracker/qubership-apihub-agents-backend/client"
+	"github.com/Netcracker/qubership-apihub-agents-backend/entity"
+	"github.com/Netcracker/qubership-apihub-agents-backend/exception"
+	"github.com/Netcracker/qubership-apihub-agents-backend/repository"
+	"github.com/Netcracker/qubership-apihub-agents-backend/secctx"
+	"github.com/Netcracker/qubership-apihub-agents-backend/utils"
+	"github.com/Netcracker/qubership-apihub-agents-backend/view"
+)
+
+const snapshotRetentionPageLimit = 100
+
+// SnapshotRetentionService stores snapshot retention policies per workspace and selects snapshots expired by them
+type SnapshotRetentionService interface {
+	GetSnapshotRetentionPolicies(workspaceId string) (*view.SnapshotRetentionPolicies, error)
+	UpdateSnapshotRetentionPolicies(ctx context.Context, workspaceId string, req view.UpdateSnapshotRetentionPoliciesReq) (*view.SnapshotRetentionPolicies, error)
+	// DryRunSnapshotRetention lists snapshots which are deleted by the next cleanup job run
+	DryRunSnapshotRetention(ctx context.Context, workspaceId string) (*view.SnapshotRetentionDryRun, error)
+	// GetExpiredSnapshots returns snapshots expired by the workspace policies, only SNAPSHOTS_TTL_DAYS is applied if the workspace has no policies.
+	// False is returned if the workspace has no policies
+	GetExpiredSnapshots(ctx context.Context, workspaceId string, now time.Time) ([]view.ExpiredSnapshot, bool, error)
+}
+
+func NewSnapshotRetentionService(apihubClient client.ApihubClient, systemInfoService SystemInfoService, permissionService PermissionService, snapshotRetentionRepository repository.SnapshotRetentionRepository) SnapshotRetentionService {
+	return &snapshotRetentionServiceImpl{
+		apihubClient:                apihubClient,
+		systemInfoService:           systemInfoService,
+		permissionService:           permissionService,
+		snapshotRetentionRepository: snapshotRetentionRepository,
+	}
+}
+
+type snapshotRetentionServiceImpl struct {
+	apihubClient                client.ApihubClient
+	systemInfoService           SystemInfoService
+	permissionService           PermissionService
+	snapshotRetentionRepository repository.SnapshotRetentionRepository
+}
+
+func (s snapshotRetentionServiceImpl) GetSnapshotRetentionPolicies(workspaceId string) (*view.SnapshotRetentionPolicies, error) {
+	ent, err := s.snapshotRetentionRepository.GetSnapshotRetention(workspaceId)
+	if err != nil {
+		return nil, err
+	}
+	if ent == nil {
+		return &view.SnapshotRetentionPolicies{WorkspaceId: workspaceId, Policies: make([]view.SnapshotRetentionPolicy, 0)}, nil
+	}
+	result := entity.MakeSnapshotRetentionPoliciesView(*ent)
+	return &result, nil
+}
+
+func (s snapshotRetentionServiceImpl) UpdateSnapshotRetentionPolicies(ctx context.Context, workspaceId string, req view.UpdateSnapshotRetentionPoliciesReq) (*view.SnapshotRetentionPolicies, error) {
+	err := checkWorkspaceManagePermission(ctx, s.apihubClient, s.permissionService, workspaceId)
+	if err != nil {
+		return nil, err
+	}
+	policies := req.Policies
+	if policies == nil {
+		policies = make([]view.SnapshotRetentionPolicy, 0)
+	}
+	scopes := make(map[string]struct{}, len(policies))
+	for i, policy := range policies {
+		if err = validateSnapshotRetentionPolicy(i, policy); err != nil {
+			return nil, err
+		}
+		scope := utils.ToId(policy.CloudName) + "|" + utils.ToId(policy.Namespace)
+		if _, exists := scopes[scope]; exists {
+			return nil, &exception.CustomError{
+				Status:  http.StatusBadRequest,
+				Code:    exception.InvalidParameter,
+				Message: exception.InvalidParameterMsg,
+				Params:  map[string]interface{}{"param": fmt.Sprintf("policies[%d]", i)},
+				Debug:   "only one policy is allowed for the same cloud and namespace",
+			}
+		}
+		scopes[scope] = struct{}{}
+	}
+	ent := entity.SnapshotRetentionEntity{
+		WorkspaceId: workspaceId,
+		Policies:    policies,
+		UpdatedAt:   time.Now(),
+		UpdatedBy:   secctx.GetUserId(ctx),
+	}
+	err = s.snapshotRetentionRepository.SaveSnapshotRetention(&ent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store snapshot retention policies: %v", err.Error())
+	}
+	result := entity.MakeSnapshotRetentionPoliciesView(ent)
+	return &result, nil
+}
+
+func validateSnapshotRetentionPolicy(index int, policy view.SnapshotRetentionPolicy) error {
+	invalidParam := func(name string, reason string) error {
+		return &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.InvalidParameter,
+			Message: exception.InvalidParameterMsg,
+			Params:  map[string]interface{}{"param": fmt.Sprintf("policies[%d].%s", index, name)},
+			Debug:   reason,
+		}
+	}
+	if policy.Namespace != "" && policy.CloudName == "" {
+		return &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.RequiredParamsMissing,
+			Message: exception.RequiredParamsMissingMsg,
+			Params:  map[string]interface{}{"params": fmt.Sprintf("policies[%d].cloudName", index)},
+		}
+	}
+	if policy.TtlDays != nil && *policy.TtlDays < 0 {
+		return invalidParam("ttlDays", "value must not be negative")
+	}
+	if policy.AuthSecurityCheckTtlDays != nil && *policy.AuthSecurityCheckTtlDays < 0 {
+		return invalidParam("authSecurityCheckTtlDays", "value must not be negative")
+	}
+	if policy.KeepLast < 0 {
+		return invalidParam("keepLast", "value must not be negative")
+	}
+	if policy.KeepWeekly < 0 {
+		return invalidParam("keepWeekly", "value must not be negative")
+	}
+	for _, status := range policy.KeepStatuses {
+		if !slices.Contains([]view.VersionStatus{view.DraftStatus, view.ReleaseStatus, view.ArchivedStatus}, view.VersionStatus(status)) {
+			return invalidParam("keepStatuses", fmt.Sprintf("unknown status '%s'", status))
+		}
+	}
+	return nil
+}
+
+// DryRunSnapshotRetention uses the same computation as the cleanup job, so exactly the listed snapshots are deleted by the next run
+func (s snapshotRetentionServiceImpl) DryRunSnapshotRetention(ctx context.Context, workspaceId string) (*view.SnapshotRetentionDryRun, error) {
+	err := checkWorkspacePermission(ctx, s.apihubClient, s.permissionService, workspaceId, view.ReadPermission)
+	if err != nil {
+		return nil, err
+	}
+	// the cleanup job lists snapshots as sysadmin, so the dry run has to see the snapshots which the user can't see too
+	expired, policiesConfigured, err := s.GetExpiredSnapshots(secctx.MakeSysadminContext(ctx), workspaceId, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &view.SnapshotRetentionDryRun{
+		WorkspaceId:        workspaceId,
+		PoliciesConfigured: policiesConfigured,
+		Snapshots:          expired,
+	}, nil
+}
+
+func (s snapshotRetentionServiceImpl) GetExpiredSnapshots(ctx context.Context, workspaceId string, now time.Time) ([]view.ExpiredSnapshot, bool, error) {
+	ent, err := s.snapshotRetentionRepository.GetSnapshotRetention(workspaceId)
+	if err != nil {
+		return nil, false, err
+	}
+	policies := make([]view.SnapshotRetentionPolicy, 0)
+	if ent != nil {
+		policies = ent.Policies
+	}
+	expired, err := s.getExpiredSnapshots(ctx, workspaceId, policies, now)
+	if err != nil {
+		return nil, len(policies) > 0, err
+	}
+	return expired, len(policies) > 0, nil
+}
+
+func (s snapshotRetentionServiceImpl) getExpiredSnapshots(ctx context.Context, workspaceId string, policies []view.SnapshotRetentionPolicy, now time.Time) ([]view.ExpiredSnapshot, error) {
+	runenvGroupId := fmt.Sprintf("%s.%s", workspaceId, view.DefaultSnapshotsGroupAlias)
+	result := make([]view.ExpiredSnapshot, 0)
+	for page := 0; ; page++ {
+		dashboards, err := s.apihubClient.GetPackages(ctx, view.PackagesSearchReq{
+			ParentId:           runenvGroupId,
+			Kind:               string(view.KindDashbord),
+			ShowAllDescendants: true,
+			ShowParents:        true,
+			Limit:              snapshotRetentionPageLimit,
+			Page:               page,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get snapshot dashboards of '%s' workspace: %v", workspaceId, err.Error())
+		}
+		if dashboards == nil {
+			break
+		}
+		for _, dashboard := range dashboards.Packages {
+			if dashboard.Id != view.MakeSnapshotDashboardIdByGroupId(dashboard.ParentId) {
+				continue
+			}
+			expired, err := s.getExpiredDashboardSnapshots(ctx, runenvGroupId, dashboard, policies, now)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, expired...)
+		}
+		if len(dashboards.Packages) < snapshotRetentionPageLimit {
+			break
+		}
+	}
+	return result, nil
+}
+
+func (s snapshotRetentionServiceImpl) getExpiredDashboardSnapshots(ctx context.Context, runenvGroupId string, dashboard view.PackagesInfo, policies []view.SnapshotRetentionPolicy, now time.Time) ([]view.ExpiredSnapshot, error) {
+	groupId := dashboard.ParentId
+	// cloud and namespace names are the names of the parent groups
+	cloudName, namespace := "", ""
+	for _, parent := range dashboard.Parents {
+		if parent.Id == groupId {
+			namespace = parent.Name
+		} else if parent.ParentId == runenvGroupId {
+			cloudName = parent.Name
+		}
+	}
+	policy := matchSnapshotRetentionPolicy(policies, runenvGroupId, groupId)
+
+	versions := make([]view.PublishedVersionListView, 0)
+	versionReq := view.VersionSearchRequest{Limit: snapshotRetentionPageLimit}
+	for versionReq.Page = 0; ; versionReq.Page++ {
+		dashboardVersions, err := s.apihubClient.GetVersions(ctx, dashboard.Id, versionReq)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get versions of '%s' dashboard: %v", dashboard.Id, err.Error())
+		}
+		if dashboardVersions == nil {
+			break
+		}
+		versions = append(versions, dashboardVersions.Versions...)
+		if len(dashboardVersions.Versions) < versionReq.Limit {
+			break
+		}
+	}
+
+	result := make([]view.ExpiredSnapshot, 0)
+	for _, version := range applySnapshotRetentionPolicy(policy, s.systemInfoService.GetSnapshotsTTLDays(), versions, now) {
+		reason := view.SnapshotRetentionReasonTtl
+		if strings.HasPrefix(version.Version, authSecurityCheckVersionPrefix) {
+			reason = view.SnapshotRetentionReasonAuthSecurityCheckTtl
+		}
+		result = append(result, view.ExpiredSnapshot{
+			CloudName: cloudName,
+			Namespace: namespace,
+			GroupId:   groupId,
+			PackageId: dashboard.Id,
+			Version:   version.Version,
+			Status:    version.Status,
+			Labels:    version.VersionLabels,
+			CreatedAt: version.CreatedAt,
+			Reason:    reason,
+		})
+	}
+	return result, nil
+}
+
+// matchSnapshotRetentionPolicy returns the policy with the most specific scope matching the namespace group.
+// Empty policy is returned if no policy matches, so only SNAPSHOTS_TTL_DAYS is applied
+func matchSnapshotRetentionPolicy(policies []view.SnapshotRetentionPolicy, runenvGroupId string, groupId string) view.SnapshotRetentionPolicy {
+	var workspacePolicy, cloudPolicy *view.SnapshotRetentionPolicy
+	for i, policy := range policies {
+		cloudGroupId := runenvGroupId + "." + utils.ToId(policy.CloudName)
+		switch {
+		case policy.CloudName == "":
+			workspacePolicy = &policies[i]
+		case policy.Namespace == "":
+			if strings.HasPrefix(groupId, cloudGroupId+".") {
+				cloudPolicy = &policies[i]
+			}
+		case groupId == cloudGroupId+"."+utils.ToId(policy.Namespace):
+			return policy
+		}
+	}
+	if cloudPolicy != nil {
+		return *cloudPolicy
+	}
+	if workspacePolicy != nil {
+		return *workspacePolicy
+	}
+	return view.SnapshotRetentionPolicy{}
+}
+
+// applySnapshotRetentionPolicy returns versions which are older than the policy ttl and are not kept by the keep rules.
+// Auth security check versions are not counted by keepLast and keepWeekly rules
+func applySnapshotRetentionPolicy(policy view.SnapshotRetentionPolicy, defaultTtlDays int, versions []view.PublishedVersionListView, now time.Time) []view.PublishedVersionListView {
+	ttlDays := defaultTtlDays
+	if policy.TtlDays != nil {
+		ttlDays = *policy.TtlDays
+	}
+	authSecurityCheckTtlDays := ttlDays
+	if policy.AuthSecurityCheckTtlDays != nil {
+		authSecurityCheckTtlDays = *policy.AuthSecurityCheckTtlDays
+	}
+
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].CreatedAt.After(versions[j].CreatedAt)
+	})
+	snapshots := make([]view.PublishedVersionListView, 0, len(versions))
+	for _, version := range versions {
+		if !strings.HasPrefix(version.Version, authSecurityCheckVersionPrefix) {
+			snapshots = append(snapshots, version)
+		}
+	}
+	kept := make(map[string]struct{})
+	for i := 0; i < policy.KeepLast && i < len(snapshots); i++ {
+		kept[snapshots[i].Version] = struct{}{}
+	}
+	for week := 0; week < policy.KeepWeekly; week++ {
+		weekStart := now.AddDate(0, 0, -7*(week+1))
+		weekEnd := now.AddDate(0, 0, -7*week)
+		for _, snapshot := range snapshots {
+			if !snapshot.CreatedAt.Before(weekStart) && snapshot.CreatedAt.Before(weekEnd) {
+				kept[snapshot.Version] = struct{}{}
+				break
+			}
+		}
+	}
+
+	result := make([]view.PublishedVersionListView, 0)
+	for _, version := range versions {
+		if _, exists := kept[version.Version]; exists {
+			continue
+		}
+		if slices.Contains(policy.KeepStatuses, version.Status) {
+			continue
+		}
+		if slices.ContainsFunc(version.VersionLabels, func(label string) bool { return slices.Contains(policy.KeepLabels, label) }) {
+			continue
+		}
+		versionTtlDays := ttlDays
+		if strings.HasPrefix(version.Version, authSecurityCheckVersionPrefix) {
+			versionTtlDays = authSecurityCheckTtlDays
+		}
+		if versionTtlDays == 0 {
+			continue
+		}
+		if version.CreatedAt.Before(now.AddDate(0, 0, -versionTtlDays)) {
+			result = append(result, version)
+		}
+	}
+	return result
+}
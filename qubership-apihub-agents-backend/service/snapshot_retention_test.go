@@ -0,0 +1,180 @@
+package service
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Netcracker/qubership-apihub-agents-backend/view"
+)
+
+func TestMatchSnapshotRetentionPolicy(t *testing.T) {
+	ttl := func(days int) *int { return &days }
+	workspacePolicy := view.SnapshotRetentionPolicy{TtlDays: ttl(1)}
+	cloudPolicy := view.SnapshotRetentionPolicy{CloudName: "Cloud-1", TtlDays: ttl(2)}
+	namespacePolicy := view.SnapshotRetentionPolicy{CloudName: "Cloud-1", Namespace: "ns-1", TtlDays: ttl(3)}
+	otherCloudPolicy := view.SnapshotRetentionPolicy{CloudName: "cloud-10", TtlDays: ttl(4)}
+	runenvGroupId := "ws.runenv"
+
+	tests := []struct {
+		name     string
+		policies []view.SnapshotRetentionPolicy
+		groupId  string
+		expected view.SnapshotRetentionPolicy
+	}{
+		{
+			name:     "no policies",
+			policies: nil,
+			groupId:  "ws.runenv.CLOUD-1.NS-1",
+			expected: view.SnapshotRetentionPolicy{},
+		},
+		{
+			name:     "namespace policy wins",
+			policies: []view.SnapshotRetentionPolicy{workspacePolicy, namespacePolicy, cloudPolicy},
+			groupId:  "ws.runenv.CLOUD-1.NS-1",
+			expected: namespacePolicy,
+		},
+		{
+			name:     "cloud policy for other namespace",
+			policies: []view.SnapshotRetentionPolicy{workspacePolicy, namespacePolicy, cloudPolicy},
+			groupId:  "ws.runenv.CLOUD-1.NS-2",
+			expected: cloudPolicy,
+		},
+		{
+			name:     "workspace policy for other cloud",
+			policies: []view.SnapshotRetentionPolicy{workspacePolicy, namespacePolicy, cloudPolicy},
+			groupId:  "ws.runenv.CLOUD-2.NS-1",
+			expected: workspacePolicy,
+		},
+		{
+			name:     "cloud id prefix is not matched",
+			policies: []view.SnapshotRetentionPolicy{otherCloudPolicy},
+			groupId:  "ws.runenv.CLOUD-1.NS-1",
+			expected: view.SnapshotRetentionPolicy{},
+		},
+		{
+			name:     "namespace id prefix is not matched",
+			policies: []view.SnapshotRetentionPolicy{namespacePolicy},
+			groupId:  "ws.runenv.CLOUD-1.NS-10",
+			expected: view.SnapshotRetentionPolicy{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := matchSnapshotRetentionPolicy(tt.policies, runenvGroupId, tt.groupId)
+			if result.CloudName != tt.expected.CloudName || result.Namespace != tt.expected.Namespace || !slices.Equal(intPtrs(result.TtlDays), intPtrs(tt.expected.TtlDays)) {
+				t.Errorf("Expected %+v, got %+v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestApplySnapshotRetentionPolicy(t *testing.T) {
+	ttl := func(days int) *int { return &days }
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) time.Time { return now.AddDate(0, 0, -days) }
+	version := func(name string, createdAt time.Time) view.PublishedVersionListView {
+		return view.PublishedVersionListView{Version: name, Status: string(view.DraftStatus), CreatedAt: createdAt}
+	}
+
+	tests := []struct {
+		name           string
+		policy         view.SnapshotRetentionPolicy
+		defaultTtlDays int
+		versions       []view.PublishedVersionListView
+		expected       []string
+	}{
+		{
+			name:           "default ttl",
+			policy:         view.SnapshotRetentionPolicy{},
+			defaultTtlDays: 10,
+			versions:       []view.PublishedVersionListView{version("v1", daysAgo(11)), version("v2", daysAgo(9))},
+			expected:       []string{"v1"},
+		},
+		{
+			name:           "policy ttl overrides default ttl",
+			policy:         view.SnapshotRetentionPolicy{TtlDays: ttl(5)},
+			defaultTtlDays: 10,
+			versions:       []view.PublishedVersionListView{version("v1", daysAgo(11)), version("v2", daysAgo(6)), version("v3", daysAgo(4))},
+			expected:       []string{"v1", "v2"},
+		},
+		{
+			name:           "zero ttl keeps everything",
+			policy:         view.SnapshotRetentionPolicy{TtlDays: ttl(0)},
+			defaultTtlDays: 10,
+			versions:       []view.PublishedVersionListView{version("v1", daysAgo(100))},
+			expected:       []string{},
+		},
+		{
+			name:           "keep last",
+			policy:         view.SnapshotRetentionPolicy{TtlDays: ttl(1), KeepLast: 2},
+			defaultTtlDays: 10,
+			versions:       []view.PublishedVersionListView{version("v1", daysAgo(30)), version("v3", daysAgo(10)), version("v2", daysAgo(20))},
+			expected:       []string{"v1"},
+		},
+		{
+			name:           "keep weekly keeps the latest snapshot of each week",
+			policy:         view.SnapshotRetentionPolicy{TtlDays: ttl(1), KeepWeekly: 2},
+			defaultTtlDays: 10,
+			versions: []view.PublishedVersionListView{
+				version("week0-old", daysAgo(5)), version("week0-new", daysAgo(2)),
+				version("week1-old", daysAgo(12)), version("week1-new", daysAgo(9)),
+				version("week2", daysAgo(16)),
+			},
+			expected: []string{"week0-old", "week1-old", "week2"},
+		},
+		{
+			name:           "keep statuses and labels",
+			policy:         view.SnapshotRetentionPolicy{TtlDays: ttl(1), KeepStatuses: []string{string(view.ReleaseStatus)}, KeepLabels: []string{"keep"}},
+			defaultTtlDays: 10,
+			versions: []view.PublishedVersionListView{
+				{Version: "released", Status: string(view.ReleaseStatus), CreatedAt: daysAgo(30)},
+				{Version: "labeled", Status: string(view.DraftStatus), VersionLabels: []string{"other", "keep"}, CreatedAt: daysAgo(30)},
+				version("expired", daysAgo(30)),
+			},
+			expected: []string{"expired"},
+		},
+		{
+			name:           "auth security check versions have own ttl and are not counted by keep last",
+			policy:         view.SnapshotRetentionPolicy{TtlDays: ttl(10), AuthSecurityCheckTtlDays: ttl(1), KeepLast: 1},
+			defaultTtlDays: 30,
+			versions: []view.PublishedVersionListView{
+				version(authSecurityCheckVersionPrefix+"new", daysAgo(2)),
+				version("snapshot-new", daysAgo(20)),
+				version("snapshot-old", daysAgo(25)),
+			},
+			expected: []string{"snapshot-old", authSecurityCheckVersionPrefix + "new"},
+		},
+		{
+			name:           "auth security check versions use policy ttl by default",
+			policy:         view.SnapshotRetentionPolicy{TtlDays: ttl(3)},
+			defaultTtlDays: 30,
+			versions:       []view.PublishedVersionListView{version(authSecurityCheckVersionPrefix+"v1", daysAgo(4))},
+			expected:       []string{authSecurityCheckVersionPrefix + "v1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := applySnapshotRetentionPolicy(tt.policy, tt.defaultTtlDays, tt.versions, now)
+			names := make([]string, 0, len(result))
+			for _, v := range result {
+				names = append(names, v.Version)
+			}
+			slices.Sort(names)
+			expected := slices.Clone(tt.expected)
+			slices.Sort(expected)
+			if !slices.Equal(names, expected) {
+				t.Errorf("Expected %v, got %v", expected, names)
+			}
+		})
+	}
+}
+
+func intPtrs(value *int) []int {
+	if value == nil {
+		return nil
+	}
+	return []int{*value}
+}
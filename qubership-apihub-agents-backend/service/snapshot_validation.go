@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Netcracker/qubership-apihub-agents-backend/secctx"
+	"github.com/Netcracker/qubership-apihub-agents-backend/utils"
+	"github.com/Netcracker/qubership-apihub-agents-backend/view"
+	"golang.org/x/sync/errgroup"
+)
+
+const snapshotValidationConcurrency = 10
+
+func (s *snapshotServiceImpl) ValidateSnapshot(ctx context.Context, namespace string, workspaceId string, version string, snapshotDTO view.CreateSnapshotDTO) (*view.SnapshotValidation, error) {
+	result := &view.SnapshotValidation{
+		Version:  version,
+		Checks:   make([]view.SnapshotCheck, 0),
+		Services: make([]view.SnapshotServiceValidation, 0),
+	}
+
+	workspace, err := s.apihubClient.GetPackageById(ctx, workspaceId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workspace by id: %v", err.Error())
+	}
+	if workspace == nil || workspace.Kind != string(view.KindWorkspace) {
+		result.Checks = append(result.Checks, view.MakeSnapshotCheck(view.SnapshotCheckWorkspaceExists, fmt.Errorf("workspace %s not found", workspaceId)))
+		return result, nil
+	}
+	result.Checks = append(result.Checks, view.MakeSnapshotCheck(view.SnapshotCheckWorkspaceExists, nil))
+
+	serviceListResponse, err := s.listSnapshotServices(ctx, namespace, workspaceId, snapshotDTO.Agent)
+	if err != nil {
+		result.Checks = append(result.Checks, view.MakeSnapshotCheck(view.SnapshotCheckDiscoveryComplete, err))
+		return result, nil
+	}
+	if serviceListResponse.Status != view.StatusComplete {
+		result.Checks = append(result.Checks, view.MakeSnapshotCheck(view.SnapshotCheckDiscoveryComplete, fmt.Errorf("service discovery status is %s", serviceListResponse.Status)))
+		return result, nil
+	}
+	result.Checks = append(result.Checks, view.MakeSnapshotCheck(view.SnapshotCheckDiscoveryComplete, nil))
+
+	services := filterService(serviceListResponse.Services, snapshotDTO.Services, snapshotDTO.Promote)
+	if len(services) == 0 {
+		result.Checks = append(result.Checks, view.MakeSnapshotCheck(view.SnapshotCheckServicesSelected, fmt.Errorf("no (selected) services with documents in namespace %s", namespace)))
+	} else {
+		result.Checks = append(result.Checks, view.MakeSnapshotCheck(view.SnapshotCheckServicesSelected, nil))
+	}
+
+	if version != "" {
+		err = validateVersionName(version)
+	}
+	if err == nil && len(services) > 0 {
+		targetPackageIds := getSnapshotTargetPackageIds(namespace, workspaceId, services, snapshotDTO)
+		result.Version, err = s.resolveSnapshotVersion(ctx, namespace, workspaceId, version, services, targetPackageIds, snapshotDTO)
+	}
+	result.Checks = append(result.Checks, view.MakeSnapshotCheck(view.SnapshotCheckVersionName, err))
+
+	if !snapshotDTO.Promote {
+		result.Checks = append(result.Checks, view.MakeSnapshotCheck(view.SnapshotCheckGroupHierarchy, s.checkGroupHierarchy(namespace, workspaceId, services, snapshotDTO)))
+	}
+
+	result.Services, err = s.validateSnapshotServices(ctx, namespace, workspaceId, serviceListResponse.Services, services, snapshotDTO)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Valid = view.IsSnapshotChecksPassed(result.Checks)
+	for _, svc := range result.Services {
+		if svc.Included && !svc.Valid {
+			result.Valid = false
+		}
+	}
+	return result, nil
+}
+
+// checkGroupHierarchy checks that snapshot groups, dashboard and packages either don't exist and can be created or have the expected kind
+func (s *snapshotServiceImpl) checkGroupHierarchy(namespace string, workspaceId string, services []view.Service, snapshotDTO view.CreateSnapshotDTO) error {
+	sysCtx := secctx.MakeSysadminContext(context.Background()) // snapshot groups are created using api-key
+	runenvGroupId := fmt.Sprintf("%s.%s", workspaceId, view.DefaultSnapshotsGroupAlias)
+	cloudGroupId := fmt.Sprintf("%s.%s", runenvGroupId, utils.ToId(snapshotDTO.CloudName))
+	namespaceGroupId := fmt.Sprintf("%s.%s", cloudGroupId, utils.ToId(namespace))
+	expectedKinds := map[string]view.PackageKind{
+		runenvGroupId:    view.KindGroup,
+		cloudGroupId:     view.KindGroup,
+		namespaceGroupId: view.KindGroup,
+		view.MakeSnapshotDashboardIdByGroupId(namespaceGroupId): view.KindDashbord,
+	}
+	for _, svc := range services {
+		expectedKinds[namespaceGroupId+"."+utils.ToId(svc.Id)] = view.KindPackage
+	}
+
+	errGrp := errgroup.Group{}
+	errGrp.SetLimit(snapshotValidationConcurrency)
+	for packageId, kind := range expectedKinds {
+		errGrp.Go(func() error {
+			pkg, err := s.apihubClient.GetPackageById(sysCtx, packageId)
+			if err != nil {
+				return fmt.Errorf("unable to get package %s: %s", packageId, err)
+			}
+			if pkg != nil && pkg.Kind != string(kind) {
+				return fmt.Errorf("package %s exists but is not a %s (kind: %s)", packageId, kind, pkg.Kind)
+			}
+			return nil
+		})
+	}
+	return errGrp.Wait()
+}
+
+func (s *snapshotServiceImpl) validateSnapshotServices(ctx context.Context, namespace string, workspaceId string, allServices []view.Service, includedServices []view.Service, snapshotDTO view.CreateSnapshotDTO) ([]view.SnapshotServiceValidation, error) {
+	discoveredServices := make(map[string]view.Service, len(allServices))
+	for _, svc := range allServices {
+		discoveredServices[svc.Id] = svc
+	}
+	includedServiceIds := make(map[string]struct{}, len(includedServices))
+	for _, svc := range includedServices {
+		includedServiceIds[svc.Id] = struct{}{}
+	}
+	serviceIds := snapshotDTO.Services
+	if len(serviceIds) == 0 {
+		for _, svc := range allServices {
+			serviceIds = append(serviceIds, svc.Id)
+		}
+	}
+
+	var promoteStatuses view.AvailablePackagePromoteStatuses
+	if snapshotDTO.Promote && len(includedServices) > 0 {
+		baselinePackageIds := make([]string, 0, len(includedServices))
+		for _, svc := range includedServices {
+			baselinePackageIds = append(baselinePackageIds, svc.Baseline.PackageId)
+		}
+		var err error
+		promoteStatuses, err = s.apihubClient.GetUserPackagesPromoteStatuses(ctx, view.PackagesReq{Packages: baselinePackageIds})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user promote statuses: %v", err.Error())
+		}
+	}
+
+	result := make([]view.SnapshotServiceValidation, len(serviceIds))
+	errGrp := errgroup.Group{}
+	errGrp.SetLimit(snapshotValidationConcurrency)
+	for i, serviceId := range serviceIds {
+		svc, discovered := discoveredServices[serviceId]
+		_, included := includedServiceIds[serviceId]
+		result[i] = view.SnapshotServiceValidation{Id: serviceId, Included: included, Checks: make([]view.SnapshotCheck, 0)}
+		if !discovered {
+			result[i].Checks = append(result[i].Checks, view.MakeSnapshotCheck(view.SnapshotCheckServiceDiscovered, fmt.Errorf("service is not found in namespace %s", namespace)))
+			continue
+		}
+		errGrp.Go(func() error {
+			checks, err := s.validateSnapshotService(ctx, namespace, workspaceId, svc, included, promoteStatuses, snapshotDTO)
+			if err != nil {
+				return err
+			}
+			result[i].Checks = checks
+			result[i].Valid = view.IsSnapshotChecksPassed(checks)
+			return nil
+		})
+	}
+	err := errGrp.Wait()
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *snapshotServiceImpl) validateSnapshotService(ctx context.Context, namespace string, workspaceId string, svc view.Service, included bool, promoteStatuses view.AvailablePackagePromoteStatuses, snapshotDTO view.CreateSnapshotDTO) ([]view.SnapshotCheck, error) {
+	checks := []view.SnapshotCheck{view.MakeSnapshotCheck(view.SnapshotCheckServiceDiscovered, nil)}
+	if len(svc.Documents) == 0 {
+		checks = append(checks, view.MakeSnapshotCheck(view.SnapshotCheckDocuments, fmt.Errorf("service has no documents and will not be included to the snapshot")))
+	} else {
+		checks = append(checks, view.MakeSnapshotCheck(view.SnapshotCheckDocuments, nil))
+	}
+
+	hasBaseline := svc.Baseline != nil && svc.Baseline.PackageId != ""
+	switch {
+	case hasBaseline:
+		checks = append(checks, view.SnapshotCheck{Name: view.SnapshotCheckBaseline, Status: view.SnapshotCheckPassed, Details: fmt.Sprintf("baseline package %s", svc.Baseline.PackageId)})
+	case snapshotDTO.Promote:
+		checks = append(checks, view.MakeSnapshotCheck(view.SnapshotCheckBaseline, fmt.Errorf("baseline package is required for promotion, the service will not be included to the snapshot")))
+	default:
+		checks = append(checks, view.SnapshotCheck{Name: view.SnapshotCheckBaseline, Status: view.SnapshotCheckWarning, Details: "baseline package not found, changes will not be calculated"})
+	}
+	if !included {
+		return checks, nil
+	}
+
+	previousVersionCheck := view.SnapshotCheck{Name: view.SnapshotCheckPreviousVersion, Status: view.SnapshotCheckPassed}
+	switch {
+	case snapshotDTO.PreviousVersion == "":
+		previousVersionCheck.Status = view.SnapshotCheckSkipped
+		previousVersionCheck.Details = "previous version is not set"
+	case !hasBaseline:
+		previousVersionCheck.Status = view.SnapshotCheckWarning
+		previousVersionCheck.Details = "version will be published without previous version since baseline package not found"
+	default:
+		previousVersion, err := s.apihubClient.GetVersion(ctx, svc.Baseline.PackageId, snapshotDTO.PreviousVersion)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get previous version %s for package %s: %v", snapshotDTO.PreviousVersion, svc.Baseline.PackageId, err.Error())
+		}
+		if previousVersion == nil {
+			previousVersionCheck.Status = view.SnapshotCheckWarning
+			previousVersionCheck.Details = fmt.Sprintf("version %s not found in baseline package %s, version will be published without previous version", snapshotDTO.PreviousVersion, svc.Baseline.PackageId)
+		} else if previousVersion.Status == string(view.DraftStatus) {
+			previousVersionCheck.Status = view.SnapshotCheckWarning
+			previousVersionCheck.Details = fmt.Sprintf("version %s in baseline package %s is a draft, version will be published without previous version", snapshotDTO.PreviousVersion, svc.Baseline.PackageId)
+		}
+	}
+	checks = append(checks, previousVersionCheck)
+
+	failedFiles := make([]string, 0)
+	contents := make([][]byte, len(svc.Documents))
+	for i, spec := range svc.Documents {
+		var err error
+		contents[i], err = s.agentService.GetServiceSpecification(ctx, snapshotDTO.Agent, namespace, workspaceId, svc.Id, spec.FileId)
+		if err != nil {
+			failedFiles = append(failedFiles, fmt.Sprintf("%s (%s)", spec.FileId, err.Error()))
+		} else if len(contents[i]) == 0 {
+			failedFiles = append(failedFiles, fmt.Sprintf("%s (empty content)", spec.FileId))
+		}
+	}
+	if len(failedFiles) > 0 {
+		checks = append(checks, view.MakeSnapshotCheck(view.SnapshotCheckSpecsDownloadable, fmt.Errorf("unable to get specifications: %s", strings.Join(failedFiles, ", "))))
+		checks = append(checks, view.SnapshotCheck{Name: view.SnapshotCheckSpecsValid, Status: view.SnapshotCheckSkipped, Details: "specifications are not downloaded"})
+	} else {
+		checks = append(checks, view.MakeSnapshotCheck(view.SnapshotCheckSpecsDownloadable, nil))
+		specValidation, err := s.specValidationService.ValidateServiceSpecs(workspaceId, svc.Id, svc.Documents, contents)
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, makeSpecsValidCheck(*specValidation))
+	}
+
+	if snapshotDTO.Promote {
+		if !slices.Contains(promoteStatuses[svc.Baseline.PackageId], snapshotDTO.VersionStatus) {
+			checks = append(checks, view.MakeSnapshotCheck(view.SnapshotCheckPromotePermission, fmt.Errorf("not enough privileges to publish %s version to package %s", snapshotDTO.VersionStatus, svc.Baseline.PackageId)))
+		} else {
+			checks = append(checks, view.MakeSnapshotCheck(view.SnapshotCheckPromotePermission, nil))
+		}
+	}
+	return checks, nil
+}
+
+func makeSpecsValidCheck(specValidation view.ServiceSpecValidation) view.SnapshotCheck {
+	check := view.SnapshotCheck{Name: view.SnapshotCheckSpecsValid, Status: view.SnapshotCheckPassed}
+	if specValidation.Errors > 0 {
+		check.Status = view.SnapshotCheckFailed
+	} else if specValidation.Warnings > 0 {
+		check.Status = view.SnapshotCheckWarning
+	} else {
+		return check
+	}
+	details := make([]string, 0, len(specValidation.Findings))
+	for _, finding := range specValidation.Findings {
+		if finding.Path != "" {
+			details = append(details, fmt.Sprintf("%s %s %s at %s: %s", finding.FileId, finding.Severity, finding.Rule, finding.Path, finding.Message))
+		} else {
+			details = append(details, fmt.Sprintf("%s %s %s: %s", finding.FileId, finding.Severity, finding.Rule, finding.Message))
+		}
+	}
+	check.Details = fmt.Sprintf("%d error(s), %d warning(s): %s", specValidation.Errors, specValidation.Warnings, strings.Join(details, "; "))
+	return check
+}
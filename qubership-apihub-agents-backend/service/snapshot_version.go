@@ -0,0 +1,293 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Netcracker/qubership-apihub-agents-backend/client"
+	"github.com/Netcracker/qubership-apihub-agents-backend/entity"
+	"github.com/Netcracker/qubership-apihub-agents-backend/exception"
+	"github.com/Netcracker/qubership-apihub-agents-backend/repository"
+	"github.com/Netcracker/qubership-apihub-agents-backend/secctx"
+	"github.com/Netcracker/qubership-apihub-agents-backend/view"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+)
+
+const versionTemplateCounter = "n"
+const versionTemplateLabelPrefix = "label:"
+const versionCounterPageLimit = 100
+
+var versionTemplatePlaceholder = regexp.MustCompile(`\{\{\s*([^{}]*?)\s*\}\}`)
+
+// SnapshotVersionService generates snapshot versions by the workspace template and validates release versions
+type SnapshotVersionService interface {
+	GetSnapshotVersionTemplate(workspaceId string) (*view.SnapshotVersionTemplate, error)
+	UpdateSnapshotVersionTemplate(ctx context.Context, workspaceId string, req view.UpdateSnapshotVersionTemplateReq) (*view.SnapshotVersionTemplate, error)
+	DeleteSnapshotVersionTemplate(ctx context.Context, workspaceId string) error
+	// ResolveSnapshotVersion renders the workspace template for the snapshot services.
+	// The counter is incremented by the versions which already exist in versionPackageIds and by the counters reserved before
+	ResolveSnapshotVersion(ctx context.Context, workspaceId string, cloudName string, namespace string, services []view.Service, versionPackageIds []string) (string, error)
+	// ValidateReleaseVersion checks the version against release version patterns of the existing packages, invalid patterns are skipped
+	ValidateReleaseVersion(ctx context.Context, version string, packageIds []string) error
+}
+
+func NewSnapshotVersionService(apihubClient client.ApihubClient, permissionService PermissionService, snapshotVersionTemplateRepository repository.SnapshotVersionTemplateRepository) SnapshotVersionService {
+	return &snapshotVersionServiceImpl{
+		apihubClient:                      apihubClient,
+		permissionService:                 permissionService,
+		snapshotVersionTemplateRepository: snapshotVersionTemplateRepository,
+	}
+}
+
+type snapshotVersionServiceImpl struct {
+	apihubClient                      client.ApihubClient
+	permissionService                 PermissionService
+	snapshotVersionTemplateRepository repository.SnapshotVersionTemplateRepository
+}
+
+func (s snapshotVersionServiceImpl) GetSnapshotVersionTemplate(workspaceId string) (*view.SnapshotVersionTemplate, error) {
+	ent, err := s.snapshotVersionTemplateRepository.GetSnapshotVersionTemplate(workspaceId)
+	if err != nil {
+		return nil, err
+	}
+	if ent == nil {
+		return &view.SnapshotVersionTemplate{WorkspaceId: workspaceId}, nil
+	}
+	result := entity.MakeSnapshotVersionTemplateView(*ent)
+	return &result, nil
+}
+
+func (s snapshotVersionServiceImpl) UpdateSnapshotVersionTemplate(ctx context.Context, workspaceId string, req view.UpdateSnapshotVersionTemplateReq) (*view.SnapshotVersionTemplate, error) {
+	err := checkWorkspaceManagePermission(ctx, s.apihubClient, s.permissionService, workspaceId)
+	if err != nil {
+		return nil, err
+	}
+	err = validateVersionTemplate(req.Template)
+	if err != nil {
+		return nil, err
+	}
+	ent := entity.SnapshotVersionTemplateEntity{
+		WorkspaceId: workspaceId,
+		Template:    req.Template,
+		UpdatedAt:   time.Now(),
+		UpdatedBy:   secctx.GetUserId(ctx),
+	}
+	err = s.snapshotVersionTemplateRepository.SaveSnapshotVersionTemplate(&ent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store snapshot version template: %v", err.Error())
+	}
+	result := entity.MakeSnapshotVersionTemplateView(ent)
+	return &result, nil
+}
+
+func (s snapshotVersionServiceImpl) DeleteSnapshotVersionTemplate(ctx context.Context, workspaceId string) error {
+	err := checkWorkspaceManagePermission(ctx, s.apihubClient, s.permissionService, workspaceId)
+	if err != nil {
+		return err
+	}
+	return s.snapshotVersionTemplateRepository.DeleteSnapshotVersionTemplate(workspaceId)
+}
+
+func validateVersionTemplate(template string) error {
+	invalidTemplate := func(reason string) error {
+		return &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.InvalidParameter,
+			Message: exception.InvalidParameterMsg,
+			Params:  map[string]interface{}{"param": "template"},
+			Debug:   reason,
+		}
+	}
+	counters := 0
+	for _, match := range versionTemplatePlaceholder.FindAllStringSubmatch(template, -1) {
+		name := match[1]
+		switch {
+		case name == versionTemplateCounter:
+			counters++
+		case name == "yyyy", name == "MM", name == "dd", name == "cloud", name == "namespace":
+		case strings.HasPrefix(name, versionTemplateLabelPrefix) && len(name) > len(versionTemplateLabelPrefix):
+		default:
+			return invalidTemplate(fmt.Sprintf("unknown placeholder '%s'", match[0]))
+		}
+	}
+	if counters > 1 {
+		return invalidTemplate("only one {{n}} placeholder is allowed")
+	}
+	return validateVersionName(versionTemplatePlaceholder.ReplaceAllString(template, ""))
+}
+
+func (s snapshotVersionServiceImpl) ResolveSnapshotVersion(ctx context.Context, workspaceId string, cloudName string, namespace string, services []view.Service, versionPackageIds []string) (string, error) {
+	ent, err := s.snapshotVersionTemplateRepository.GetSnapshotVersionTemplate(workspaceId)
+	if err != nil {
+		return "", fmt.Errorf("failed to get snapshot version template: %v", err.Error())
+	}
+	if ent == nil {
+		return "", &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.RequiredParamsMissing,
+			Message: exception.RequiredParamsMissingMsg,
+			Params:  map[string]interface{}{"params": "version"},
+			Debug:   "snapshot version template is not configured for the workspace",
+		}
+	}
+	template := ent.Template
+	notResolved := func(reason string) error {
+		return &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.SnapshotVersionTemplateNotResolved,
+			Message: exception.SnapshotVersionTemplateNotResolvedMsg,
+			Params:  map[string]interface{}{"template": template, "reason": reason},
+		}
+	}
+
+	now := time.Now().UTC()
+	var renderErr error
+	render := func(part string) string {
+		return versionTemplatePlaceholder.ReplaceAllStringFunc(part, func(placeholder string) string {
+			name := versionTemplatePlaceholder.FindStringSubmatch(placeholder)[1]
+			switch name {
+			case "yyyy":
+				return now.Format("2006")
+			case "MM":
+				return now.Format("01")
+			case "dd":
+				return now.Format("02")
+			case "cloud":
+				return cloudName
+			case "namespace":
+				return namespace
+			}
+			labelName := strings.TrimPrefix(name, versionTemplateLabelPrefix)
+			values := make([]string, 0)
+			for _, svc := range services {
+				if value := svc.Labels[labelName]; value != "" && !slices.Contains(values, value) {
+					values = append(values, value)
+				}
+			}
+			if len(values) == 0 {
+				renderErr = notResolved(fmt.Sprintf("label '%s' is not set for the snapshot services", labelName))
+			} else if len(values) > 1 {
+				slices.Sort(values)
+				renderErr = notResolved(fmt.Sprintf("snapshot services have different values of label '%s': %s", labelName, strings.Join(values, ", ")))
+			} else {
+				return values[0]
+			}
+			return ""
+		})
+	}
+
+	var version string
+	counterLoc := counterLocation(template)
+	if counterLoc == nil {
+		version = render(template)
+	} else {
+		prefix := render(template[:counterLoc[0]])
+		suffix := render(template[counterLoc[1]:])
+		if renderErr == nil {
+			counter, err := s.getNextCounter(ctx, versionPackageIds, prefix, suffix)
+			if err != nil {
+				return "", err
+			}
+			// the counter is reserved, so concurrent snapshots don't get the same version before any of them is published
+			counter, err = s.snapshotVersionTemplateRepository.ReserveSnapshotVersionCounter(workspaceId, prefix+"{{"+versionTemplateCounter+"}}"+suffix, counter)
+			if err != nil {
+				return "", fmt.Errorf("failed to reserve snapshot version counter: %v", err.Error())
+			}
+			version = prefix + strconv.Itoa(counter) + suffix
+		}
+	}
+	if renderErr != nil {
+		return "", renderErr
+	}
+	if version == "" {
+		return "", notResolved("version is empty")
+	}
+	if err = validateVersionName(version); err != nil {
+		return "", err
+	}
+	return version, nil
+}
+
+func counterLocation(template string) []int {
+	for _, loc := range versionTemplatePlaceholder.FindAllStringSubmatchIndex(template, -1) {
+		if template[loc[2]:loc[3]] == versionTemplateCounter {
+			return loc[:2]
+		}
+	}
+	return nil
+}
+
+// getNextCounter returns the max counter of the existing versions matching the template plus one
+func (s snapshotVersionServiceImpl) getNextCounter(ctx context.Context, packageIds []string, prefix string, suffix string) (int, error) {
+	versionRegexp := regexp.MustCompile("^" + regexp.QuoteMeta(prefix) + `(\d+)` + regexp.QuoteMeta(suffix) + "$")
+	sysCtx := secctx.MakeSysadminContext(ctx) // counter has to take into account versions which the user can't see
+	maxCounter := 0
+	for _, packageId := range packageIds {
+		for page := 0; ; page++ {
+			versions, err := s.apihubClient.GetVersions(sysCtx, packageId, view.VersionSearchRequest{
+				TextFilter: prefix,
+				Page:       page,
+				Limit:      versionCounterPageLimit,
+			})
+			if err != nil {
+				return 0, fmt.Errorf("failed to get versions of package %s: %v", packageId, err.Error())
+			}
+			if versions == nil {
+				break
+			}
+			for _, version := range versions.Versions {
+				match := versionRegexp.FindStringSubmatch(strings.Split(version.Version, "@")[0])
+				if match == nil {
+					continue
+				}
+				if counter, err := strconv.Atoi(match[1]); err == nil && counter > maxCounter {
+					maxCounter = counter
+				}
+			}
+			if len(versions.Versions) < versionCounterPageLimit {
+				break
+			}
+		}
+	}
+	return maxCounter + 1, nil
+}
+
+func (s snapshotVersionServiceImpl) ValidateReleaseVersion(ctx context.Context, version string, packageIds []string) error {
+	sysCtx := secctx.MakeSysadminContext(ctx)
+	errGrp := errgroup.Group{}
+	errGrp.SetLimit(10)
+	for _, packageId := range packageIds {
+		errGrp.Go(func() error {
+			pkg, err := s.apihubClient.GetPackageById(sysCtx, packageId)
+			if err != nil {
+				return fmt.Errorf("failed to get package %s: %v", packageId, err.Error())
+			}
+			if pkg == nil || pkg.ReleaseVersionPattern == "" {
+				return nil
+			}
+			pattern, err := regexp.Compile(pkg.ReleaseVersionPattern)
+			if err != nil {
+				// the pattern is configured in APIHUB, so it is not a client error and the version is validated by APIHUB on publish
+				log.Warnf("Release version pattern '%s' of package %s is invalid, validation of the package is skipped: %s", pkg.ReleaseVersionPattern, packageId, err.Error())
+				return nil
+			}
+			if !pattern.MatchString(version) {
+				return &exception.CustomError{
+					Status:  http.StatusBadRequest,
+					Code:    exception.ReleaseVersionPatternMismatch,
+					Message: exception.ReleaseVersionPatternMismatchMsg,
+					Params:  map[string]interface{}{"version": version, "pattern": pkg.ReleaseVersionPattern, "packageId": packageId},
+				}
+			}
+			return nil
+		})
+	}
+	return errGrp.Wait()
+}
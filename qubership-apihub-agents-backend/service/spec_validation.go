@@ -0,0 +1,366 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Netcracker/qubership-apihub-agents-backend/client"
+	"github.com/Netcracker/qubership-apihub-agents-backend/entity"
+	"github.com/Netcracker/qubership-apihub-agents-backend/exception"
+	"github.com/Netcracker/qubership-apihub-agents-backend/repository"
+	"github.com/Netcracker/qubership-apihub-agents-backend/secctx"
+	"github.com/Netcracker/qubership-apihub-agents-backend/view"
+	"gopkg.in/yaml.v3"
+)
+
+const maxFindingsPerDocument = 100
+
+// SpecValidationService checks specifications content before publishing
+type SpecValidationService interface {
+	GetSpecLintRules(workspaceId string) (*view.SpecLintRules, error)
+	UpdateSpecLintRules(ctx context.Context, workspaceId string, req view.UpdateSpecLintRulesReq) (*view.SpecLintRules, error)
+	// ValidateServiceSpecs parses documents by their type and applies lint rules of the workspace. contents[i] is the content of documents[i]
+	ValidateServiceSpecs(workspaceId string, serviceId string, documents []view.Document, contents [][]byte) (*view.ServiceSpecValidation, error)
+}
+
+func NewSpecValidationService(apihubClient client.ApihubClient, permissionService PermissionService, specLintRulesRepository repository.SpecLintRulesRepository) SpecValidationService {
+	return &specValidationServiceImpl{
+		apihubClient:            apihubClient,
+		permissionService:       permissionService,
+		specLintRulesRepository: specLintRulesRepository,
+	}
+}
+
+type specValidationServiceImpl struct {
+	apihubClient            client.ApihubClient
+	permissionService       PermissionService
+	specLintRulesRepository repository.SpecLintRulesRepository
+}
+
+func (s specValidationServiceImpl) GetSpecLintRules(workspaceId string) (*view.SpecLintRules, error) {
+	ent, err := s.specLintRulesRepository.GetSpecLintRules(workspaceId)
+	if err != nil {
+		return nil, err
+	}
+	if ent == nil {
+		return &view.SpecLintRules{WorkspaceId: workspaceId, Rules: make([]view.SpecLintRule, 0)}, nil
+	}
+	result := entity.MakeSpecLintRulesView(*ent)
+	return &result, nil
+}
+
+func (s specValidationServiceImpl) UpdateSpecLintRules(ctx context.Context, workspaceId string, req view.UpdateSpecLintRulesReq) (*view.SpecLintRules, error) {
+	err := checkWorkspaceManagePermission(ctx, s.apihubClient, s.permissionService, workspaceId)
+	if err != nil {
+		return nil, err
+	}
+	rules := req.Rules
+	if rules == nil {
+		rules = make([]view.SpecLintRule, 0)
+	}
+	for i := range rules {
+		if rules[i].Severity == "" {
+			rules[i].Severity = view.SpecFindingError
+		}
+		if err = validateSpecLintRule(i, rules[i]); err != nil {
+			return nil, err
+		}
+	}
+	ent := entity.SpecLintRulesEntity{
+		WorkspaceId: workspaceId,
+		Rules:       rules,
+		UpdatedAt:   time.Now(),
+		UpdatedBy:   secctx.GetUserId(ctx),
+	}
+	err = s.specLintRulesRepository.SaveSpecLintRules(&ent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store spec lint rules: %v", err.Error())
+	}
+	result := entity.MakeSpecLintRulesView(ent)
+	return &result, nil
+}
+
+func validateSpecLintRule(index int, rule view.SpecLintRule) error {
+	invalidParam := func(name string, reason string) error {
+		return &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.InvalidParameter,
+			Message: exception.InvalidParameterMsg,
+			Params:  map[string]interface{}{"param": fmt.Sprintf("rules[%d].%s", index, name)},
+			Debug:   reason,
+		}
+	}
+	if !strings.HasPrefix(rule.Path, "/") {
+		return invalidParam("path", "path must be a JSON pointer, e.g. /info/description")
+	}
+	if rule.Pattern != "" {
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return invalidParam("pattern", err.Error())
+		}
+	}
+	if rule.Severity != view.SpecFindingError && rule.Severity != view.SpecFindingWarning {
+		return invalidParam("severity", fmt.Sprintf("unknown severity '%s'", rule.Severity))
+	}
+	return nil
+}
+
+func (s specValidationServiceImpl) ValidateServiceSpecs(workspaceId string, serviceId string, documents []view.Document, contents [][]byte) (*view.ServiceSpecValidation, error) {
+	ent, err := s.specLintRulesRepository.GetSpecLintRules(workspaceId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get spec lint rules: %v", err.Error())
+	}
+	var rules []view.SpecLintRule
+	if ent != nil {
+		rules = ent.Rules
+	}
+	result := view.ServiceSpecValidation{ServiceId: serviceId, Findings: make([]view.SpecFinding, 0)}
+	for i, document := range documents {
+		result.Findings = append(result.Findings, validateDocument(document, contents[i], rules)...)
+	}
+	for _, finding := range result.Findings {
+		if finding.Severity == view.SpecFindingError {
+			result.Errors++
+		} else {
+			result.Warnings++
+		}
+	}
+	return &result, nil
+}
+
+func validateDocument(document view.Document, content []byte, rules []view.SpecLintRule) []view.SpecFinding {
+	findings := make([]view.SpecFinding, 0)
+	addFinding := func(severity view.SpecFindingSeverity, rule string, path string, message string) {
+		if len(findings) < maxFindingsPerDocument {
+			findings = append(findings, view.SpecFinding{FileId: document.FileId, Severity: severity, Rule: rule, Path: path, Message: message})
+		}
+	}
+	if len(bytes.TrimSpace(content)) == 0 {
+		addFinding(view.SpecFindingError, view.SpecFindingRuleEmpty, "", "document is empty")
+		return findings
+	}
+	if !utf8.Valid(content) {
+		addFinding(view.SpecFindingError, view.SpecFindingRuleSyntax, "", "document is not a valid UTF-8 text")
+		return findings
+	}
+	if !isStructuredDocument(document) {
+		if isBracedTextDocument(document) && !hasBalancedBraces(content) {
+			addFinding(view.SpecFindingError, view.SpecFindingRuleSyntax, "", "document has unbalanced braces, it may be truncated")
+		}
+		return findings
+	}
+
+	root, err := parseStructuredDocument(document, content)
+	if err != nil {
+		addFinding(view.SpecFindingError, view.SpecFindingRuleSyntax, "", err.Error())
+		return findings
+	}
+	rootObject, isObject := asObject(root)
+	if !isObject {
+		addFinding(view.SpecFindingError, view.SpecFindingRuleStructure, "", "document root is not an object")
+		return findings
+	}
+	var requiredFields []string
+	switch document.Type {
+	case view.OpenAPI30Type, view.OpenAPI31Type:
+		requiredFields = []string{"openapi", "info"}
+	case view.OpenAPI20Type:
+		requiredFields = []string{"swagger", "info", "paths"}
+	}
+	for _, field := range requiredFields {
+		if _, exists := rootObject[field]; !exists {
+			addFinding(view.SpecFindingError, view.SpecFindingRuleStructure, "/"+field, fmt.Sprintf("required field '%s' is missing", field))
+		}
+	}
+
+	externalRefs := make(map[string]struct{})
+	walkRefs(root, "", func(path string, ref string) {
+		if !strings.HasPrefix(ref, "#") {
+			if _, reported := externalRefs[ref]; !reported {
+				externalRefs[ref] = struct{}{}
+				addFinding(view.SpecFindingWarning, view.SpecFindingRuleExternalRef, path, fmt.Sprintf("external reference '%s' is not checked", ref))
+			}
+			return
+		}
+		pointer, err := url.PathUnescape(strings.TrimPrefix(ref, "#"))
+		if err != nil {
+			pointer = strings.TrimPrefix(ref, "#")
+		}
+		if _, found := resolveJsonPointer(root, pointer); !found {
+			addFinding(view.SpecFindingError, view.SpecFindingRuleUnresolvedRef, path, fmt.Sprintf("reference '%s' cannot be resolved", ref))
+		}
+	})
+
+	for _, rule := range rules {
+		if len(rule.DocumentTypes) > 0 && !slices.Contains(rule.DocumentTypes, document.Type) {
+			continue
+		}
+		value, found := resolveJsonPointer(root, rule.Path)
+		if !found {
+			addFinding(rule.Severity, rule.Name, rule.Path, "value is missing")
+			continue
+		}
+		if rule.Pattern == "" {
+			continue
+		}
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			continue
+		}
+		strValue, isString := value.(string)
+		if !isString {
+			valueBytes, _ := json.Marshal(value)
+			strValue = string(valueBytes)
+		}
+		if !pattern.MatchString(strValue) {
+			addFinding(rule.Severity, rule.Name, rule.Path, fmt.Sprintf("value '%s' doesn't match pattern '%s'", strValue, rule.Pattern))
+		}
+	}
+	return findings
+}
+
+func isStructuredDocument(document view.Document) bool {
+	switch strings.ToLower(document.Format) {
+	case "json", "yaml", "yml":
+		return true
+	}
+	return strings.HasPrefix(document.Type, "openapi") || strings.HasPrefix(document.Type, "json-schema")
+}
+
+func isBracedTextDocument(document view.Document) bool {
+	return strings.HasPrefix(document.Type, string(view.GraphqlApiType)) || strings.HasPrefix(document.Type, string(view.ProtobufApiType)) ||
+		strings.HasSuffix(document.FileId, ".graphql") || strings.HasSuffix(document.FileId, ".gql") || strings.HasSuffix(document.FileId, ".proto")
+}
+
+func parseStructuredDocument(document view.Document, content []byte) (interface{}, error) {
+	format := strings.ToLower(document.Format)
+	if format != "json" && format != "yaml" && format != "yml" {
+		if trimmed := bytes.TrimSpace(content); trimmed[0] == '{' || trimmed[0] == '[' {
+			format = "json"
+		} else {
+			format = "yaml"
+		}
+	}
+	var root interface{}
+	if format == "json" {
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.UseNumber()
+		if err := decoder.Decode(&root); err != nil {
+			if strings.Contains(err.Error(), "unexpected EOF") || strings.Contains(err.Error(), "unexpected end of JSON input") {
+				return nil, fmt.Errorf("document is truncated: %v", err.Error())
+			}
+			return nil, fmt.Errorf("invalid JSON: %v", err.Error())
+		}
+		if decoder.More() {
+			return nil, fmt.Errorf("invalid JSON: unexpected data after the document")
+		}
+		return root, nil
+	}
+	if err := yaml.Unmarshal(content, &root); err != nil {
+		return nil, fmt.Errorf("invalid YAML: %v", err.Error())
+	}
+	return root, nil
+}
+
+// asObject handles both JSON objects and YAML mappings
+func asObject(node interface{}) (map[string]interface{}, bool) {
+	switch typed := node.(type) {
+	case map[string]interface{}:
+		return typed, true
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(typed))
+		for k, v := range typed {
+			result[fmt.Sprintf("%v", k)] = v
+		}
+		return result, true
+	}
+	return nil, false
+}
+
+func walkRefs(node interface{}, path string, onRef func(path string, ref string)) {
+	if object, isObject := asObject(node); isObject {
+		for _, key := range slices.Sorted(maps.Keys(object)) {
+			value := object[key]
+			childPath := path + "/" + escapeJsonPointerToken(key)
+			if ref, isString := value.(string); key == "$ref" && isString {
+				onRef(childPath, ref)
+				continue
+			}
+			walkRefs(value, childPath, onRef)
+		}
+		return
+	}
+	if array, isArray := node.([]interface{}); isArray {
+		for i, value := range array {
+			walkRefs(value, path+"/"+strconv.Itoa(i), onRef)
+		}
+	}
+}
+
+func resolveJsonPointer(root interface{}, pointer string) (interface{}, bool) {
+	if pointer == "" {
+		return root, true
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, false
+	}
+	current := root
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		if object, isObject := asObject(current); isObject {
+			value, exists := object[token]
+			if !exists {
+				return nil, false
+			}
+			current = value
+			continue
+		}
+		if array, isArray := current.([]interface{}); isArray {
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(array) {
+				return nil, false
+			}
+			current = array[index]
+			continue
+		}
+		return nil, false
+	}
+	return current, true
+}
+
+func escapeJsonPointerToken(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+// hasBalancedBraces is a truncation check for text documents like GraphQL and Protobuf, braces in quoted strings are ignored
+func hasBalancedBraces(content []byte) bool {
+	depth := 0
+	inString := false
+	for i := 0; i < len(content); i++ {
+		switch c := content[i]; {
+		case inString && c == '\\':
+			i++
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth < 0 {
+				return false
+			}
+		}
+	}
+	return depth == 0
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/Netcracker/qubership-apihub-agents-backend/view"
+)
+
+func TestValidateDocument(t *testing.T) {
+	openapiDocument := view.Document{FileId: "openapi.json", Format: "json", Type: view.OpenAPI30Type}
+	yamlDocument := view.Document{FileId: "openapi.yaml", Format: "yaml", Type: view.OpenAPI30Type}
+	graphqlDocument := view.Document{FileId: "schema.graphql", Format: "graphql", Type: string(view.GraphqlApiType)}
+	descriptionRule := view.SpecLintRule{Name: "description", Path: "/info/description", Severity: view.SpecFindingWarning}
+	versionRule := view.SpecLintRule{Name: "version", Path: "/info/version", Pattern: `^\d+\.\d+\.\d+$`, Severity: view.SpecFindingError}
+
+	tests := []struct {
+		name     string
+		document view.Document
+		content  string
+		rules    []view.SpecLintRule
+		expected []string // rule and path of each finding
+	}{
+		{
+			name:     "valid document",
+			document: openapiDocument,
+			content:  `{"openapi": "3.0.0", "info": {"title": "t", "version": "1.0.0"}, "paths": {}}`,
+			expected: []string{},
+		},
+		{
+			name:     "empty document",
+			document: openapiDocument,
+			content:  " \n",
+			expected: []string{view.SpecFindingRuleEmpty + " "},
+		},
+		{
+			name:     "truncated json",
+			document: openapiDocument,
+			content:  `{"openapi": "3.0.0", "info": {`,
+			expected: []string{view.SpecFindingRuleSyntax + " "},
+		},
+		{
+			name:     "data after json document",
+			document: openapiDocument,
+			content:  `{"openapi": "3.0.0", "info": {}} {}`,
+			expected: []string{view.SpecFindingRuleSyntax + " "},
+		},
+		{
+			name:     "root is not an object",
+			document: openapiDocument,
+			content:  `["openapi"]`,
+			expected: []string{view.SpecFindingRuleStructure + " "},
+		},
+		{
+			name:     "required field is missing",
+			document: yamlDocument,
+			content:  "openapi: 3.0.0\npaths: {}\n",
+			expected: []string{view.SpecFindingRuleStructure + " /info"},
+		},
+		{
+			name:     "unresolved and external references",
+			document: yamlDocument,
+			content: "openapi: 3.0.0\ninfo: {}\ncomponents:\n  schemas:\n    A: {type: string}\n" +
+				"paths:\n  /a:\n    get:\n      responses:\n        '200': {$ref: '#/components/schemas/A'}\n" +
+				"        '400': {$ref: '#/components/schemas/B'}\n        '500': {$ref: 'common.yaml#/E'}\n",
+			expected: []string{
+				view.SpecFindingRuleUnresolvedRef + " /paths/~1a/get/responses/400/$ref",
+				view.SpecFindingRuleExternalRef + " /paths/~1a/get/responses/500/$ref",
+			},
+		},
+		{
+			name:     "lint rules",
+			document: openapiDocument,
+			content:  `{"openapi": "3.0.0", "info": {"version": "v1"}}`,
+			rules:    []view.SpecLintRule{descriptionRule, versionRule},
+			expected: []string{"description /info/description", "version /info/version"},
+		},
+		{
+			name:     "lint rule of other document type is skipped",
+			document: openapiDocument,
+			content:  `{"openapi": "3.0.0", "info": {}}`,
+			rules:    []view.SpecLintRule{{Name: "description", Path: "/info/description", DocumentTypes: []string{"openapi-2-0"}}},
+			expected: []string{},
+		},
+		{
+			name:     "unbalanced braces in text document",
+			document: graphqlDocument,
+			content:  "type Query {\n  a: String\n",
+			expected: []string{view.SpecFindingRuleSyntax + " "},
+		},
+		{
+			name:     "text document is not parsed",
+			document: graphqlDocument,
+			content:  "type Query {\n  a: String\n}\n",
+			rules:    []view.SpecLintRule{descriptionRule},
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := validateDocument(tt.document, []byte(tt.content), tt.rules)
+			if len(findings) != len(tt.expected) {
+				t.Fatalf("Expected %d findings, got %+v", len(tt.expected), findings)
+			}
+			for i, finding := range findings {
+				if result := finding.Rule + " " + finding.Path; result != tt.expected[i] {
+					t.Errorf("Expected finding %q, got %q", tt.expected[i], result)
+				}
+				if finding.FileId != tt.document.FileId {
+					t.Errorf("Expected file id %q, got %q", tt.document.FileId, finding.FileId)
+				}
+			}
+		})
+	}
+}
+
+func TestHasBalancedBraces(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected bool
+	}{
+		{name: "no braces", content: "scalar Date", expected: true},
+		{name: "nested braces", content: "message A { message B { string c = 1; } }", expected: true},
+		{name: "missing closing brace", content: "type Query { a: String", expected: false},
+		{name: "closing brace first", content: "} type Query {", expected: false},
+		{name: "braces in strings are ignored", content: `type Query { a: String @deprecated(reason: "use {b}") }`, expected: true},
+		{name: "unbalanced braces in strings are ignored", content: `type Query { "{" a: String }`, expected: true},
+		{name: "escaped quote in string", content: `type Query { "\"{" a: String }`, expected: true},
+		{name: "truncated string", content: `type Query { "a }`, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := hasBalancedBraces([]byte(tt.content)); result != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestResolveJsonPointer(t *testing.T) {
+	root := map[string]interface{}{
+		"info": map[string]interface{}{"title": "t"},
+		"paths": map[interface{}]interface{}{
+			"/a/{id}": map[string]interface{}{"get": "op"},
+		},
+		"tags":  []interface{}{"first", map[string]interface{}{"name": "second"}},
+		"a~b":   "tilde",
+		"empty": "",
+	}
+
+	tests := []struct {
+		name     string
+		pointer  string
+		expected interface{}
+		found    bool
+	}{
+		{name: "object field", pointer: "/info/title", expected: "t", found: true},
+		{name: "escaped slash in yaml mapping", pointer: "/paths/~1a~1{id}/get", expected: "op", found: true},
+		{name: "escaped tilde", pointer: "/a~0b", expected: "tilde", found: true},
+		{name: "array item", pointer: "/tags/1/name", expected: "second", found: true},
+		{name: "empty value", pointer: "/empty", expected: "", found: true},
+		{name: "missing field", pointer: "/info/version", found: false},
+		{name: "array index out of range", pointer: "/tags/2", found: false},
+		{name: "negative array index", pointer: "/tags/-1", found: false},
+		{name: "not a number array index", pointer: "/tags/first", found: false},
+		{name: "path through scalar", pointer: "/info/title/x", found: false},
+		{name: "pointer without leading slash", pointer: "info", found: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, found := resolveJsonPointer(root, tt.pointer)
+			if found != tt.found {
+				t.Fatalf("Expected found %v, got %v", tt.found, found)
+			}
+			if found && result != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+
+	if result, found := resolveJsonPointer(root, ""); !found || result == nil {
+		t.Errorf("Expected the root for the empty pointer, got %v", result)
+	}
+}
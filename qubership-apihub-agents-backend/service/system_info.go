@@ -13,20 +13,23 @@ const (
 	BASE_PATH    = "BASE_PATH"
 	API_SPEC_DIR = "API_SPEC_DIR"
 
-	POSTGRESQL_HOST            = "AGENTS_BACKEND_POSTGRESQL_HOST"
-	POSTGRESQL_PORT            = "AGENTS_BACKEND_POSTGRESQL_PORT"
-	POSTGRESQL_DB_NAME         = "AGENTS_BACKEND_POSTGRESQL_DB_NAME"
-	POSTGRESQL_USERNAME        = "AGENTS_BACKEND_POSTGRESQL_USERNAME"
-	POSTGRESQL_PASSWORD        = "AGENTS_BACKEND_POSTGRESQL_PASSWORD"
-	APIHUB_URL                 = "APIHUB_URL"
-	APIHUB_ACCESS_TOKEN        = "APIHUB_ACCESS_TOKEN"
-	DEFAULT_WORKSPACE_ID       = "DEFAULT_WORKSPACE_ID"
-	SNAPSHOTS_CLEANUP_SCHEDULE = "SNAPSHOTS_CLEANUP_SCHEDULE"
-	SNAPSHOTS_TTL_DAYS         = "SNAPSHOTS_TTL_DAYS"
-	INSECURE_PROXY             = "INSECURE_PROXY" //TODO: remove this after deprecated proxy path is removed
-	LISTEN_ADDRESS             = "LISTEN_ADDRESS"
-	ORIGIN_ALLOWED             = "ORIGIN_ALLOWED"
-	LOG_LEVEL                  = "LOG_LEVEL"
+	POSTGRESQL_HOST                      = "AGENTS_BACKEND_POSTGRESQL_HOST"
+	POSTGRESQL_PORT                      = "AGENTS_BACKEND_POSTGRESQL_PORT"
+	POSTGRESQL_DB_NAME                   = "AGENTS_BACKEND_POSTGRESQL_DB_NAME"
+	POSTGRESQL_USERNAME                  = "AGENTS_BACKEND_POSTGRESQL_USERNAME"
+	POSTGRESQL_PASSWORD                  = "AGENTS_BACKEND_POSTGRESQL_PASSWORD"
+	APIHUB_URL                           = "APIHUB_URL"
+	APIHUB_ACCESS_TOKEN                  = "APIHUB_ACCESS_TOKEN"
+	DEFAULT_WORKSPACE_ID                 = "DEFAULT_WORKSPACE_ID"
+	SNAPSHOTS_CLEANUP_SCHEDULE           = "SNAPSHOTS_CLEANUP_SCHEDULE"
+	SNAPSHOTS_TTL_DAYS                   = "SNAPSHOTS_TTL_DAYS"
+	AUTH_SECURITY_CHECK_CLEANUP_SCHEDULE = "AUTH_SECURITY_CHECK_CLEANUP_SCHEDULE"
+	AUTH_SECURITY_CHECK_KEEP_LAST        = "AUTH_SECURITY_CHECK_KEEP_LAST"
+	DISCOVERY_HISTORY_KEEP_LAST          = "DISCOVERY_HISTORY_KEEP_LAST"
+	INSECURE_PROXY                       = "INSECURE_PROXY" //TODO: remove this after deprecated proxy path is removed
+	LISTEN_ADDRESS                       = "LISTEN_ADDRESS"
+	ORIGIN_ALLOWED                       = "ORIGIN_ALLOWED"
+	LOG_LEVEL                            = "LOG_LEVEL"
 )
 
 type SystemInfoService interface {
@@ -45,6 +48,9 @@ type SystemInfoService interface {
 	GetDefaultWorkspaceId() string
 	GetSnapshotsCleanupSchedule() string
 	GetSnapshotsTTLDays() int
+	GetAuthSecurityCheckCleanupSchedule() string
+	GetAuthSecurityCheckKeepLast() int
+	GetDiscoveryHistoryKeepLast() int
 	InsecureProxyEnabled() bool //TODO: remove this after deprecated proxy path is removed
 	GetListenAddress() string
 	GetOriginAllowed() string
@@ -81,6 +87,9 @@ func (s systemInfoServiceImpl) Init() error {
 	s.setDefaultWorkspaceId()
 	s.setSnapshotsCleanupSchedule()
 	s.setSnapshotsTTLDays()
+	s.setAuthSecurityCheckCleanupSchedule()
+	s.setAuthSecurityCheckKeepLast()
+	s.setDiscoveryHistoryKeepLast()
 	s.setInsecureProxy()
 
 	s.setListenAddress()
@@ -253,6 +262,52 @@ func (s systemInfoServiceImpl) GetSnapshotsTTLDays() int {
 	return s.systemInfoMap[SNAPSHOTS_TTL_DAYS].(int)
 }
 
+func (s systemInfoServiceImpl) setAuthSecurityCheckCleanupSchedule() {
+	schedule := os.Getenv(AUTH_SECURITY_CHECK_CLEANUP_SCHEDULE)
+	if schedule == "" {
+		schedule = "0 23 * * 0" // at 11:00 PM on Sunday
+	}
+	s.systemInfoMap[AUTH_SECURITY_CHECK_CLEANUP_SCHEDULE] = schedule
+}
+
+func (s systemInfoServiceImpl) GetAuthSecurityCheckCleanupSchedule() string {
+	return s.systemInfoMap[AUTH_SECURITY_CHECK_CLEANUP_SCHEDULE].(string)
+}
+
+func (s systemInfoServiceImpl) setAuthSecurityCheckKeepLast() {
+	envVal := os.Getenv(AUTH_SECURITY_CHECK_KEEP_LAST)
+	if envVal == "" {
+		envVal = "10"
+	}
+	val, err := strconv.Atoi(envVal)
+	if err != nil || val < 1 {
+		log.Errorf("invalid %v env value: %v. Value by default - 10", AUTH_SECURITY_CHECK_KEEP_LAST, envVal)
+		val = 10
+	}
+	s.systemInfoMap[AUTH_SECURITY_CHECK_KEEP_LAST] = val
+}
+
+func (s systemInfoServiceImpl) GetAuthSecurityCheckKeepLast() int {
+	return s.systemInfoMap[AUTH_SECURITY_CHECK_KEEP_LAST].(int)
+}
+
+func (s systemInfoServiceImpl) setDiscoveryHistoryKeepLast() {
+	envVal := os.Getenv(DISCOVERY_HISTORY_KEEP_LAST)
+	if envVal == "" {
+		envVal = "50"
+	}
+	val, err := strconv.Atoi(envVal)
+	if err != nil || val < 1 {
+		log.Errorf("invalid %v env value: %v. Value by default - 50", DISCOVERY_HISTORY_KEEP_LAST, envVal)
+		val = 50
+	}
+	s.systemInfoMap[DISCOVERY_HISTORY_KEEP_LAST] = val
+}
+
+func (s systemInfoServiceImpl) GetDiscoveryHistoryKeepLast() int {
+	return s.systemInfoMap[DISCOVERY_HISTORY_KEEP_LAST].(int)
+}
+
 func (s systemInfoServiceImpl) InsecureProxyEnabled() bool {
 	return s.systemInfoMap[INSECURE_PROXY].(bool)
 }
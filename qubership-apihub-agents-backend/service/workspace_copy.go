@@ -0,0 +1,909 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Netcracker/qubership-apihub-agents-backend/client"
+	"github.com/Netcracker/qubership-apihub-agents-backend/entity"
+	"github.com/Netcracker/qubership-apihub-agents-backend/exception"
+	"github.com/Netcracker/qubership-apihub-agents-backend/repository"
+	"github.com/Netcracker/qubership-apihub-agents-backend/secctx"
+	"github.com/Netcracker/qubership-apihub-agents-backend/utils"
+	"github.com/Netcracker/qubership-apihub-agents-backend/view"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+)
+
+const workspaceCopyConcurrency = 10
+const workspaceCopyPageSize = 100
+const workspaceLockWaitTimeout = time.Minute
+const workspaceLockRetryInterval = time.Second
+
+// WorkspaceCopyService copies groups and service packages structure from one workspace to another.
+// Every copy is stored together with the list of packages to create, so a failed copy can be resumed or rolled back
+type WorkspaceCopyService interface {
+	// PreviewWorkspaceCopy returns the list of packages which would be created by the copy without creating them
+	PreviewWorkspaceCopy(ctx context.Context, dstWorkspaceId string, req view.WorkspaceCopyReq) (*view.WorkspaceCopyPlan, error)
+	// CopyWorkspaceStructure creates missing packages in the destination workspace.
+	// Nothing is stored if all packages already exist
+	CopyWorkspaceStructure(ctx context.Context, dstWorkspaceId string, req view.WorkspaceCopyReq) (*view.WorkspaceCopy, error)
+	// ResumeWorkspaceCopy creates packages which were not created by the failed or interrupted copy
+	ResumeWorkspaceCopy(ctx context.Context, dstWorkspaceId string, copyId string) (*view.WorkspaceCopy, error)
+	// RollbackWorkspaceCopy deletes packages created by the copy in the reverse order.
+	// Packages which got content after the copy (child packages or published versions) are kept
+	RollbackWorkspaceCopy(ctx context.Context, dstWorkspaceId string, copyId string) (*view.WorkspaceCopy, error)
+	GetWorkspaceCopy(dstWorkspaceId string, copyId string) (*view.WorkspaceCopy, error)
+	ListWorkspaceCopies(dstWorkspaceId string, limit int, page int) (*view.WorkspaceCopies, error)
+	// GetWorkspaceDrift lists packages copied from the source workspace which differ from their source packages
+	GetWorkspaceDrift(ctx context.Context, dstWorkspaceId string, srcWorkspaceId string) (*view.WorkspaceDriftReport, error)
+	// SyncWorkspaceStructure updates metadata of copied packages which differ from their source packages.
+	// Packages which were moved or deleted in the source workspace are only reported
+	SyncWorkspaceStructure(ctx context.Context, dstWorkspaceId string, req view.WorkspaceSyncReq) (*view.WorkspaceDriftReport, error)
+}
+
+func NewWorkspaceCopyService(apihubClient client.ApihubClient, systemInfoService SystemInfoService, jobLockService JobLockService, workspaceCopyRepository repository.WorkspaceCopyRepository) WorkspaceCopyService {
+	return &workspaceCopyServiceImpl{
+		defaultWorkspaceId:      systemInfoService.GetDefaultWorkspaceId(),
+		apihubClient:            apihubClient,
+		jobLockService:          jobLockService,
+		workspaceCopyRepository: workspaceCopyRepository,
+	}
+}
+
+type workspaceCopyServiceImpl struct {
+	defaultWorkspaceId      string
+	apihubClient            client.ApihubClient
+	jobLockService          JobLockService
+	workspaceCopyRepository repository.WorkspaceCopyRepository
+}
+
+func (w workspaceCopyServiceImpl) PreviewWorkspaceCopy(ctx context.Context, dstWorkspaceId string, req view.WorkspaceCopyReq) (*view.WorkspaceCopyPlan, error) {
+	dstWorkspace, srcWorkspaceId, err := w.validateWorkspaces(ctx, dstWorkspaceId, req.SrcWorkspaceId)
+	if err != nil {
+		return nil, err
+	}
+	return w.planWorkspaceCopy(ctx, srcWorkspaceId, dstWorkspaceId, req.ServiceNames, dstWorkspace.DefaultRole)
+}
+
+func (w workspaceCopyServiceImpl) CopyWorkspaceStructure(ctx context.Context, dstWorkspaceId string, req view.WorkspaceCopyReq) (*view.WorkspaceCopy, error) {
+	dstWorkspace, srcWorkspaceId, err := w.validateWorkspaces(ctx, dstWorkspaceId, req.SrcWorkspaceId)
+	if err != nil {
+		return nil, err
+	}
+	ctx, unlock, err := w.lockWorkspace(ctx, dstWorkspaceId)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	plan, err := w.planWorkspaceCopy(ctx, srcWorkspaceId, dstWorkspaceId, req.ServiceNames, dstWorkspace.DefaultRole)
+	if err != nil {
+		return nil, err
+	}
+	copyEnt := entity.WorkspaceCopyEntity{
+		SrcWorkspaceId: srcWorkspaceId,
+		DstWorkspaceId: dstWorkspaceId,
+		DefaultRole:    dstWorkspace.DefaultRole,
+		Status:         string(view.WorkspaceCopyStatusComplete),
+		CreatedAt:      time.Now(),
+		CreatedBy:      secctx.GetUserId(ctx),
+	}
+	if len(plan.Packages) == 0 {
+		result := entity.MakeWorkspaceCopyView(copyEnt, nil)
+		return &result, nil
+	}
+	copyEnt.CopyId = uuid.NewString()
+	copyEnt.Status = string(view.WorkspaceCopyStatusRunning)
+	packageEnts := make([]entity.WorkspaceCopyPackageEntity, 0, len(plan.Packages))
+	for i, pkg := range plan.Packages {
+		packageEnts = append(packageEnts, entity.MakeWorkspaceCopyPackageEntity(copyEnt.CopyId, i, pkg))
+	}
+	err = w.workspaceCopyRepository.SaveWorkspaceCopy(&copyEnt, packageEnts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store workspace structure copy: %v", err.Error())
+	}
+	w.applyWorkspaceCopy(ctx, &copyEnt, packageEnts)
+	result := entity.MakeWorkspaceCopyView(copyEnt, packageEnts)
+	return &result, nil
+}
+
+func (w workspaceCopyServiceImpl) ResumeWorkspaceCopy(ctx context.Context, dstWorkspaceId string, copyId string) (*view.WorkspaceCopy, error) {
+	ctx, unlock, err := w.lockWorkspace(ctx, dstWorkspaceId)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	copyEnt, packageEnts, err := w.getWorkspaceCopyEntities(dstWorkspaceId, copyId)
+	if err != nil {
+		return nil, err
+	}
+	// running copy which is not locked by any instance was interrupted, e.g. by the restart
+	if copyEnt.Status != string(view.WorkspaceCopyStatusFailed) && copyEnt.Status != string(view.WorkspaceCopyStatusRunning) {
+		return nil, makeIncorrectWorkspaceCopyStatusError(*copyEnt, "resume")
+	}
+	copyEnt.Status = string(view.WorkspaceCopyStatusRunning)
+	copyEnt.Details = ""
+	copyEnt.FinishedAt = nil
+	err = w.workspaceCopyRepository.UpdateWorkspaceCopyStatus(copyEnt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update workspace structure copy status: %v", err.Error())
+	}
+	w.applyWorkspaceCopy(ctx, copyEnt, packageEnts)
+	result := entity.MakeWorkspaceCopyView(*copyEnt, packageEnts)
+	return &result, nil
+}
+
+func (w workspaceCopyServiceImpl) RollbackWorkspaceCopy(ctx context.Context, dstWorkspaceId string, copyId string) (*view.WorkspaceCopy, error) {
+	ctx, unlock, err := w.lockWorkspace(ctx, dstWorkspaceId)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	copyEnt, packageEnts, err := w.getWorkspaceCopyEntities(dstWorkspaceId, copyId)
+	if err != nil {
+		return nil, err
+	}
+	if copyEnt.Status == string(view.WorkspaceCopyStatusRolledBack) {
+		return nil, makeIncorrectWorkspaceCopyStatusError(*copyEnt, "roll back")
+	}
+	notRolledBack := 0
+	for i := len(packageEnts) - 1; i >= 0; i-- {
+		packageEnt := &packageEnts[i]
+		if packageEnt.Status != string(view.WorkspaceCopyPackageStatusCreated) {
+			continue
+		}
+		w.rollbackPackage(ctx, packageEnt)
+		if packageEnt.Status != string(view.WorkspaceCopyPackageStatusRolledBack) {
+			notRolledBack++
+		}
+	}
+	finishedAt := time.Now()
+	copyEnt.FinishedAt = &finishedAt
+	if notRolledBack == 0 {
+		copyEnt.Status = string(view.WorkspaceCopyStatusRolledBack)
+		copyEnt.Details = ""
+	} else {
+		copyEnt.Status = string(view.WorkspaceCopyStatusFailed)
+		copyEnt.Details = fmt.Sprintf("%d created packages were not rolled back", notRolledBack)
+	}
+	err = w.workspaceCopyRepository.UpdateWorkspaceCopyStatus(copyEnt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update workspace structure copy status: %v", err.Error())
+	}
+	result := entity.MakeWorkspaceCopyView(*copyEnt, packageEnts)
+	return &result, nil
+}
+
+func (w workspaceCopyServiceImpl) GetWorkspaceCopy(dstWorkspaceId string, copyId string) (*view.WorkspaceCopy, error) {
+	copyEnt, packageEnts, err := w.getWorkspaceCopyEntities(dstWorkspaceId, copyId)
+	if err != nil {
+		return nil, err
+	}
+	result := entity.MakeWorkspaceCopyView(*copyEnt, packageEnts)
+	return &result, nil
+}
+
+func (w workspaceCopyServiceImpl) ListWorkspaceCopies(dstWorkspaceId string, limit int, page int) (*view.WorkspaceCopies, error) {
+	ents, err := w.workspaceCopyRepository.ListWorkspaceCopies(dstWorkspaceId, limit, page)
+	if err != nil {
+		return nil, err
+	}
+	result := view.WorkspaceCopies{Copies: make([]view.WorkspaceCopy, 0, len(ents))}
+	for _, ent := range ents {
+		result.Copies = append(result.Copies, entity.MakeWorkspaceCopyView(ent, nil))
+	}
+	return &result, nil
+}
+
+// lockWorkspace makes sure that only one copy creates packages in the destination workspace at a time across all replicas.
+// The lock held by another operation is awaited for workspaceLockWaitTimeout. The returned context is cancelled if the lock is lost
+func (w workspaceCopyServiceImpl) lockWorkspace(ctx context.Context, dstWorkspaceId string) (context.Context, func(), error) {
+	lockName := "workspace_copy:" + dstWorkspaceId
+	deadline := time.Now().Add(workspaceLockWaitTimeout)
+	for {
+		lockCtx, unlock, err := w.jobLockService.AcquireLock(ctx, lockName)
+		if err != nil {
+			return nil, nil, err
+		}
+		if lockCtx != nil {
+			return lockCtx, unlock, nil
+		}
+		if time.Now().After(deadline) {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-time.After(workspaceLockRetryInterval):
+		}
+	}
+	return nil, nil, &exception.CustomError{
+		Status:  http.StatusConflict,
+		Code:    exception.WorkspaceLocked,
+		Message: exception.WorkspaceLockedMsg,
+		Params:  map[string]interface{}{"workspaceId": dstWorkspaceId},
+	}
+}
+
+// validateWorkspaces returns the destination workspace and the source workspace id which defaults to the default workspace
+func (w workspaceCopyServiceImpl) validateWorkspaces(ctx context.Context, dstWorkspaceId string, srcWorkspaceId string) (*view.SimplePackage, string, error) {
+	if srcWorkspaceId == "" {
+		srcWorkspaceId = w.defaultWorkspaceId
+	}
+	if srcWorkspaceId == "" {
+		return nil, "", &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.RequiredParamsMissing,
+			Message: exception.RequiredParamsMissingMsg,
+			Params:  map[string]interface{}{"params": "srcWorkspaceId"},
+		}
+	}
+	if srcWorkspaceId == dstWorkspaceId {
+		return nil, "", &exception.CustomError{
+			Status:  http.StatusBadRequest,
+			Code:    exception.InvalidParameter,
+			Message: exception.InvalidParameterMsg,
+			Params:  map[string]interface{}{"param": "srcWorkspaceId"},
+			Debug:   "source and destination workspaces must be different",
+		}
+	}
+	var dstWorkspace *view.SimplePackage
+	for _, workspaceId := range []string{srcWorkspaceId, dstWorkspaceId} {
+		workspace, err := w.apihubClient.GetPackageById(ctx, workspaceId)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to get workspace by id: %v", err.Error())
+		}
+		if workspace == nil || workspace.Kind != string(view.KindWorkspace) {
+			return nil, "", &exception.CustomError{
+				Status:  http.StatusNotFound,
+				Code:    exception.WorkspaceNotFound,
+				Message: exception.WorkspaceNotFoundMsg,
+				Params:  map[string]interface{}{"workspaceId": workspaceId},
+			}
+		}
+		if workspaceId == dstWorkspaceId {
+			dstWorkspace = workspace
+		}
+	}
+	return dstWorkspace, srcWorkspaceId, nil
+}
+
+func (w workspaceCopyServiceImpl) getWorkspaceCopyEntities(dstWorkspaceId string, copyId string) (*entity.WorkspaceCopyEntity, []entity.WorkspaceCopyPackageEntity, error) {
+	copyEnt, err := w.workspaceCopyRepository.GetWorkspaceCopy(copyId)
+	if err != nil {
+		return nil, nil, err
+	}
+	if copyEnt == nil || copyEnt.DstWorkspaceId != dstWorkspaceId {
+		return nil, nil, &exception.CustomError{
+			Status:  http.StatusNotFound,
+			Code:    exception.WorkspaceCopyNotFound,
+			Message: exception.WorkspaceCopyNotFoundMsg,
+			Params:  map[string]interface{}{"copyId": copyId},
+		}
+	}
+	packageEnts, err := w.workspaceCopyRepository.GetWorkspaceCopyPackages(copyId)
+	if err != nil {
+		return nil, nil, err
+	}
+	return copyEnt, packageEnts, nil
+}
+
+func makeIncorrectWorkspaceCopyStatusError(copyEnt entity.WorkspaceCopyEntity, action string) error {
+	return &exception.CustomError{
+		Status:  http.StatusConflict,
+		Code:    exception.IncorrectWorkspaceCopyStatus,
+		Message: exception.IncorrectWorkspaceCopyStatusMsg,
+		Params:  map[string]interface{}{"action": action, "copyId": copyEnt.CopyId, "status": copyEnt.Status},
+	}
+}
+
+// planWorkspaceCopy calculates packages to create in the destination workspace for the services which have a package
+// in the source workspace only. Missing parent groups are copied from the source workspace under the product group
+func (w workspaceCopyServiceImpl) planWorkspaceCopy(ctx context.Context, srcWorkspaceId string, dstWorkspaceId string, serviceNames []string, defaultRole string) (*view.WorkspaceCopyPlan, error) {
+	result := view.WorkspaceCopyPlan{
+		SrcWorkspaceId:   srcWorkspaceId,
+		DstWorkspaceId:   dstWorkspaceId,
+		DefaultRole:      defaultRole,
+		Packages:         make([]view.WorkspaceCopyPackage, 0),
+		ExistingServices: make([]string, 0),
+		MissingServices:  make([]string, 0),
+	}
+	mutex := &sync.Mutex{}
+	srcPackagesToCopy := make([]*view.PackagesInfo, 0)
+
+	wg := sync.WaitGroup{}
+	errMap := sync.Map{}
+	for _, serviceName := range serviceNames {
+		svcName := serviceName
+		wg.Add(1)
+		utils.SafeAsync(func() {
+			defer wg.Done()
+			dstPackage, err := w.apihubClient.GetPackageByServiceName(ctx, dstWorkspaceId, svcName)
+			if err != nil {
+				errMap.Store(fmt.Sprintf("failed to get apihub package by service name: %v", err.Error()), nil)
+				return
+			}
+			if dstPackage != nil {
+				mutex.Lock()
+				result.ExistingServices = append(result.ExistingServices, svcName)
+				mutex.Unlock()
+				return
+			}
+			srcPackage, err := w.apihubClient.GetPackageByServiceName(ctx, srcWorkspaceId, svcName)
+			if err != nil {
+				errMap.Store(fmt.Sprintf("failed to get apihub package by service name: %v", err.Error()), nil)
+				return
+			}
+			mutex.Lock()
+			if srcPackage == nil {
+				result.MissingServices = append(result.MissingServices, svcName)
+			} else {
+				srcPackagesToCopy = append(srcPackagesToCopy, srcPackage)
+			}
+			mutex.Unlock()
+		})
+	}
+	wg.Wait()
+
+	if errList := collectErrors(&errMap); len(errList) > 0 {
+		return nil, fmt.Errorf("failed to calculate service packages to copy: %v", strings.Join(errList, ", "))
+	}
+	sort.Strings(result.ExistingServices)
+	sort.Strings(result.MissingServices)
+	if len(srcPackagesToCopy) == 0 {
+		return &result, nil
+	}
+
+	newGroups := make([]view.WorkspaceCopyPackage, 0)
+	newPackages := make([]view.WorkspaceCopyPackage, 0, len(srcPackagesToCopy))
+
+	productPackageId := fmt.Sprintf("%s.%s", dstWorkspaceId, srcWorkspaceId)
+	productPackage, err := w.apihubClient.GetPackageById(ctx, productPackageId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get apihub package by id: %v", err.Error())
+	}
+	if productPackage == nil {
+		newGroups = append(newGroups, view.WorkspaceCopyPackage{
+			PackageId:   productPackageId,
+			ParentId:    dstWorkspaceId,
+			Kind:        string(view.KindGroup),
+			Name:        fmt.Sprintf("%s Product", srcWorkspaceId),
+			Alias:       srcWorkspaceId,
+			Description: fmt.Sprintf("Group to sync packages from '%v' workspace during service discovery", srcWorkspaceId),
+			Status:      view.WorkspaceCopyPackageStatusPlanned,
+		})
+	} else {
+		if productPackage.Kind != string(view.KindGroup) {
+			return nil, fmt.Errorf("unable to copy service packages from '%v' to '%v' workspace: package '%v' has invalid package type", srcWorkspaceId, dstWorkspaceId, productPackageId)
+		}
+	}
+
+	seenPackages := make(map[string]struct{})
+	seenPackages[productPackageId] = struct{}{}
+	isSeen := func(packageId string) bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		_, exists := seenPackages[packageId]
+		return exists
+	}
+
+	errMap = sync.Map{}
+	wg = sync.WaitGroup{}
+	for _, srtPkg := range srcPackagesToCopy {
+		wg.Add(1)
+		srcPackage := srtPkg
+		utils.SafeAsync(func() {
+			defer wg.Done()
+			newPackageId := fmt.Sprintf("%s.%s", dstWorkspaceId, srcPackage.Id)
+			dstPackage, err := w.apihubClient.GetPackageById(ctx, newPackageId)
+			if err != nil {
+				errMap.Store(fmt.Sprintf("failed to get apihub package by id: %v", err.Error()), nil)
+				return
+			}
+			if dstPackage != nil {
+				errMap.Store(fmt.Sprintf("unable to copy '%v' package from '%v' to '%v' workspace: package with id='%v' and different serviceName already exists", srcPackage.Id, srcWorkspaceId, dstWorkspaceId, newPackageId), nil)
+				return
+			}
+			srcParentIds := getOrderedParentPackageIds(srcPackage.Id)
+			for _, srcParentId := range srcParentIds {
+				dstParentId := fmt.Sprintf("%s.%s", dstWorkspaceId, srcParentId)
+				if isSeen(dstParentId) {
+					continue
+				}
+				dstParent, err := w.apihubClient.GetPackageById(ctx, dstParentId)
+				if err != nil {
+					errMap.Store(fmt.Sprintf("failed to get apihub package by id: %v", err.Error()), nil)
+					return
+				}
+				if dstParent == nil {
+					srcParent, err := w.apihubClient.GetPackageById(ctx, srcParentId)
+					if err != nil {
+						errMap.Store(fmt.Sprintf("failed to get apihub package by id: %v", err.Error()), nil)
+						return
+					}
+					if srcParent == nil {
+						errMap.Store(fmt.Sprintf("unable to copy parents structure for '%v' package: parent package '%v' doesn't exist", srcPackage.Id, srcParentId), nil)
+						return
+					}
+					mutex.Lock()
+					if _, exists := seenPackages[dstParentId]; !exists {
+						newGroups = append(newGroups, view.WorkspaceCopyPackage{
+							PackageId:             dstParentId,
+							SrcPackageId:          srcParentId,
+							ParentId:              getParentPackageId(dstParentId),
+							Kind:                  string(view.KindGroup),
+							Name:                  srcParent.Name,
+							Alias:                 srcParent.Alias,
+							Description:           srcParent.Description,
+							ImageUrl:              srcParent.ImageUrl,
+							ReleaseVersionPattern: srcParent.ReleaseVersionPattern,
+							Status:                view.WorkspaceCopyPackageStatusPlanned,
+						})
+						seenPackages[dstParentId] = struct{}{}
+					}
+					mutex.Unlock()
+					continue
+				}
+				if dstParent.Kind != string(view.KindGroup) {
+					errMap.Store(fmt.Sprintf("unable to copy service packages from '%v' to '%v' workspace: package '%v' has invalid package type", srcWorkspaceId, dstWorkspaceId, dstParentId), nil)
+					return
+				}
+				mutex.Lock()
+				seenPackages[dstParentId] = struct{}{}
+				mutex.Unlock()
+			}
+			mutex.Lock()
+			newPackages = append(newPackages, view.WorkspaceCopyPackage{
+				PackageId:             newPackageId,
+				SrcPackageId:          srcPackage.Id,
+				ParentId:              getParentPackageId(newPackageId),
+				Kind:                  srcPackage.Kind,
+				Name:                  srcPackage.Name,
+				Alias:                 srcPackage.Alias,
+				Description:           srcPackage.Description,
+				ServiceName:           srcPackage.ServiceName,
+				ImageUrl:              srcPackage.ImageUrl,
+				ReleaseVersionPattern: srcPackage.ReleaseVersionPattern,
+				Status:                view.WorkspaceCopyPackageStatusPlanned,
+			})
+			mutex.Unlock()
+		})
+	}
+	wg.Wait()
+
+	if errList := collectErrors(&errMap); len(errList) > 0 {
+		return nil, fmt.Errorf("failed to calculate service packages to copy: %v", strings.Join(errList, ", "))
+	}
+
+	// parent groups must be created before their children
+	sort.Slice(newGroups, func(i, j int) bool {
+		iDepth, jDepth := strings.Count(newGroups[i].PackageId, "."), strings.Count(newGroups[j].PackageId, ".")
+		if iDepth != jDepth {
+			return iDepth < jDepth
+		}
+		return newGroups[i].PackageId < newGroups[j].PackageId
+	})
+	sort.Slice(newPackages, func(i, j int) bool {
+		return newPackages[i].PackageId < newPackages[j].PackageId
+	})
+	result.Packages = append(newGroups, newPackages...)
+	return &result, nil
+}
+
+func getParentPackageId(packageId string) string {
+	parts := strings.Split(packageId, ".")
+	return strings.Join(parts[:len(parts)-1], ".")
+}
+
+func getOrderedParentPackageIds(packageId string) []string {
+	parts := strings.Split(packageId, ".")
+	parentIds := make([]string, 0)
+	for i, part := range parts {
+		if i == 0 {
+			parentIds = append(parentIds, part)
+			continue
+		}
+		if i == (len(parts) - 1) {
+			break
+		}
+		parentIds = append(parentIds, parentIds[i-1]+"."+part)
+	}
+	return parentIds
+}
+
+func collectErrors(errMap *sync.Map) []string {
+	errList := make([]string, 0)
+	errMap.Range(func(key, value interface{}) bool {
+		errList = append(errList, key.(string))
+		return true
+	})
+	return errList
+}
+
+// applyWorkspaceCopy creates groups sequentially in the planned order and then service packages concurrently.
+// Packages which are already created by the previous attempt are skipped
+func (w workspaceCopyServiceImpl) applyWorkspaceCopy(ctx context.Context, copyEnt *entity.WorkspaceCopyEntity, packageEnts []entity.WorkspaceCopyPackageEntity) {
+	unavailableGroupIds := make(map[string]struct{})
+	for i := range packageEnts {
+		packageEnt := &packageEnts[i]
+		if packageEnt.Kind != string(view.KindGroup) {
+			continue
+		}
+		if isWorkspaceCopyPackageApplied(*packageEnt) {
+			continue
+		}
+		if _, unavailable := unavailableGroupIds[packageEnt.ParentId]; unavailable {
+			w.setPackageStatus(packageEnt, view.WorkspaceCopyPackageStatusFailed, fmt.Sprintf("parent group '%s' was not created", packageEnt.ParentId))
+		} else {
+			w.applyPackage(ctx, copyEnt.DefaultRole, packageEnt)
+		}
+		if packageEnt.Status == string(view.WorkspaceCopyPackageStatusFailed) {
+			unavailableGroupIds[packageEnt.PackageId] = struct{}{}
+		}
+	}
+
+	errGrp := errgroup.Group{}
+	errGrp.SetLimit(workspaceCopyConcurrency)
+	for i := range packageEnts {
+		packageEnt := &packageEnts[i]
+		if packageEnt.Kind == string(view.KindGroup) || isWorkspaceCopyPackageApplied(*packageEnt) {
+			continue
+		}
+		if _, unavailable := unavailableGroupIds[packageEnt.ParentId]; unavailable {
+			w.setPackageStatus(packageEnt, view.WorkspaceCopyPackageStatusFailed, fmt.Sprintf("parent group '%s' was not created", packageEnt.ParentId))
+			continue
+		}
+		errGrp.Go(func() error {
+			w.applyPackage(ctx, copyEnt.DefaultRole, packageEnt)
+			return nil
+		})
+	}
+	_ = errGrp.Wait()
+
+	failed := 0
+	for _, packageEnt := range packageEnts {
+		if packageEnt.Status == string(view.WorkspaceCopyPackageStatusFailed) {
+			failed++
+		}
+	}
+	finishedAt := time.Now()
+	copyEnt.FinishedAt = &finishedAt
+	if failed == 0 {
+		copyEnt.Status = string(view.WorkspaceCopyStatusComplete)
+		copyEnt.Details = ""
+	} else {
+		copyEnt.Status = string(view.WorkspaceCopyStatusFailed)
+		copyEnt.Details = fmt.Sprintf("%d of %d packages failed", failed, len(packageEnts))
+	}
+	err := w.workspaceCopyRepository.UpdateWorkspaceCopyStatus(copyEnt)
+	if err != nil {
+		log.Errorf("Failed to update workspace structure copy %s status: %s", copyEnt.CopyId, err.Error())
+	}
+}
+
+func isWorkspaceCopyPackageApplied(packageEnt entity.WorkspaceCopyPackageEntity) bool {
+	return packageEnt.Status == string(view.WorkspaceCopyPackageStatusCreated) ||
+		packageEnt.Status == string(view.WorkspaceCopyPackageStatusExisting)
+}
+
+// applyPackage creates the package if it doesn't exist yet, so applying the same copy again is safe
+func (w workspaceCopyServiceImpl) applyPackage(ctx context.Context, defaultRole string, packageEnt *entity.WorkspaceCopyPackageEntity) {
+	existingPackage, err := w.apihubClient.GetPackageById(ctx, packageEnt.PackageId)
+	if err != nil {
+		w.setPackageStatus(packageEnt, view.WorkspaceCopyPackageStatusFailed, fmt.Sprintf("failed to get apihub package by id: %v", err.Error()))
+		return
+	}
+	if existingPackage != nil {
+		if existingPackage.Kind != packageEnt.Kind || existingPackage.ServiceName != packageEnt.ServiceName {
+			w.setPackageStatus(packageEnt, view.WorkspaceCopyPackageStatusFailed, fmt.Sprintf("package already exists with kind '%s' and serviceName '%s'", existingPackage.Kind, existingPackage.ServiceName))
+			return
+		}
+		w.setPackageStatus(packageEnt, view.WorkspaceCopyPackageStatusExisting, "")
+		return
+	}
+	_, err = w.apihubClient.CreatePackage(ctx, entity.MakeWorkspaceCopyPackageView(*packageEnt).MakeCreateRequest(defaultRole))
+	if err != nil {
+		w.setPackageStatus(packageEnt, view.WorkspaceCopyPackageStatusFailed, fmt.Sprintf("failed to create package: %v", err.Error()))
+		return
+	}
+	w.setPackageStatus(packageEnt, view.WorkspaceCopyPackageStatusCreated, "")
+}
+
+func (w workspaceCopyServiceImpl) rollbackPackage(ctx context.Context, packageEnt *entity.WorkspaceCopyPackageEntity) {
+	if packageEnt.Kind == string(view.KindGroup) {
+		children, err := w.apihubClient.GetPackages(ctx, view.PackagesSearchReq{ParentId: packageEnt.PackageId, Limit: 1})
+		if err != nil {
+			w.setPackageStatus(packageEnt, view.WorkspaceCopyPackageStatusCreated, fmt.Sprintf("failed to get child packages: %v", err.Error()))
+			return
+		}
+		if children != nil && len(children.Packages) > 0 {
+			w.setPackageStatus(packageEnt, view.WorkspaceCopyPackageStatusCreated, "group is kept since it contains packages which were not created by the copy")
+			return
+		}
+	} else {
+		versions, err := w.apihubClient.GetVersions(ctx, packageEnt.PackageId, view.VersionSearchRequest{Limit: 1})
+		if err != nil {
+			w.setPackageStatus(packageEnt, view.WorkspaceCopyPackageStatusCreated, fmt.Sprintf("failed to get package versions: %v", err.Error()))
+			return
+		}
+		if versions != nil && len(versions.Versions) > 0 {
+			w.setPackageStatus(packageEnt, view.WorkspaceCopyPackageStatusCreated, "package is kept since it has published versions")
+			return
+		}
+	}
+	err := w.apihubClient.DeletePackage(ctx, packageEnt.PackageId)
+	if err != nil {
+		w.setPackageStatus(packageEnt, view.WorkspaceCopyPackageStatusCreated, fmt.Sprintf("failed to delete package: %v", err.Error()))
+		return
+	}
+	w.setPackageStatus(packageEnt, view.WorkspaceCopyPackageStatusRolledBack, "")
+}
+
+// setPackageStatus stores the package status right away, so the progress is not lost if the copy is interrupted
+func (w workspaceCopyServiceImpl) setPackageStatus(packageEnt *entity.WorkspaceCopyPackageEntity, status view.WorkspaceCopyPackageStatus, details string) {
+	packageEnt.Status = string(status)
+	packageEnt.Details = details
+	err := w.workspaceCopyRepository.UpdateWorkspaceCopyPackageStatus(packageEnt)
+	if err != nil {
+		log.Errorf("Failed to update workspace structure copy %s package %s status: %s", packageEnt.CopyId, packageEnt.PackageId, err.Error())
+	}
+}
+
+func (w workspaceCopyServiceImpl) GetWorkspaceDrift(ctx context.Context, dstWorkspaceId string, srcWorkspaceId string) (*view.WorkspaceDriftReport, error) {
+	return w.SyncWorkspaceStructure(ctx, dstWorkspaceId, view.WorkspaceSyncReq{SrcWorkspaceId: srcWorkspaceId, DryRun: true})
+}
+
+func (w workspaceCopyServiceImpl) SyncWorkspaceStructure(ctx context.Context, dstWorkspaceId string, req view.WorkspaceSyncReq) (*view.WorkspaceDriftReport, error) {
+	_, srcWorkspaceId, err := w.validateWorkspaces(ctx, dstWorkspaceId, req.SrcWorkspaceId)
+	if err != nil {
+		return nil, err
+	}
+	if !req.DryRun {
+		lockCtx, unlock, err := w.lockWorkspace(ctx, dstWorkspaceId)
+		if err != nil {
+			return nil, err
+		}
+		defer unlock()
+		ctx = lockCtx
+	}
+	dstPackages, err := w.getCopiedPackages(ctx, srcWorkspaceId, dstWorkspaceId, req.ServiceNames)
+	if err != nil {
+		return nil, err
+	}
+	drifts := make([]*view.PackageDrift, len(dstPackages))
+	errGrp := errgroup.Group{}
+	errGrp.SetLimit(workspaceCopyConcurrency)
+	for i, dstPackage := range dstPackages {
+		errGrp.Go(func() error {
+			drift, err := w.getPackageDrift(ctx, srcWorkspaceId, dstWorkspaceId, dstPackage)
+			if err != nil {
+				return err
+			}
+			if drift != nil && !req.DryRun {
+				switch drift.Type {
+				case view.PackageDriftMetadata:
+					err = w.apihubClient.UpdatePackage(ctx, drift.PackageId, makePackageUpdateRequest(drift.Fields))
+					if err != nil {
+						drift.Error = fmt.Sprintf("failed to update package: %v", err.Error())
+					} else {
+						drift.Synced = true
+					}
+				case view.PackageDriftMoved:
+					drift.Error = "group path changes are not synced, the package has to be moved manually"
+				}
+			}
+			drifts[i] = drift
+			return nil
+		})
+	}
+	if err = errGrp.Wait(); err != nil {
+		return nil, fmt.Errorf("failed to calculate packages drift between '%v' and '%v' workspaces: %v", srcWorkspaceId, dstWorkspaceId, err.Error())
+	}
+
+	result := view.WorkspaceDriftReport{
+		SrcWorkspaceId:  srcWorkspaceId,
+		DstWorkspaceId:  dstWorkspaceId,
+		DryRun:          req.DryRun,
+		CheckedPackages: len(dstPackages),
+		Packages:        make([]view.PackageDrift, 0),
+	}
+	for _, drift := range drifts {
+		if drift != nil {
+			result.Packages = append(result.Packages, *drift)
+		}
+	}
+	sort.Slice(result.Packages, func(i, j int) bool {
+		return result.Packages[i].PackageId < result.Packages[j].PackageId
+	})
+	return &result, nil
+}
+
+// getCopiedPackages returns packages under the product group of the source workspace in the destination workspace.
+// If service names are set, only packages of these services and their parent groups are returned
+func (w workspaceCopyServiceImpl) getCopiedPackages(ctx context.Context, srcWorkspaceId string, dstWorkspaceId string, serviceNames []string) ([]view.PackagesInfo, error) {
+	productPackageId := fmt.Sprintf("%s.%s", dstWorkspaceId, srcWorkspaceId)
+	result := make([]view.PackagesInfo, 0)
+	if len(serviceNames) == 0 {
+		for page := 0; ; page++ {
+			packages, err := w.apihubClient.GetPackages(ctx, view.PackagesSearchReq{
+				ParentId:           productPackageId,
+				ShowAllDescendants: true,
+				Limit:              workspaceCopyPageSize,
+				Page:               page,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to get packages copied from '%v' workspace: %v", srcWorkspaceId, err.Error())
+			}
+			if packages == nil {
+				break
+			}
+			result = append(result, packages.Packages...)
+			if len(packages.Packages) < workspaceCopyPageSize {
+				break
+			}
+		}
+		return result, nil
+	}
+
+	mutex := sync.Mutex{}
+	seenPackages := make(map[string]struct{})
+	markSeen := func(packageId string) bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if _, exists := seenPackages[packageId]; exists {
+			return false
+		}
+		seenPackages[packageId] = struct{}{}
+		return true
+	}
+	errGrp := errgroup.Group{}
+	errGrp.SetLimit(workspaceCopyConcurrency)
+	for _, serviceName := range serviceNames {
+		errGrp.Go(func() error {
+			dstPackage, err := w.apihubClient.GetPackageByServiceName(ctx, dstWorkspaceId, serviceName)
+			if err != nil {
+				return fmt.Errorf("failed to get apihub package by service name: %v", err.Error())
+			}
+			if dstPackage == nil || !strings.HasPrefix(dstPackage.Id, productPackageId+".") {
+				// package was not copied from the source workspace
+				return nil
+			}
+			for _, parentId := range getOrderedParentPackageIds(dstPackage.Id) {
+				if !strings.HasPrefix(parentId, productPackageId+".") || !markSeen(parentId) {
+					continue
+				}
+				parent, err := w.apihubClient.GetPackageById(ctx, parentId)
+				if err != nil {
+					return fmt.Errorf("failed to get apihub package by id: %v", err.Error())
+				}
+				if parent == nil {
+					continue
+				}
+				mutex.Lock()
+				result = append(result, makePackagesInfo(*parent))
+				mutex.Unlock()
+			}
+			if markSeen(dstPackage.Id) {
+				mutex.Lock()
+				result = append(result, *dstPackage)
+				mutex.Unlock()
+			}
+			return nil
+		})
+	}
+	if err := errGrp.Wait(); err != nil {
+		return nil, fmt.Errorf("failed to get packages copied from '%v' workspace: %v", srcWorkspaceId, err.Error())
+	}
+	return result, nil
+}
+
+// getPackageDrift returns nil if the copied package matches its source package.
+// Service packages are matched by service name, groups are matched by package id
+func (w workspaceCopyServiceImpl) getPackageDrift(ctx context.Context, srcWorkspaceId string, dstWorkspaceId string, dstPackage view.PackagesInfo) (*view.PackageDrift, error) {
+	srcPackageId := strings.TrimPrefix(dstPackage.Id, dstWorkspaceId+".")
+	result := view.PackageDrift{
+		PackageId:    dstPackage.Id,
+		SrcPackageId: srcPackageId,
+		Kind:         dstPackage.Kind,
+		ServiceName:  dstPackage.ServiceName,
+	}
+	var srcPackage *view.PackagesInfo
+	if dstPackage.ServiceName != "" {
+		pkg, err := w.apihubClient.GetPackageByServiceName(ctx, srcWorkspaceId, dstPackage.ServiceName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get apihub package by service name: %v", err.Error())
+		}
+		srcPackage = pkg
+	} else {
+		pkg, err := w.apihubClient.GetPackageById(ctx, srcPackageId)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get apihub package by id: %v", err.Error())
+		}
+		if pkg != nil {
+			packageInfo := makePackagesInfo(*pkg)
+			srcPackage = &packageInfo
+		}
+	}
+	if srcPackage == nil {
+		result.Type = view.PackageDriftMissing
+		return &result, nil
+	}
+	result.Fields = makePackageFieldDiffs(*srcPackage, dstPackage)
+	if srcPackage.Id != srcPackageId {
+		result.Type = view.PackageDriftMoved
+		result.SrcPackageId = srcPackage.Id
+		result.Fields = append(result.Fields, view.PackageFieldDiff{
+			Field:    "parentId",
+			SrcValue: fmt.Sprintf("%s.%s", dstWorkspaceId, srcPackage.ParentId),
+			DstValue: dstPackage.ParentId,
+		})
+		return &result, nil
+	}
+	if len(result.Fields) == 0 {
+		return nil, nil
+	}
+	result.Type = view.PackageDriftMetadata
+	return &result, nil
+}
+
+func makePackageFieldDiffs(srcPackage view.PackagesInfo, dstPackage view.PackagesInfo) []view.PackageFieldDiff {
+	result := make([]view.PackageFieldDiff, 0)
+	fields := []struct {
+		name     string
+		srcValue string
+		dstValue string
+	}{
+		{"name", srcPackage.Name, dstPackage.Name},
+		{"description", srcPackage.Description, dstPackage.Description},
+		{"imageUrl", srcPackage.ImageUrl, dstPackage.ImageUrl},
+		{"releaseVersionPattern", srcPackage.ReleaseVersionPattern, dstPackage.ReleaseVersionPattern},
+	}
+	for _, field := range fields {
+		if field.srcValue != field.dstValue {
+			result = append(result, view.PackageFieldDiff{Field: field.name, SrcValue: field.srcValue, DstValue: field.dstValue})
+		}
+	}
+	return result
+}
+
+func makePackageUpdateRequest(fields []view.PackageFieldDiff) view.PackageUpdateRequest {
+	result := view.PackageUpdateRequest{}
+	for _, field := range fields {
+		value := field.SrcValue
+		switch field.Field {
+		case "name":
+			result.Name = &value
+		case "description":
+			result.Description = &value
+		case "imageUrl":
+			result.ImageUrl = &value
+		case "releaseVersionPattern":
+			result.ReleaseVersionPattern = &value
+		}
+	}
+	return result
+}
+
+func makePackagesInfo(pkg view.SimplePackage) view.PackagesInfo {
+	return view.PackagesInfo{
+		Id:                    pkg.Id,
+		Alias:                 pkg.Alias,
+		ParentId:              pkg.ParentId,
+		Kind:                  pkg.Kind,
+		Name:                  pkg.Name,
+		Description:           pkg.Description,
+		ServiceName:           pkg.ServiceName,
+		ImageUrl:              pkg.ImageUrl,
+		DefaultRole:           pkg.DefaultRole,
+		ReleaseVersionPattern: pkg.ReleaseVersionPattern,
+	}
+}
@@ -12,6 +12,7 @@ type AgentKeepaliveMessage struct {
 	BackendVersion string `json:"backendVersion" validate:"required"`
 	Name           string `json:"name"`
 	AgentVersion   string `json:"agentVersion"`
+	InstanceId     string `json:"instanceId"`
 }
 
 type AgentStatus string
@@ -30,6 +31,30 @@ type AgentInstance struct {
 	Name                     string                   `json:"name"`
 	AgentVersion             string                   `json:"agentVersion"`
 	CompatibilityError       *AgentCompatibilityError `json:"compatibilityError,omitempty"`
+	Replicas                 []AgentReplica           `json:"replicas,omitempty"`
+}
+
+type AgentReplica struct {
+	InstanceId     string      `json:"instanceId"`
+	Url            string      `json:"url"`
+	LastActive     time.Time   `json:"lastActive"`
+	Status         AgentStatus `json:"status"`
+	BackendVersion string      `json:"backendVersion"`
+	AgentVersion   string      `json:"agentVersion"`
+}
+
+// ActiveReplicaUrls returns urls of the active agent replicas, falls back to the agent url if there are no active replicas
+func (a AgentInstance) ActiveReplicaUrls() []string {
+	urls := make([]string, 0, len(a.Replicas))
+	for _, replica := range a.Replicas {
+		if replica.Status == AgentStatusActive {
+			urls = append(urls, replica.Url)
+		}
+	}
+	if len(urls) == 0 && a.AgentUrl != "" {
+		urls = append(urls, a.AgentUrl)
+	}
+	return urls
 }
 
 func MakeAgentId(cloud, namespace string) string {
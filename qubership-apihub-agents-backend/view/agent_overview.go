@@ -0,0 +1,38 @@
+package view
+
+import "time"
+
+type AgentsOverview struct {
+	Agents      []AgentOverview `json:"agents"`
+	WorkspaceId string          `json:"workspaceId"`
+	CreatedAt   time.Time       `json:"createdAt"`
+}
+
+type AgentOverview struct {
+	AgentInstance
+	NamespacesCount int                 `json:"namespacesCount"`
+	NamespacesError string              `json:"namespacesError,omitempty"`
+	Namespaces      []NamespaceOverview `json:"namespaces"`
+}
+
+type NamespaceOverview struct {
+	Namespace         string                          `json:"namespace"`
+	LastDiscovery     *NamespaceDiscoveryOverview     `json:"lastDiscovery,omitempty"`
+	LastSnapshot      *SnapshotListItem               `json:"lastSnapshot,omitempty"`
+	LastSecurityCheck *NamespaceSecurityCheckOverview `json:"lastSecurityCheck,omitempty"`
+}
+
+type NamespaceDiscoveryOverview struct {
+	Status     string     `json:"status"`
+	Details    string     `json:"details,omitempty"`
+	StartedAt  time.Time  `json:"startedAt"`
+	StartedBy  string     `json:"startedBy,omitempty"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
+
+type NamespaceSecurityCheckOverview struct {
+	ProcessId string `json:"processId"`
+	NamespaceSecurityCheckStatus
+	StartedAt  time.Time  `json:"startedAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
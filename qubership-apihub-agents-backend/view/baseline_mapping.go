@@ -0,0 +1,68 @@
+package view
+
+import "time"
+
+type BaselineMappingRuleType string
+
+// BaselineMappingRuleExplicit maps the service with the exact name to the package
+const BaselineMappingRuleExplicit BaselineMappingRuleType = "explicit"
+
+// BaselineMappingRuleRegex rewrites the service name by the pattern and looks for the package by the resulting service name
+const BaselineMappingRuleRegex BaselineMappingRuleType = "regex"
+
+// BaselineMappingRuleLabel matches the service by the label. The package is looked up by the label value
+// used as the service name if the package id is not set
+const BaselineMappingRuleLabel BaselineMappingRuleType = "label"
+
+type BaselineMappingRule struct {
+	Type BaselineMappingRuleType `json:"type" validate:"required"`
+	// ServiceName is the exact service name for explicit rules
+	ServiceName string `json:"serviceName,omitempty"`
+	// Pattern and Replacement are the regular expression and its replacement template (e.g. $1) for regex rules
+	Pattern     string `json:"pattern,omitempty"`
+	Replacement string `json:"replacement,omitempty"`
+	// LabelName and LabelValue are matched against service labels for label rules, any value matches if LabelValue is empty
+	LabelName  string `json:"labelName,omitempty"`
+	LabelValue string `json:"labelValue,omitempty"`
+	// PackageId is the baseline package, required for explicit rules
+	PackageId string `json:"packageId,omitempty"`
+}
+
+type BaselineMappingRules struct {
+	WorkspaceId string                `json:"workspaceId"`
+	Rules       []BaselineMappingRule `json:"rules"`
+	UpdatedAt   *time.Time            `json:"updatedAt,omitempty"`
+	UpdatedBy   string                `json:"updatedBy,omitempty"`
+}
+
+type UpdateBaselineMappingRulesReq struct {
+	// Rules are applied in the given order, the first rule which points to an existing package wins
+	Rules []BaselineMappingRule `json:"rules" validate:"dive"`
+}
+
+type BaselineMatchType string
+
+const BaselineMatchServiceName BaselineMatchType = "serviceName"
+const BaselineMatchExplicit BaselineMatchType = "explicit"
+const BaselineMatchRegex BaselineMatchType = "regex"
+const BaselineMatchLabel BaselineMatchType = "label"
+
+// BaselineMatch explains why the package was selected as the service baseline
+type BaselineMatch struct {
+	Type BaselineMatchType `json:"type"`
+	// RuleIndex is the index of the matched mapping rule, empty if the baseline is matched by the service name
+	RuleIndex   *int   `json:"ruleIndex,omitempty"`
+	ServiceName string `json:"serviceName,omitempty"`
+	Details     string `json:"details"`
+}
+
+type TestBaselineMappingReq struct {
+	ServiceName string            `json:"serviceName" validate:"required"`
+	Labels      map[string]string `json:"serviceLabels"`
+}
+
+type BaselineMappingResult struct {
+	Found     bool           `json:"found"`
+	PackageId string         `json:"packageId,omitempty"`
+	Match     *BaselineMatch `json:"match,omitempty"`
+}
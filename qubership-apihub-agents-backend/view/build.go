@@ -2,6 +2,9 @@ package view
 
 const BuildType string = "build"
 
+// SpecChecksumMetadataKey is the build config metadata key of the service documents checksum
+const SpecChecksumMetadataKey = "specChecksum"
+
 type BuildConfig struct {
 	PackageId                string                 `json:"packageId"`
 	Version                  string                 `json:"version"`
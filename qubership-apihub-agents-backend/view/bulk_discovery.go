@@ -0,0 +1,46 @@
+package view
+
+import "time"
+
+type StartBulkDiscoveryReq struct {
+	WorkspaceId string                `json:"workspaceId" validate:"required"`
+	Targets     []BulkDiscoveryTarget `json:"targets" validate:"dive"`
+	// Cloud limits agents which namespaces are matched by NamespacePattern, all clouds are used if empty
+	Cloud string `json:"cloud"`
+	// NamespacePattern is a shell pattern (e.g. prod-*) matched against namespaces of all active agents
+	NamespacePattern string `json:"namespacePattern"`
+	FailOnError      bool   `json:"failOnError"`
+}
+
+type BulkDiscoveryTarget struct {
+	AgentId   string `json:"agentId" validate:"required"`
+	Namespace string `json:"namespace" validate:"required"`
+}
+
+type BulkDiscoveryJobId struct {
+	JobId string `json:"jobId"`
+}
+
+type BulkDiscoveryJob struct {
+	JobId           string                      `json:"jobId"`
+	WorkspaceId     string                      `json:"workspaceId"`
+	Status          Status                      `json:"status"`
+	Details         string                      `json:"details,omitempty"`
+	CreatedAt       time.Time                   `json:"createdAt"`
+	CreatedBy       string                      `json:"createdBy"`
+	FinishedAt      *time.Time                  `json:"finishedAt,omitempty"`
+	TargetsTotal    int                         `json:"targetsTotal"`
+	TargetsComplete int                         `json:"targetsComplete"`
+	TargetsFailed   int                         `json:"targetsFailed"`
+	Targets         []BulkDiscoveryTargetStatus `json:"targets"`
+}
+
+type BulkDiscoveryTargetStatus struct {
+	AgentId       string     `json:"agentId"`
+	Namespace     string     `json:"namespace"`
+	Status        Status     `json:"status"`
+	Details       string     `json:"details,omitempty"`
+	ServicesCount int        `json:"servicesCount"`
+	StartedAt     *time.Time `json:"startedAt,omitempty"`
+	FinishedAt    *time.Time `json:"finishedAt,omitempty"`
+}
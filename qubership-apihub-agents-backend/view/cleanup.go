@@ -0,0 +1,51 @@
+package view
+
+import "time"
+
+const CleanupJobSnapshots = "snapshots"
+const CleanupJobAuthSecurityChecks = "authSecurityChecks"
+
+const CleanupTriggerSchedule = "schedule"
+const CleanupTriggerManual = "manual"
+
+// StatusSkipped is the status of a cleanup run which did nothing because of APIHUB migration in progress
+const StatusSkipped Status = "skipped"
+
+type CleanupRun struct {
+	RunId             string     `json:"runId"`
+	JobType           string     `json:"jobType"`
+	Trigger           string     `json:"trigger"`
+	Status            Status     `json:"status"`
+	Details           string     `json:"details,omitempty"`
+	StartedAt         time.Time  `json:"startedAt"`
+	StartedBy         string     `json:"startedBy,omitempty"`
+	FinishedAt        *time.Time `json:"finishedAt,omitempty"`
+	WorkspacesScanned int        `json:"workspacesScanned"`
+	GroupsProcessed   int        `json:"groupsProcessed"`
+	DeletedSnapshots  int        `json:"deletedSnapshots"`
+	// DeletedSecurityChecks is the number of auth security check processes deleted by authSecurityChecks job
+	DeletedSecurityChecks int               `json:"deletedSecurityChecks,omitempty"`
+	Groups                []CleanupRunGroup `json:"groups,omitempty"`
+	Errors                []string          `json:"errors,omitempty"`
+}
+
+// CleanupRunGroup is the namespace group processed by the run, the snapshots group if it is cleaned up by APIHUB recursive deletion as a whole
+type CleanupRunGroup struct {
+	WorkspaceId string `json:"workspaceId"`
+	GroupId     string `json:"groupId"`
+	// Status is running if the group is cleaned up by APIHUB recursive deletion job, complete if all deletions
+	// of the group are finished, error otherwise
+	Status Status `json:"status"`
+	// JobId is the id of APIHUB recursive deletion job, is set if the group is cleaned up by SNAPSHOTS_TTL_DAYS
+	JobId            string `json:"jobId,omitempty"`
+	DeletedSnapshots int    `json:"deletedSnapshots,omitempty"`
+	Error            string `json:"error,omitempty"`
+}
+
+type CleanupRuns struct {
+	Runs []CleanupRun `json:"runs"`
+}
+
+type CleanupRunId struct {
+	RunId string `json:"runId"`
+}
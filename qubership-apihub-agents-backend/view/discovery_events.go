@@ -0,0 +1,33 @@
+package view
+
+type DiscoveryEventType string
+
+const DiscoveryEventStatus DiscoveryEventType = "status"
+const DiscoveryEventService DiscoveryEventType = "service"
+
+type DiscoveryEvent struct {
+	Type    DiscoveryEventType
+	Status  *DiscoveryStatusEvent
+	Service *DiscoveryServiceEvent
+}
+
+// Data returns the payload of the event which is sent to the client
+func (e DiscoveryEvent) Data() interface{} {
+	if e.Type == DiscoveryEventService {
+		return e.Service
+	}
+	return e.Status
+}
+
+type DiscoveryStatusEvent struct {
+	Status        Status `json:"status"`
+	Debug         string `json:"debug,omitempty"`
+	ServicesCount int    `json:"servicesCount"`
+}
+
+type DiscoveryServiceEvent struct {
+	Id             string `json:"id"`
+	Name           string `json:"serviceName"`
+	DocumentsCount int    `json:"documentsCount"`
+	Error          string `json:"error,omitempty"`
+}
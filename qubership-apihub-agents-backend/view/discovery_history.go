@@ -0,0 +1,106 @@
+package view
+
+import "time"
+
+type DiscoveryHistoryItem struct {
+	DiscoveryId   string     `json:"discoveryId"`
+	AgentId       string     `json:"agentId"`
+	CloudName     string     `json:"cloudName"`
+	Namespace     string     `json:"namespace"`
+	WorkspaceId   string     `json:"workspaceId"`
+	Status        string     `json:"status"`
+	Details       string     `json:"details,omitempty"`
+	StartedAt     *time.Time `json:"startedAt,omitempty"`
+	StartedBy     string     `json:"startedBy,omitempty"`
+	FinishedAt    time.Time  `json:"finishedAt"`
+	ServicesCount int        `json:"servicesCount"`
+}
+
+type DiscoveryHistoryItems struct {
+	Discoveries []DiscoveryHistoryItem `json:"discoveries"`
+}
+
+type DiscoveryHistory struct {
+	DiscoveryHistoryItem
+	Services []DiscoveredService `json:"services"`
+}
+
+type DiscoveredService struct {
+	Id             string               `json:"id"`
+	Name           string               `json:"serviceName"`
+	Url            string               `json:"url"`
+	Documents      []DiscoveredDocument `json:"documents"`
+	Baseline       *Baseline            `json:"baseline,omitempty"`
+	Labels         map[string]string    `json:"serviceLabels,omitempty"`
+	Error          string               `json:"error,omitempty"`
+	DiagnosticInfo *ServiceDiagnostic   `json:"diagnosticInfo,omitempty"`
+}
+
+type DiscoveredDocument struct {
+	Document
+	// Checksum of the document content, empty if the content was not available at the time of discovery
+	Checksum string `json:"checksum,omitempty"`
+}
+
+type DiscoveriesDiff struct {
+	From            DiscoveryHistoryItem `json:"from"`
+	To              DiscoveryHistoryItem `json:"to"`
+	AddedServices   []DiscoveredService  `json:"addedServices"`
+	RemovedServices []DiscoveredService  `json:"removedServices"`
+	ChangedServices []ServiceDiff        `json:"changedServices"`
+}
+
+type ServiceDiff struct {
+	ServiceId        string               `json:"serviceId"`
+	ServiceName      string               `json:"serviceName"`
+	AddedDocuments   []DiscoveredDocument `json:"addedDocuments,omitempty"`
+	RemovedDocuments []DiscoveredDocument `json:"removedDocuments,omitempty"`
+	ChangedDocuments []DocumentDiff       `json:"changedDocuments,omitempty"`
+	LabelChanges     []LabelDiff          `json:"labelChanges,omitempty"`
+	PreviousError    string               `json:"previousError,omitempty"`
+	CurrentError     string               `json:"currentError,omitempty"`
+}
+
+type DocumentDiff struct {
+	FileId           string `json:"fileId"`
+	Name             string `json:"name"`
+	PreviousChecksum string `json:"previousChecksum"`
+	CurrentChecksum  string `json:"currentChecksum"`
+}
+
+type DiffAction string
+
+const DiffActionAdded DiffAction = "added"
+const DiffActionRemoved DiffAction = "removed"
+const DiffActionChanged DiffAction = "changed"
+
+type LabelDiff struct {
+	Name          string     `json:"name"`
+	Action        DiffAction `json:"action"`
+	PreviousValue string     `json:"previousValue,omitempty"`
+	CurrentValue  string     `json:"currentValue,omitempty"`
+}
+
+func MakeDiscoveredServices(services []Service, checksums map[string]map[string]string) []DiscoveredService {
+	result := make([]DiscoveredService, 0, len(services))
+	for _, service := range services {
+		documents := make([]DiscoveredDocument, 0, len(service.Documents))
+		for _, document := range service.Documents {
+			documents = append(documents, DiscoveredDocument{
+				Document: document,
+				Checksum: checksums[service.Id][document.FileId],
+			})
+		}
+		result = append(result, DiscoveredService{
+			Id:             service.Id,
+			Name:           service.Name,
+			Url:            service.Url,
+			Documents:      documents,
+			Baseline:       service.Baseline,
+			Labels:         service.Labels,
+			Error:          service.Error,
+			DiagnosticInfo: service.DiagnosticInfo,
+		})
+	}
+	return result
+}
@@ -24,7 +24,7 @@ type StartNamespaceSecurityCheckReq struct {
 type EndpointsProcessTask struct {
 	ProcessId string
 	Namespace string
-	AgentUrl  string
+	Agent     AgentInstance
 	ServiceId string
 	PackageId string
 	Version   string
@@ -9,6 +9,14 @@ const KindWorkspace PackageKind = "workspace"
 const KindGroup PackageKind = "group"
 const KindDashbord PackageKind = "dashboard"
 
+const ReadPermission = "read"
+const CreateAndUpdatePackagePermission = "create_and_update_package"
+
+// MakeManageVersionPermission returns the permission required to change or delete versions with the status
+func MakeManageVersionPermission(status string) string {
+	return "manage_" + status + "_version"
+}
+
 type SimplePackage struct {
 	Id                    string              `json:"packageId"`
 	Alias                 string              `json:"alias" validate:"required"`
@@ -85,6 +93,14 @@ type PackageCreateRequest struct {
 	ExcludeFromSearch     *bool  `json:"excludeFromSearch"`
 }
 
+// PackageUpdateRequest contains only the package fields to change, nil fields are not updated
+type PackageUpdateRequest struct {
+	Name                  *string `json:"name,omitempty"`
+	Description           *string `json:"description,omitempty"`
+	ImageUrl              *string `json:"imageUrl,omitempty"`
+	ReleaseVersionPattern *string `json:"releaseVersionPattern,omitempty"`
+}
+
 type AvailablePackagePromoteStatuses map[string][]string // map[packageId][]version status
 
 type PackagesReq struct {
@@ -63,8 +63,9 @@ type ServiceNamesResponse struct {
 }
 
 type Baseline struct {
-	PackageId string   `json:"packageId"`
-	Name      string   `json:"name"`
-	Url       string   `json:"url"`
-	Versions  []string `json:"versions"`
+	PackageId string         `json:"packageId"`
+	Name      string         `json:"name"`
+	Url       string         `json:"url"`
+	Versions  []string       `json:"versions"`
+	Match     *BaselineMatch `json:"match,omitempty"`
 }
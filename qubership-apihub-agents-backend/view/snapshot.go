@@ -14,33 +14,115 @@ type CreateSnapshotRequest struct {
 	Services        []string `json:"services"`
 	Status          string   `json:"status"`
 	BuilderId       string   `json:"builderId"`
+	// ValidateSpecs enables specifications validation before publishing, results are returned in the response
+	ValidateSpecs bool `json:"validateSpecs"`
+	// FailOnSpecErrors rejects the snapshot if at least one specification has validation errors, implies ValidateSpecs
+	FailOnSpecErrors bool `json:"failOnSpecErrors"`
+	// RepublishUnchanged disables deduplication: services with the same specifications as in the last snapshot are published again
+	RepublishUnchanged bool `json:"republishUnchanged"`
 }
 
 type CreateSnapshotDTO struct {
-	PreviousVersion string
-	Services        []string
-	ClientBuild     bool
-	BuilderId       string
-	Promote         bool
-	VersionStatus   string
-	AgentUrl        string
-	CloudName       string
+	PreviousVersion  string
+	Services         []string
+	ClientBuild      bool
+	BuilderId        string
+	Promote          bool
+	VersionStatus    string
+	Agent            AgentInstance
+	CloudName        string
+	ValidateSpecs    bool
+	FailOnSpecErrors bool
+	// SkipUnchanged enables referencing of the last published version for services with unchanged specifications
+	SkipUnchanged bool
 }
 
 type CreateSnapshotResponse struct {
 	Snapshot *GroupBuildConfig `json:"snapshot,omitempty"`
 	Services []BuildConfig     `json:"services"`
+	// SpecValidation is set if specifications validation was requested
+	SpecValidation []ServiceSpecValidation `json:"specValidation,omitempty"`
+	// UnchangedServices are not published, the snapshot references their last published versions
+	UnchangedServices []SnapshotUnchangedService `json:"unchangedServices,omitempty"`
+}
+
+// PromoteSnapshotReq publishes service versions of an existing snapshot to the baseline packages
+type PromoteSnapshotReq struct {
+	// Version of the baseline packages, the snapshot version is used if empty
+	Version         string `json:"version"`
+	PreviousVersion string `json:"previousVersion"`
+	Status          string `json:"status" validate:"required"`
+	// Services limits the promoted services, all snapshot services are promoted if empty
+	Services []string `json:"services"`
+}
+
+type SnapshotUnchangedService struct {
+	ServiceId string `json:"serviceId"`
+	PackageId string `json:"packageId"`
+	Version   string `json:"version"`
+}
+
+const SnapshotsSortByCreatedAt = "createdAt"
+const SnapshotsSortByVersion = "version"
+
+// SnapshotListReq filters snapshots, filters by status and text are applied by APIHUB, the rest of filters are applied to the loaded versions
+type SnapshotListReq struct {
+	Page          int
+	Limit         int
+	Status        string
+	CreatedBy     string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	TextFilter    string
+	SortBy        string
+	SortOrder     string
+}
+
+// UpdateSnapshotReq changes the snapshot dashboard version, fields which are not set are not changed
+type UpdateSnapshotReq struct {
+	Status *string   `json:"status"`
+	Labels *[]string `json:"labels"`
+}
+
+type DeleteSnapshotResponse struct {
+	PackageId string `json:"packageId"`
+	Version   string `json:"version"`
+	// DeletedServices are service versions published by the snapshot
+	DeletedServices []SnapshotServiceVersion `json:"deletedServices"`
+	// KeptServices are referenced by other snapshots or published by previous snapshots
+	KeptServices []SnapshotServiceVersion `json:"keptServices"`
+}
+
+type SnapshotServiceVersion struct {
+	ServiceId string `json:"serviceId"`
+	PackageId string `json:"packageId"`
+	Version   string `json:"version"`
 }
 
 type SnapshotsListResponse struct {
 	Snapshots []SnapshotListItem `json:"snapshots"`
 	PackageId string             `json:"packageId"`
+	// TotalCount is the number of snapshots matching the filters, it's set only if createdBy or creation date filters are used
+	// since APIHUB doesn't return the total number of versions and other requests are paged by APIHUB
+	TotalCount *int `json:"totalCount,omitempty"`
 }
 
 type SnapshotListItem struct {
-	Version           string    `json:"version"`
-	CreatedAt         time.Time `json:"createdAt"`
-	NotLatestRevision bool      `json:"notLatestRevision,omitempty"`
+	Version           string            `json:"version"`
+	CreatedAt         time.Time         `json:"createdAt"`
+	NotLatestRevision bool              `json:"notLatestRevision,omitempty"`
+	Status            string            `json:"status,omitempty"`
+	CreatedBy         *VersionCreatedBy `json:"createdBy,omitempty"`
+	Labels            []string          `json:"labels,omitempty"`
+	PreviousVersion   string            `json:"previousVersion,omitempty"`
+	// Summary is not set if it's failed to load it
+	Summary *SnapshotSummary `json:"summary,omitempty"`
+}
+
+type SnapshotSummary struct {
+	ServiceCount    int           `json:"serviceCount"`
+	BreakingChanges int           `json:"breakingChanges"`
+	Changes         ChangeSummary `json:"changes"`
 }
 
 type Snapshot struct {
@@ -63,9 +145,61 @@ type ServiceWithChanges struct {
 	ViewBaselineUrl          string         `json:"viewBaselineUrl,omitempty"`
 	BaselineFound            bool           `json:"baselineFound"`
 	BaselineVersionFound     bool           `json:"baselineVersionFound"`
+	BaselineMatch            *BaselineMatch `json:"baselineMatch,omitempty"`
 	ApiTypes                 []string       `json:"apiTypes"`
 }
 
 func MakeSnapshotDashboardIdByGroupId(groupId string) string {
 	return groupId + "." + utils.ToId("snapshot-dash")
 }
+
+type SnapshotServiceComparisonStatus string
+
+const SnapshotServiceAdded SnapshotServiceComparisonStatus = "added"
+const SnapshotServiceRemoved SnapshotServiceComparisonStatus = "removed"
+const SnapshotServiceChanged SnapshotServiceComparisonStatus = "changed"
+const SnapshotServiceUnchanged SnapshotServiceComparisonStatus = "unchanged"
+
+type SnapshotComparison struct {
+	From     SnapshotComparisonSide      `json:"from"`
+	To       SnapshotComparisonSide      `json:"to"`
+	Summary  SnapshotComparisonSummary   `json:"summary"`
+	Services []SnapshotServiceComparison `json:"services"`
+}
+
+type SnapshotComparisonSide struct {
+	CloudName       string `json:"cloudName,omitempty"`
+	Namespace       string `json:"namespace,omitempty"`
+	PackageId       string `json:"packageId"`
+	Version         string `json:"version"`
+	PublishedAt     string `json:"publishedAt"`
+	ViewSnapshotUrl string `json:"viewSnapshotUrl"`
+}
+
+type SnapshotComparisonSummary struct {
+	Added     int `json:"added"`
+	Removed   int `json:"removed"`
+	Changed   int `json:"changed"`
+	Unchanged int `json:"unchanged"`
+	// Changes are summed up by all changed services which have comparison
+	Changes ChangeSummary `json:"changes"`
+}
+
+type SnapshotServiceComparison struct {
+	Id            string                          `json:"id"`
+	Status        SnapshotServiceComparisonStatus `json:"status"`
+	FromPackageId string                          `json:"fromPackageId,omitempty"`
+	FromVersion   string                          `json:"fromVersion,omitempty"`
+	ToPackageId   string                          `json:"toPackageId,omitempty"`
+	ToVersion     string                          `json:"toVersion,omitempty"`
+	// Changes are empty if APIHUB has no comparison of the service versions yet
+	Changes        *ChangeSummary `json:"changes,omitempty"`
+	ViewChangesUrl string         `json:"viewChangesUrl,omitempty"`
+	ApiTypes       []string       `json:"apiTypes,omitempty"`
+}
+
+type SnapshotRef struct {
+	CloudName string `json:"cloudName"`
+	Namespace string `json:"namespace"`
+	Version   string `json:"version"`
+}
@@ -0,0 +1,80 @@
+package view
+
+import "time"
+
+type SnapshotApprovalStatus string
+
+const SnapshotApprovalPending SnapshotApprovalStatus = "pending"
+const SnapshotApprovalApproved SnapshotApprovalStatus = "approved"
+const SnapshotApprovalRejected SnapshotApprovalStatus = "rejected"
+
+type SnapshotApprovalAction string
+
+const SnapshotApprovalActionSubmitted SnapshotApprovalAction = "submitted"
+const SnapshotApprovalActionApproved SnapshotApprovalAction = "approved"
+const SnapshotApprovalActionRejected SnapshotApprovalAction = "rejected"
+const SnapshotApprovalActionCommented SnapshotApprovalAction = "commented"
+
+// SnapshotApprovers are users who approve snapshots of the workspace, promotion to release requires approval if the list is not empty
+type SnapshotApprovers struct {
+	WorkspaceId string     `json:"workspaceId"`
+	Approvers   []string   `json:"approvers"`
+	UpdatedAt   *time.Time `json:"updatedAt,omitempty"`
+	UpdatedBy   string     `json:"updatedBy,omitempty"`
+}
+
+type UpdateSnapshotApproversReq struct {
+	Approvers []string `json:"approvers"`
+}
+
+type SubmitSnapshotApprovalReq struct {
+	Comment string `json:"comment"`
+}
+
+type SnapshotApprovalDecisionReq struct {
+	// Status is either approved or rejected
+	Status SnapshotApprovalStatus `json:"status" validate:"required"`
+	// Services limits the decision to the services, the decision applies to all snapshot services if empty
+	Services []string `json:"services"`
+	Comment  string   `json:"comment"`
+}
+
+type SnapshotApprovalCommentReq struct {
+	// ServiceId is set if the comment is about the service
+	ServiceId string `json:"serviceId"`
+	Comment   string `json:"comment" validate:"required"`
+}
+
+type SnapshotApproval struct {
+	ApprovalId  string                    `json:"approvalId"`
+	WorkspaceId string                    `json:"workspaceId"`
+	CloudName   string                    `json:"cloudName"`
+	Namespace   string                    `json:"namespace"`
+	PackageId   string                    `json:"packageId"`
+	Version     string                    `json:"version"`
+	Status      SnapshotApprovalStatus    `json:"status"`
+	CreatedAt   time.Time                 `json:"createdAt"`
+	CreatedBy   string                    `json:"createdBy"`
+	UpdatedAt   time.Time                 `json:"updatedAt"`
+	Services    []SnapshotApprovalService `json:"services"`
+	// Events is the audit trail of the approval
+	Events []SnapshotApprovalEvent `json:"events"`
+}
+
+type SnapshotApprovalService struct {
+	ServiceId string                 `json:"serviceId"`
+	PackageId string                 `json:"packageId"`
+	Version   string                 `json:"version"`
+	Status    SnapshotApprovalStatus `json:"status"`
+	DecidedAt *time.Time             `json:"decidedAt,omitempty"`
+	DecidedBy string                 `json:"decidedBy,omitempty"`
+	Comment   string                 `json:"comment,omitempty"`
+}
+
+type SnapshotApprovalEvent struct {
+	ServiceId string                 `json:"serviceId,omitempty"`
+	Action    SnapshotApprovalAction `json:"action"`
+	Comment   string                 `json:"comment,omitempty"`
+	CreatedAt time.Time              `json:"createdAt"`
+	CreatedBy string                 `json:"createdBy"`
+}
@@ -0,0 +1,53 @@
+package view
+
+import "time"
+
+const SnapshotBundleManifestFile = "manifest.json"
+
+// SnapshotBundleFormatVersion is incremented on incompatible changes of the bundle layout
+const SnapshotBundleFormatVersion = 1
+
+// SnapshotBundleManifest describes the content of the snapshot bundle archive.
+// Spec files of the services are stored in the archive by SnapshotBundleFile.Path
+type SnapshotBundleManifest struct {
+	FormatVersion int                     `json:"formatVersion"`
+	ApihubUrl     string                  `json:"apihubUrl"`
+	WorkspaceId   string                  `json:"workspaceId"`
+	CloudName     string                  `json:"cloudName"`
+	Namespace     string                  `json:"namespace"`
+	PackageId     string                  `json:"packageId"`
+	Version       string                  `json:"version"`
+	PublishedAt   string                  `json:"publishedAt"`
+	ExportedAt    time.Time               `json:"exportedAt"`
+	Services      []SnapshotBundleService `json:"services"`
+	// Snapshot contains change summaries of the services
+	Snapshot *Snapshot `json:"snapshot,omitempty"`
+}
+
+type SnapshotBundleService struct {
+	Id                       string               `json:"id"`
+	Name                     string               `json:"name"`
+	PackageId                string               `json:"packageId"`
+	Version                  string               `json:"version"`
+	Status                   string               `json:"status"`
+	Labels                   []string             `json:"labels"`
+	PreviousVersion          string               `json:"previousVersion,omitempty"`
+	PreviousVersionPackageId string               `json:"previousVersionPackageId,omitempty"`
+	Files                    []SnapshotBundleFile `json:"files"`
+}
+
+type SnapshotBundleFile struct {
+	FileId string `json:"fileId"`
+	Slug   string `json:"slug"`
+	Path   string `json:"path"`
+	Type   string `json:"type"`
+	Format string `json:"format"`
+}
+
+// ImportSnapshotBundleReq overrides values of the bundle manifest, manifest values are used if empty
+type ImportSnapshotBundleReq struct {
+	CloudName string
+	Namespace string
+	Version   string
+	Status    string
+}
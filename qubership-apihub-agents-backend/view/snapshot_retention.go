@@ -0,0 +1,60 @@
+package view
+
+import "time"
+
+// SnapshotRetentionReasonTtl means the snapshot is older than the policy ttl
+const SnapshotRetentionReasonTtl = "ttl"
+
+// SnapshotRetentionReasonAuthSecurityCheckTtl means the auth security check version is older than the policy auth security check ttl
+const SnapshotRetentionReasonAuthSecurityCheckTtl = "authSecurityCheckTtl"
+
+// SnapshotRetentionPolicy defines which snapshots of namespace groups are deleted by the snapshots cleanup job.
+// The policy with the most specific scope is applied to the namespace group: cloud and namespace, cloud, whole workspace.
+// A snapshot is deleted when it is older than the ttl and is not kept by any of the keep rules
+type SnapshotRetentionPolicy struct {
+	// CloudName and Namespace are the policy scope, the policy is applied to the whole workspace if both are empty
+	CloudName string `json:"cloudName,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	// TtlDays is SNAPSHOTS_TTL_DAYS if not set, 0 means snapshots are never deleted
+	TtlDays *int `json:"ttlDays,omitempty"`
+	// AuthSecurityCheckTtlDays is applied to versions created by auth security checks, TtlDays is used if not set
+	AuthSecurityCheckTtlDays *int `json:"authSecurityCheckTtlDays,omitempty"`
+	// KeepLast is the number of latest snapshots which are kept regardless of ttl
+	KeepLast int `json:"keepLast,omitempty"`
+	// KeepWeekly is the number of last weeks for each of which the latest snapshot is kept
+	KeepWeekly int `json:"keepWeekly,omitempty"`
+	// KeepLabels and KeepStatuses keep snapshots with any of the labels or statuses forever
+	KeepLabels   []string `json:"keepLabels,omitempty"`
+	KeepStatuses []string `json:"keepStatuses,omitempty"`
+}
+
+type SnapshotRetentionPolicies struct {
+	WorkspaceId string                    `json:"workspaceId"`
+	Policies    []SnapshotRetentionPolicy `json:"policies"`
+	UpdatedAt   *time.Time                `json:"updatedAt,omitempty"`
+	UpdatedBy   string                    `json:"updatedBy,omitempty"`
+}
+
+type UpdateSnapshotRetentionPoliciesReq struct {
+	Policies []SnapshotRetentionPolicy `json:"policies" validate:"dive"`
+}
+
+// ExpiredSnapshot is the snapshot which is deleted by the next cleanup job run
+type ExpiredSnapshot struct {
+	CloudName string    `json:"cloudName"`
+	Namespace string    `json:"namespace"`
+	GroupId   string    `json:"groupId"`
+	PackageId string    `json:"packageId"`
+	Version   string    `json:"version"`
+	Status    string    `json:"status"`
+	Labels    []string  `json:"labels"`
+	CreatedAt time.Time `json:"createdAt"`
+	Reason    string    `json:"reason"`
+}
+
+type SnapshotRetentionDryRun struct {
+	WorkspaceId string `json:"workspaceId"`
+	// PoliciesConfigured is false if the workspace has no retention policies and only SNAPSHOTS_TTL_DAYS is applied
+	PoliciesConfigured bool              `json:"policiesConfigured"`
+	Snapshots          []ExpiredSnapshot `json:"snapshots"`
+}
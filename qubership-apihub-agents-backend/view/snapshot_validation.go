@@ -0,0 +1,63 @@
+package view
+
+type SnapshotCheckStatus string
+
+const SnapshotCheckPassed SnapshotCheckStatus = "passed"
+const SnapshotCheckFailed SnapshotCheckStatus = "failed"
+
+// SnapshotCheckWarning means that the snapshot can be created, but the result may differ from the expected one
+const SnapshotCheckWarning SnapshotCheckStatus = "warning"
+
+// SnapshotCheckSkipped means that the check was not run because the previous checks failed or it's not applicable
+const SnapshotCheckSkipped SnapshotCheckStatus = "skipped"
+
+const SnapshotCheckWorkspaceExists = "workspaceExists"
+const SnapshotCheckDiscoveryComplete = "discoveryComplete"
+const SnapshotCheckServicesSelected = "servicesSelected"
+const SnapshotCheckVersionName = "versionName"
+const SnapshotCheckGroupHierarchy = "groupHierarchy"
+const SnapshotCheckServiceDiscovered = "serviceDiscovered"
+const SnapshotCheckDocuments = "documents"
+const SnapshotCheckBaseline = "baseline"
+const SnapshotCheckPreviousVersion = "previousVersion"
+const SnapshotCheckSpecsDownloadable = "specsDownloadable"
+const SnapshotCheckSpecsValid = "specsValid"
+const SnapshotCheckPromotePermission = "promotePermission"
+
+type SnapshotCheck struct {
+	Name    string              `json:"name"`
+	Status  SnapshotCheckStatus `json:"status"`
+	Details string              `json:"details,omitempty"`
+}
+
+type SnapshotValidation struct {
+	Valid bool `json:"valid"`
+	// Version is the snapshot version, generated by the workspace template if not set in the request
+	Version  string                      `json:"version,omitempty"`
+	Checks   []SnapshotCheck             `json:"checks"`
+	Services []SnapshotServiceValidation `json:"services"`
+}
+
+type SnapshotServiceValidation struct {
+	Id string `json:"id"`
+	// Included is false for services which will not be included to the snapshot, e.g. services without documents
+	Included bool            `json:"included"`
+	Valid    bool            `json:"valid"`
+	Checks   []SnapshotCheck `json:"checks"`
+}
+
+func MakeSnapshotCheck(name string, err error) SnapshotCheck {
+	if err != nil {
+		return SnapshotCheck{Name: name, Status: SnapshotCheckFailed, Details: err.Error()}
+	}
+	return SnapshotCheck{Name: name, Status: SnapshotCheckPassed}
+}
+
+func IsSnapshotChecksPassed(checks []SnapshotCheck) bool {
+	for _, check := range checks {
+		if check.Status == SnapshotCheckFailed {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,17 @@
+package view
+
+import "time"
+
+// SnapshotVersionTemplate is used to generate the snapshot version when it is not set in the create snapshot request.
+// Supported placeholders: {{yyyy}}, {{MM}}, {{dd}} (UTC date), {{cloud}}, {{namespace}},
+// {{label:<name>}} (value of the service label, e.g. git tag) and {{n}} (auto-incremented counter)
+type SnapshotVersionTemplate struct {
+	WorkspaceId string     `json:"workspaceId"`
+	Template    string     `json:"template"`
+	UpdatedAt   *time.Time `json:"updatedAt,omitempty"`
+	UpdatedBy   string     `json:"updatedBy,omitempty"`
+}
+
+type UpdateSnapshotVersionTemplateReq struct {
+	Template string `json:"template" validate:"required"`
+}
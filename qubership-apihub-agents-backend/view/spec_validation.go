@@ -0,0 +1,53 @@
+package view
+
+import "time"
+
+type SpecFindingSeverity string
+
+const SpecFindingError SpecFindingSeverity = "error"
+const SpecFindingWarning SpecFindingSeverity = "warning"
+
+const SpecFindingRuleEmpty = "empty"
+const SpecFindingRuleSyntax = "syntax"
+const SpecFindingRuleStructure = "structure"
+const SpecFindingRuleUnresolvedRef = "unresolvedRef"
+const SpecFindingRuleExternalRef = "externalRef"
+
+type SpecFinding struct {
+	FileId   string              `json:"fileId"`
+	Severity SpecFindingSeverity `json:"severity"`
+	// Rule is either one of built-in rules or the name of the workspace lint rule
+	Rule    string `json:"rule"`
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+}
+
+type ServiceSpecValidation struct {
+	ServiceId string        `json:"serviceId"`
+	Errors    int           `json:"errors"`
+	Warnings  int           `json:"warnings"`
+	Findings  []SpecFinding `json:"findings"`
+}
+
+// SpecLintRule checks the value in the structured document (OpenAPI, JSON schema) by JSON pointer
+type SpecLintRule struct {
+	Name string `json:"name" validate:"required"`
+	// DocumentTypes limits the rule by document types, e.g. openapi-3-0. The rule applies to all structured documents if empty
+	DocumentTypes []string `json:"documentTypes,omitempty"`
+	// Path is JSON pointer to the value which must exist, e.g. /info/description
+	Path string `json:"path" validate:"required"`
+	// Pattern is the regular expression the value must match, the value is not checked if empty
+	Pattern  string              `json:"pattern,omitempty"`
+	Severity SpecFindingSeverity `json:"severity,omitempty"`
+}
+
+type SpecLintRules struct {
+	WorkspaceId string         `json:"workspaceId"`
+	Rules       []SpecLintRule `json:"rules"`
+	UpdatedAt   *time.Time     `json:"updatedAt,omitempty"`
+	UpdatedBy   string         `json:"updatedBy,omitempty"`
+}
+
+type UpdateSpecLintRulesReq struct {
+	Rules []SpecLintRule `json:"rules" validate:"dive"`
+}
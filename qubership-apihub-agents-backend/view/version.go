@@ -5,9 +5,12 @@ import "time"
 type VersionStatus string
 
 const DraftStatus VersionStatus = "draft"
+const ReleaseStatus VersionStatus = "release"
+const ArchivedStatus VersionStatus = "archived"
 
 const VersionSortByCreatedAt = "createdAt"
 
+const VersionSortOrderAsc = "asc"
 const VersionSortOrderDesc = "desc"
 
 type ApiType string
@@ -29,6 +32,7 @@ type VersionContent struct {
 	PackageId                string                 `json:"packageId"`
 	Version                  string                 `json:"version"`
 	NotLatestRevision        bool                   `json:"notLatestRevision,omitempty"`
+	Metadata                 map[string]interface{} `json:"metadata,omitempty"`
 }
 
 type VersionCreatedBy struct {
@@ -92,6 +96,43 @@ type PublishedVersionsView struct {
 	Versions []PublishedVersionListView `json:"versions"`
 }
 
+// VersionChangesSummary is the result of comparison of two package versions
+type VersionChangesSummary struct {
+	OperationTypes []VersionOperationType `json:"operationTypes"`
+}
+
+// GetChangeSummary returns changes summed up by all api types
+func (v VersionChangesSummary) GetChangeSummary() ChangeSummary {
+	result := ChangeSummary{}
+	for _, operationType := range v.OperationTypes {
+		if operationType.ChangesSummary != nil {
+			result.Add(*operationType.ChangesSummary)
+		}
+	}
+	return result
+}
+
+func (c *ChangeSummary) Add(other ChangeSummary) {
+	c.Breaking += other.Breaking
+	c.SemiBreaking += other.SemiBreaking
+	c.Deprecated += other.Deprecated
+	c.NonBreaking += other.NonBreaking
+	c.Annotation += other.Annotation
+	c.Unclassified += other.Unclassified
+}
+
+type VersionDocuments struct {
+	Documents []VersionDocument `json:"documents"`
+}
+
+type VersionDocument struct {
+	FileId string `json:"fileId"`
+	Slug   string `json:"slug"`
+	Type   string `json:"type"`
+	Format string `json:"format"`
+	Title  string `json:"title"`
+}
+
 type VersionReferences struct {
 	References []VersionReference           `json:"references"`
 	Packages   map[string]PackageVersionRef `json:"packages,omitempty"`
@@ -115,6 +156,12 @@ type PackageVersionRef struct {
 	NotLatestRevision bool       `json:"notLatestRevision,omitempty"`
 }
 
+// VersionPatchReq changes the version meta, fields which are not set are not changed
+type VersionPatchReq struct {
+	Status        *string   `json:"status,omitempty"`
+	VersionLabels *[]string `json:"versionLabels,omitempty"`
+}
+
 type DeleteVersionsRecursivelyReq struct {
 	OlderThanDate time.Time `json:"olderThanDate"`
 }
@@ -0,0 +1,131 @@
+package view
+
+import "time"
+
+type WorkspaceCopyReq struct {
+	// SrcWorkspaceId is the workspace to copy packages structure from, the default workspace is used if empty
+	SrcWorkspaceId string   `json:"srcWorkspaceId"`
+	ServiceNames   []string `json:"serviceNames" validate:"required"`
+}
+
+type WorkspaceCopyStatus string
+
+const WorkspaceCopyStatusRunning WorkspaceCopyStatus = "running"
+const WorkspaceCopyStatusComplete WorkspaceCopyStatus = "complete"
+const WorkspaceCopyStatusFailed WorkspaceCopyStatus = "failed"
+const WorkspaceCopyStatusRolledBack WorkspaceCopyStatus = "rolledBack"
+
+type WorkspaceCopyPackageStatus string
+
+const WorkspaceCopyPackageStatusPlanned WorkspaceCopyPackageStatus = "planned"
+const WorkspaceCopyPackageStatusCreated WorkspaceCopyPackageStatus = "created"
+
+// WorkspaceCopyPackageStatusExisting means that the package was created by someone else before the copy got to it
+const WorkspaceCopyPackageStatusExisting WorkspaceCopyPackageStatus = "existing"
+const WorkspaceCopyPackageStatusFailed WorkspaceCopyPackageStatus = "failed"
+const WorkspaceCopyPackageStatusRolledBack WorkspaceCopyPackageStatus = "rolledBack"
+
+type WorkspaceCopyPlan struct {
+	SrcWorkspaceId string `json:"srcWorkspaceId"`
+	DstWorkspaceId string `json:"dstWorkspaceId"`
+	DefaultRole    string `json:"defaultRole,omitempty"`
+	// Packages to create in the order of creation, groups go before their children
+	Packages []WorkspaceCopyPackage `json:"packages"`
+	// ExistingServices already have a package in the destination workspace
+	ExistingServices []string `json:"existingServices"`
+	// MissingServices have no package in the source workspace
+	MissingServices []string `json:"missingServices"`
+}
+
+type WorkspaceCopyPackage struct {
+	PackageId             string                     `json:"packageId"`
+	SrcPackageId          string                     `json:"srcPackageId,omitempty"`
+	ParentId              string                     `json:"parentId"`
+	Kind                  string                     `json:"kind"`
+	Name                  string                     `json:"name"`
+	Alias                 string                     `json:"alias"`
+	Description           string                     `json:"description,omitempty"`
+	ServiceName           string                     `json:"serviceName,omitempty"`
+	ImageUrl              string                     `json:"imageUrl,omitempty"`
+	ReleaseVersionPattern string                     `json:"releaseVersionPattern,omitempty"`
+	Status                WorkspaceCopyPackageStatus `json:"status"`
+	Details               string                     `json:"details,omitempty"`
+}
+
+type WorkspaceCopy struct {
+	// CopyId is empty if there was nothing to copy
+	CopyId         string                 `json:"copyId,omitempty"`
+	SrcWorkspaceId string                 `json:"srcWorkspaceId"`
+	DstWorkspaceId string                 `json:"dstWorkspaceId"`
+	DefaultRole    string                 `json:"defaultRole,omitempty"`
+	Status         WorkspaceCopyStatus    `json:"status"`
+	Details        string                 `json:"details,omitempty"`
+	CreatedAt      time.Time              `json:"createdAt"`
+	CreatedBy      string                 `json:"createdBy,omitempty"`
+	FinishedAt     *time.Time             `json:"finishedAt,omitempty"`
+	Packages       []WorkspaceCopyPackage `json:"packages,omitempty"`
+}
+
+type WorkspaceCopies struct {
+	Copies []WorkspaceCopy `json:"copies"`
+}
+
+type WorkspaceSyncReq struct {
+	// SrcWorkspaceId is the workspace the packages were copied from, the default workspace is used if empty
+	SrcWorkspaceId string `json:"srcWorkspaceId"`
+	// ServiceNames limits the sync by service packages and their parent groups, the whole copied tree is synced if empty
+	ServiceNames []string `json:"serviceNames"`
+	DryRun       bool     `json:"dryRun"`
+}
+
+type PackageDriftType string
+
+// PackageDriftMetadata means that the package metadata differs from the source package and can be synced
+const PackageDriftMetadata PackageDriftType = "metadataChanged"
+
+// PackageDriftMoved means that the service package is located under another group in the source workspace.
+// Package id cannot be changed, so group path changes are never synced and the package has to be moved manually.
+// Sync reports such packages with an error, metadata of moved packages is not updated either
+const PackageDriftMoved PackageDriftType = "movedInSource"
+
+// PackageDriftMissing means that the source package doesn't exist anymore
+const PackageDriftMissing PackageDriftType = "missingInSource"
+
+type WorkspaceDriftReport struct {
+	SrcWorkspaceId  string         `json:"srcWorkspaceId"`
+	DstWorkspaceId  string         `json:"dstWorkspaceId"`
+	DryRun          bool           `json:"dryRun"`
+	CheckedPackages int            `json:"checkedPackages"`
+	Packages        []PackageDrift `json:"packages"`
+}
+
+type PackageDrift struct {
+	PackageId    string             `json:"packageId"`
+	SrcPackageId string             `json:"srcPackageId"`
+	Kind         string             `json:"kind"`
+	ServiceName  string             `json:"serviceName,omitempty"`
+	Type         PackageDriftType   `json:"type"`
+	Fields       []PackageFieldDiff `json:"fields,omitempty"`
+	Synced       bool               `json:"synced"`
+	Error        string             `json:"error,omitempty"`
+}
+
+type PackageFieldDiff struct {
+	Field    string `json:"field"`
+	SrcValue string `json:"srcValue"`
+	DstValue string `json:"dstValue"`
+}
+
+func (p WorkspaceCopyPackage) MakeCreateRequest(defaultRole string) PackageCreateRequest {
+	return PackageCreateRequest{
+		ParentId:              p.ParentId,
+		Kind:                  p.Kind,
+		Name:                  p.Name,
+		Alias:                 p.Alias,
+		Description:           p.Description,
+		ServiceName:           p.ServiceName,
+		ImageUrl:              p.ImageUrl,
+		DefaultRole:           defaultRole,
+		ReleaseVersionPattern: p.ReleaseVersionPattern,
+	}
+}